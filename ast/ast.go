@@ -63,10 +63,13 @@ func (p *Program) String() string {
 //			Value: 5,
 //		},
 //	},
+//
+// Pattern is set instead of Name for destructuring (let [a, b] = xs; let {name} = person;)
 type LetStatement struct {
-	Token token.Token // token.LET token
-	Name  *Identifier
-	Value Expression
+	Token   token.Token // token.LET token
+	Name    *Identifier
+	Pattern Pattern
+	Value   Expression
 }
 
 func (ls *LetStatement) statementNode()       {}
@@ -75,7 +78,11 @@ func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	if ls.Pattern != nil {
+		out.WriteString(ls.Pattern.String())
+	} else {
+		out.WriteString(ls.Name.String())
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -137,6 +144,117 @@ func (es *ExpressionStatement) String() string {
 	return ""
 }
 
+// throw "missing input";
+type ThrowStatement struct {
+	Token token.Token // token.THROW
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode()       {}
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *ThrowStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ts.TokenLiteral() + " ")
+	if ts.Value != nil {
+		out.WriteString(ts.Value.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// config.port = 8080;
+// 代入できるのはGoの値(object.FieldSetter)のfieldだけ
+type AssignStatement struct {
+	Token  token.Token // token.ASSIGN
+	Target *FieldExpression
+	Value  Expression
+}
+
+func (as *AssignStatement) statementNode()       {}
+func (as *AssignStatement) TokenLiteral() string { return as.Token.Literal }
+func (as *AssignStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(as.Target.String() + " = ")
+	if as.Value != nil {
+		out.WriteString(as.Value.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// struct Point { x, y }
+type StructStatement struct {
+	Token  token.Token // token.STRUCT
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (ss *StructStatement) statementNode()       {}
+func (ss *StructStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *StructStatement) String() string {
+	fields := []string{}
+	for _, f := range ss.Fields {
+		fields = append(fields, f.String())
+	}
+	return "struct " + ss.Name.String() + " { " + strings.Join(fields, ", ") + " }"
+}
+
+// enum Shape { Circle(r), Rect(w, h), Empty }
+type EnumStatement struct {
+	Token    token.Token // token.ENUM
+	Name     *Identifier
+	Variants []*EnumVariant
+}
+
+func (es *EnumStatement) statementNode()       {}
+func (es *EnumStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *EnumStatement) String() string {
+	variants := []string{}
+	for _, v := range es.Variants {
+		variants = append(variants, v.String())
+	}
+	return "enum " + es.Name.String() + " { " + strings.Join(variants, ", ") + " }"
+}
+
+// Circle(r) or Empty (Fields is empty)
+type EnumVariant struct {
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (ev *EnumVariant) String() string {
+	if len(ev.Fields) == 0 {
+		return ev.Name.String()
+	}
+
+	fields := []string{}
+	for _, f := range ev.Fields {
+		fields = append(fields, f.String())
+	}
+	return ev.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
+// impl Point { fn len(self) { ... } }
+type ImplStatement struct {
+	Token   token.Token // token.IMPL
+	Name    *Identifier
+	Methods []*FunctionLiteral
+}
+
+func (is *ImplStatement) statementNode()       {}
+func (is *ImplStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImplStatement) String() string {
+	methods := []string{}
+	for _, m := range is.Methods {
+		methods = append(methods, m.String())
+	}
+	return "impl " + is.Name.String() + " { " + strings.Join(methods, " ") + " }"
+}
+
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
@@ -167,11 +285,49 @@ func (bs *BlockStatement) String() string {
 type Identifier struct {
 	Token token.Token // token.IDENT token
 	Value string
+	Type  *TypeExpression // 型注釈(let x: int や fn(a: int)のとき)。なければnil
+
+	// resolverが割り当てた変数の位置(Resolvedがfalseなら名前で探す)
+	Resolved bool
+	Depth    int // 何個外側の環境か
+	Slot     int // 環境の中の何番目か
 }
 
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
-func (i *Identifier) String() string       { return i.Value }
+func (i *Identifier) String() string {
+	if i.Type != nil {
+		return i.Value + ": " + i.Type.String()
+	}
+	return i.Value
+}
+
+// 型注釈
+// int, bool, Point のような名前か fn(int, int) -> int のような関数型
+type TypeExpression struct {
+	Token      token.Token
+	Name       string
+	Parameters []*TypeExpression // 関数型のときだけ
+	Return     *TypeExpression   // 関数型のときだけ(省略したらnil)
+}
+
+func (te *TypeExpression) TokenLiteral() string { return te.Token.Literal }
+func (te *TypeExpression) String() string {
+	if te.Token.Type != token.FUNCTION {
+		return te.Name
+	}
+
+	params := []string{}
+	for _, p := range te.Parameters {
+		params = append(params, p.String())
+	}
+
+	out := "fn(" + strings.Join(params, ", ") + ")"
+	if te.Return != nil {
+		out += " -> " + te.Return.String()
+	}
+	return out
+}
 
 type IntegerLiteral struct {
 	Token token.Token
@@ -182,6 +338,24 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (sl *StringLiteral) expressionNode()      {}
+func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) String() string       { return sl.Token.Literal }
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -191,11 +365,97 @@ func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
 func (b *Boolean) String() string       { return b.Token.Literal }
 
+// [1, 2 * 2, fn(x) { x }]
+type ArrayLiteral struct {
+	Token    token.Token // token.LBRACKET
+	Elements []Expression
+}
+
+func (al *ArrayLiteral) expressionNode()      {}
+func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+// {"name": "monkey", 1: true}
+// Keys[i] and Values[i] are a pair (kept in source order)
+type HashLiteral struct {
+	Token  token.Token // token.LBRACE
+	Keys   []Expression
+	Values []Expression
+}
+
+func (hl *HashLiteral) expressionNode()      {}
+func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for i, key := range hl.Keys {
+		pairs = append(pairs, key.String()+":"+hl.Values[i].String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// Point { x: 1, y: 2 }
+// Fields[i] is set to Values[i]
+type StructLiteral struct {
+	Token  token.Token // token.LBRACE
+	Name   *Identifier
+	Fields []*Identifier
+	Values []Expression
+}
+
+func (sl *StructLiteral) expressionNode()      {}
+func (sl *StructLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StructLiteral) String() string {
+	fields := []string{}
+	for i, f := range sl.Fields {
+		fields = append(fields, f.String()+": "+sl.Values[i].String())
+	}
+	return sl.Name.String() + " { " + strings.Join(fields, ", ") + " }"
+}
+
+// point.x, point.len()
+type FieldExpression struct {
+	Token token.Token // token.DOT
+	Left  Expression
+	Field *Identifier
+}
+
+func (fe *FieldExpression) expressionNode()      {}
+func (fe *FieldExpression) TokenLiteral() string { return fe.Token.Literal }
+func (fe *FieldExpression) String() string {
+	return "(" + fe.Left.String() + "." + fe.Field.String() + ")"
+}
+
 // define function
+// Name is filled in by the parser when the literal is bound with let (let add = fn...)
+// It only names the function in stack frames; String() leaves it out
 type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
 	Body       *BlockStatement
+	Name       string
+	ReturnType *TypeExpression // -> int で注釈したとき
+	Generator  bool            // fn*
+	Async      bool            // async fn
 }
 
 func (fl *FunctionLiteral) expressionNode()      {}
@@ -208,10 +468,19 @@ func (fl *FunctionLiteral) String() string {
 		params = append(params, p.String())
 	}
 
+	if fl.Async {
+		out.WriteString("async ")
+	}
 	out.WriteString(fl.TokenLiteral())
+	if fl.Generator {
+		out.WriteString("*")
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ","))
 	out.WriteString(") ")
+	if fl.ReturnType != nil {
+		out.WriteString("-> " + fl.ReturnType.String() + " ")
+	}
 	out.WriteString(fl.Body.String())
 
 	return out.String()
@@ -298,6 +567,25 @@ func (pe *PrefixExpression) String() string {
 	return out.String()
 }
 
+// result? (unwrap ok or return err from the function)
+type PostfixExpression struct {
+	Token    token.Token // token.QUESTION
+	Left     Expression
+	Operator string
+}
+
+func (pe *PostfixExpression) expressionNode()      {}
+func (pe *PostfixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PostfixExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(pe.Operator)
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type InfixExpression struct {
 	Token    token.Token // operator token like +, -, ...etc
 	Left     Expression
@@ -318,3 +606,359 @@ func (oe *InfixExpression) String() string {
 
 	return out.String()
 }
+
+// array[1], e["message"]
+type IndexExpression struct {
+	Token token.Token // token.LBRACKET
+	Left  Expression
+	Index Expression
+}
+
+func (ie *IndexExpression) expressionNode()      {}
+func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString("[")
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// try { ... } catch (e) { ... } finally { ... }
+// CatchParameter and Catch are nil when there is no catch clause, Finally is nil when there is no finally clause
+type TryExpression struct {
+	Token          token.Token // token.TRY
+	Block          *BlockStatement
+	CatchParameter *Identifier
+	Catch          *BlockStatement
+	Finally        *BlockStatement
+}
+
+func (te *TryExpression) expressionNode()      {}
+func (te *TryExpression) TokenLiteral() string { return te.Token.Literal }
+func (te *TryExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(te.Block.String())
+
+	if te.Catch != nil {
+		out.WriteString(" catch(")
+		out.WriteString(te.CatchParameter.String())
+		out.WriteString(") ")
+		out.WriteString(te.Catch.String())
+	}
+
+	if te.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(te.Finally.String())
+	}
+
+	return out.String()
+}
+
+// match (x) { 0 => "zero", 1 | 2 => "small", n if n < 0 => "negative", _ => "large" }
+type MatchExpression struct {
+	Token   token.Token // token.MATCH
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+func (me *MatchExpression) expressionNode()      {}
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	arms := []string{}
+	for _, a := range me.Arms {
+		arms = append(arms, a.String())
+	}
+
+	out.WriteString("match (")
+	out.WriteString(me.Subject.String())
+	out.WriteString(") { ")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// pattern if guard => body
+// Guard is nil when the arm has no guard
+type MatchArm struct {
+	Token   token.Token // token.FAT_ARROW
+	Pattern Pattern
+	Guard   Expression
+	Body    *BlockStatement
+}
+
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+
+	return out.String()
+}
+
+// select { v = recv(a) => v, send(b, 1) => "sent", _ => "nothing ready" }
+// 準備のできたcaseを一つ選んで実行する(Goのselectと同じ)
+type SelectExpression struct {
+	Token token.Token // token.SELECT
+	Cases []*SelectCase
+}
+
+func (se *SelectExpression) expressionNode()      {}
+func (se *SelectExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SelectExpression) String() string {
+	var out bytes.Buffer
+
+	cases := []string{}
+	for _, c := range se.Cases {
+		cases = append(cases, c.String())
+	}
+
+	out.WriteString("select { ")
+	out.WriteString(strings.Join(cases, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// recv(ch) => body, name = recv(ch) => body, send(ch, value) => body or _ => body
+// Channel is nil for the default case (_), Value is non-nil only for send
+type SelectCase struct {
+	Token   token.Token // token.FAT_ARROW
+	Name    *Identifier // recvで受け取った値を束縛する名前(なければnil)
+	Channel Expression
+	Value   Expression
+	Body    *BlockStatement
+}
+
+func (sc *SelectCase) IsDefault() bool { return sc.Channel == nil }
+func (sc *SelectCase) IsSend() bool    { return sc.Value != nil }
+
+func (sc *SelectCase) String() string {
+	var out bytes.Buffer
+
+	switch {
+	case sc.IsDefault():
+		out.WriteString("_")
+	case sc.IsSend():
+		out.WriteString("send(" + sc.Channel.String() + ", " + sc.Value.String() + ")")
+	default:
+		if sc.Name != nil {
+			out.WriteString(sc.Name.String() + " = ")
+		}
+		out.WriteString("recv(" + sc.Channel.String() + ")")
+	}
+	out.WriteString(" => ")
+	out.WriteString(sc.Body.String())
+
+	return out.String()
+}
+
+// [PATTERN]
+// left hand side of a match arm
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// 1, -1, "foo", true
+type LiteralPattern struct {
+	Token token.Token
+	Value Expression // IntegerLiteral, StringLiteral or Boolean
+}
+
+func (lp *LiteralPattern) patternNode()         {}
+func (lp *LiteralPattern) TokenLiteral() string { return lp.Token.Literal }
+func (lp *LiteralPattern) String() string {
+	if il, ok := lp.Value.(*IntegerLiteral); ok && il.Value < 0 {
+		return "-" + il.String()
+	}
+	if sl, ok := lp.Value.(*StringLiteral); ok {
+		return `"` + sl.String() + `"`
+	}
+	return lp.Value.String()
+}
+
+// _
+type WildcardPattern struct {
+	Token token.Token
+}
+
+func (wp *WildcardPattern) patternNode()         {}
+func (wp *WildcardPattern) TokenLiteral() string { return wp.Token.Literal }
+func (wp *WildcardPattern) String() string       { return "_" }
+
+// x (matches anything and binds it to x)
+type BindingPattern struct {
+	Token token.Token
+	Name  *Identifier
+}
+
+func (bp *BindingPattern) patternNode()         {}
+func (bp *BindingPattern) TokenLiteral() string { return bp.Token.Literal }
+func (bp *BindingPattern) String() string       { return bp.Name.String() }
+
+// 1 | 2 | 3
+type AlternativePattern struct {
+	Token        token.Token // token.PIPE
+	Alternatives []Pattern
+}
+
+func (ap *AlternativePattern) patternNode()         {}
+func (ap *AlternativePattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *AlternativePattern) String() string {
+	alternatives := []string{}
+	for _, a := range ap.Alternatives {
+		alternatives = append(alternatives, a.String())
+	}
+	return strings.Join(alternatives, " | ")
+}
+
+// ok(x), err(_)
+type ConstructorPattern struct {
+	Token     token.Token
+	Name      *Identifier
+	Arguments []Pattern
+}
+
+func (cp *ConstructorPattern) patternNode()         {}
+func (cp *ConstructorPattern) TokenLiteral() string { return cp.Token.Literal }
+func (cp *ConstructorPattern) String() string {
+	args := []string{}
+	for _, a := range cp.Arguments {
+		args = append(args, a.String())
+	}
+	return cp.Name.String() + "(" + strings.Join(args, ", ") + ")"
+}
+
+// [a, [b, c], ...rest]
+// Rest is nil when there is no ...rest
+type ArrayPattern struct {
+	Token    token.Token // token.LBRACKET
+	Elements []Pattern
+	Rest     *Identifier
+}
+
+func (ap *ArrayPattern) patternNode()         {}
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) String() string {
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// {name, age: years, pos: [x, y]}
+// Keys[i] is matched against Values[i]. {name} is short for {name: name}
+type HashPattern struct {
+	Token  token.Token // token.LBRACE
+	Keys   []string
+	Values []Pattern
+}
+
+func (hp *HashPattern) patternNode()         {}
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashPattern) String() string {
+	entries := []string{}
+	for i, key := range hp.Keys {
+		entries = append(entries, key+": "+hp.Values[i].String())
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// x = 0 (Default is used when the element or key is missing)
+type DefaultPattern struct {
+	Token   token.Token // token.ASSIGN
+	Pattern Pattern
+	Default Expression
+}
+
+func (dp *DefaultPattern) patternNode()         {}
+func (dp *DefaultPattern) TokenLiteral() string { return dp.Token.Literal }
+func (dp *DefaultPattern) String() string {
+	return dp.Pattern.String() + " = " + dp.Default.String()
+}
+
+// patternが束縛する名前(出てきた順)
+func PatternIdentifiers(pattern Pattern) []*Identifier {
+	switch pattern := pattern.(type) {
+	case *BindingPattern:
+		return []*Identifier{pattern.Name}
+	case *AlternativePattern:
+		idents := []*Identifier{}
+		for _, alt := range pattern.Alternatives {
+			idents = append(idents, PatternIdentifiers(alt)...)
+		}
+		return idents
+	case *ConstructorPattern:
+		idents := []*Identifier{}
+		for _, arg := range pattern.Arguments {
+			idents = append(idents, PatternIdentifiers(arg)...)
+		}
+		return idents
+	case *ArrayPattern:
+		idents := []*Identifier{}
+		for _, el := range pattern.Elements {
+			idents = append(idents, PatternIdentifiers(el)...)
+		}
+		if pattern.Rest != nil {
+			idents = append(idents, pattern.Rest)
+		}
+		return idents
+	case *HashPattern:
+		idents := []*Identifier{}
+		for _, v := range pattern.Values {
+			idents = append(idents, PatternIdentifiers(v)...)
+		}
+		return idents
+	case *DefaultPattern:
+		return PatternIdentifiers(pattern.Pattern)
+	default:
+		return nil
+	}
+}
+
+// yield value (fn*の中だけで書ける)
+// 値はnext(v)で渡されたv
+type YieldExpression struct {
+	Token token.Token // token.YIELD
+	Value Expression  // yield; のときはnil
+}
+
+func (ye *YieldExpression) expressionNode()      {}
+func (ye *YieldExpression) TokenLiteral() string { return ye.Token.Literal }
+func (ye *YieldExpression) String() string {
+	if ye.Value == nil {
+		return "yield"
+	}
+	return "yield " + ye.Value.String()
+}
+
+// await promise
+type AwaitExpression struct {
+	Token token.Token // token.AWAIT
+	Value Expression
+}
+
+func (ae *AwaitExpression) expressionNode()      {}
+func (ae *AwaitExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AwaitExpression) String() string {
+	return "(await " + ae.Value.String() + ")"
+}
@@ -0,0 +1,92 @@
+package monkey
+
+import (
+	"fmt"
+	"monkey/evaluator"
+	"monkey/object"
+	"reflect"
+)
+
+// HostObject exposes a pointer to a Go struct to Monkey.
+// Exported fields can be read with x.Field and written with x.Field = value,
+// and methods are called like x.Method(args) with their arguments and results
+// converted as for functions passed to ToObject.
+// Nested struct fields are exposed as HostObjects too, so writes to them reach
+// the original value.
+type HostObject struct {
+	value reflect.Value // a non-nil pointer to a struct
+}
+
+func (h *HostObject) Type() object.ObjectType { return object.ObjectType(h.value.Type().String()) }
+func (h *HostObject) Inspect() string         { return fmt.Sprintf("%+v", h.value.Interface()) }
+
+// Value returns the pointer the object wraps.
+func (h *HostObject) Value() any { return h.value.Interface() }
+
+func (h *HostObject) GetField(name string) (object.Object, bool) {
+	if method := h.value.MethodByName(name); method.IsValid() {
+		return goFunction(method), true
+	}
+
+	field, ok := h.field(name)
+	if !ok {
+		return nil, false
+	}
+	if field.Kind() == reflect.Struct {
+		return &HostObject{value: field.Addr()}, true
+	}
+
+	obj, err := ToObject(field.Interface())
+	if err != nil {
+		return &object.Error{Kind: object.HOST_ERROR, Message: err.Error()}, true
+	}
+	return obj, true
+}
+
+func (h *HostObject) SetField(name string, value object.Object) error {
+	field, ok := h.field(name)
+	if !ok {
+		return fmt.Errorf("unknown field %s on %s", name, h.Type())
+	}
+
+	v, err := fromObject(value, field.Type())
+	if err != nil {
+		return fmt.Errorf("cannot assign to %s: %s", name, err)
+	}
+	field.Set(v)
+	return nil
+}
+
+// field returns the exported field called name.
+func (h *HostObject) field(name string) (reflect.Value, bool) {
+	elem := h.value.Elem()
+	sf, ok := elem.Type().FieldByName(name)
+	if !ok || !sf.IsExported() {
+		return reflect.Value{}, false
+	}
+	return elem.FieldByIndex(sf.Index), true
+}
+
+// Two HostObjects are equal when they wrap the same pointer.
+func (h *HostObject) InfixOperator(operator string, right object.Object) (object.Object, bool) {
+	other, ok := right.(*HostObject)
+	if !ok {
+		return nil, false
+	}
+
+	same := h.value.Type() == other.value.Type() && h.value.Pointer() == other.value.Pointer()
+	switch operator {
+	case "==":
+		return nativeBool(same), true
+	case "!=":
+		return nativeBool(!same), true
+	}
+	return nil, false
+}
+
+func nativeBool(b bool) object.Object {
+	if b {
+		return evaluator.TRUE
+	}
+	return evaluator.FALSE
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/parser"
+	"monkey/repl"
+	"monkey/types"
+	"os"
+	"os/user"
+	"strings"
+)
+
+func main() {
+	printAST := flag.Bool("print-ast", false, "print the optimized AST of each line before evaluating it")
+	deterministic := flag.Bool("deterministic", false, "use a fake clock, a seeded random generator and no environment variables")
+	seed := flag.Int64("seed", 0, "random seed for --deterministic")
+	var allowRead, allowWrite dirList
+	flag.Var(&allowRead, "allow-read", "let scripts read files under `dir` (repeatable, or comma-separated)")
+	flag.Var(&allowWrite, "allow-write", "let scripts write files under `dir` (repeatable, or comma-separated)")
+	allowExec := flag.Bool("allow-exec", false, "let scripts run commands with exec")
+	allowEnv := flag.Bool("allow-env", false, "let scripts read environment variables with env")
+	flag.Parse()
+
+	// monkey check file.mk で型を推論して表示する
+	if flag.NArg() > 0 && flag.Arg(0) == "check" {
+		os.Exit(check(flag.Args()[1:]))
+	}
+
+	perms := evaluator.NewPermissions()
+	for _, dir := range allowRead {
+		if err := perms.AllowRead(dir); err != nil {
+			fmt.Fprintln(os.Stderr, "--allow-read:", err)
+			os.Exit(2)
+		}
+	}
+	for _, dir := range allowWrite {
+		if err := perms.AllowWrite(dir); err != nil {
+			fmt.Fprintln(os.Stderr, "--allow-write:", err)
+			os.Exit(2)
+		}
+	}
+	if *allowExec {
+		perms.AllowExec()
+	}
+	if *allowEnv {
+		perms.AllowEnv()
+	}
+
+	user, err := user.Current()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Hello %s! This is the Monkey programming language!\n", user.Username)
+	fmt.Printf("Feel free to type in commands\n")
+	os.Exit(repl.Start(os.Stdin, os.Stdout, repl.Options{PrintAST: *printAST, Deterministic: *deterministic, Seed: *seed, Permissions: perms}))
+}
+
+// --allow-read=a,b --allow-read=c
+type dirList []string
+
+func (d *dirList) String() string { return strings.Join(*d, ",") }
+func (d *dirList) Set(value string) error {
+	*d = append(*d, strings.Split(value, ",")...)
+	return nil
+}
+
+func check(files []string) int {
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: monkey check file.mk...")
+		return 2
+	}
+
+	status := 0
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+
+		p := parser.New(lexer.New(string(src)))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			for _, msg := range p.Errors() {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", file, msg)
+			}
+			status = 1
+			continue
+		}
+
+		bindings, errs := types.Infer(program)
+		for _, b := range bindings {
+			fmt.Println(b)
+		}
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%s:%s\n", file, err)
+			status = 1
+		}
+	}
+	return status
+}
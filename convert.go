@@ -0,0 +1,351 @@
+package monkey
+
+import (
+	"fmt"
+	"math"
+	"monkey/evaluator"
+	"monkey/object"
+	"reflect"
+)
+
+var (
+	anyType   = reflect.TypeOf((*any)(nil)).Elem()
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// ToObject converts a Go value to a Monkey value:
+//
+//	nil                      -> null
+//	bool                     -> true / false
+//	int, int8, ..., uint64   -> integer
+//	float32, float64         -> float
+//	string                   -> string
+//	slice, array             -> array
+//	map                      -> hash (keys must convert to integers, strings or booleans)
+//	func                     -> builtin function converting its arguments and results
+//	pointer to struct        -> HostObject (fields and methods are reachable from Monkey)
+//	struct                   -> HostObject wrapping a copy
+//	object.Object            -> itself
+//
+// A func whose last result is an error raises a HostError in Monkey when the error is not nil.
+// This is how Go values are bound into Monkey, so exposing a service's config is one line:
+//
+//	in.Set("config", &cfg)
+func ToObject(v any) (object.Object, error) {
+	switch v := v.(type) {
+	case nil:
+		return evaluator.NULL, nil
+	case object.Object:
+		return v, nil
+	case object.BuiltinFunction:
+		return &object.Builtin{Fn: v}, nil
+	case func(args ...object.Object) object.Object:
+		return &object.Builtin{Fn: v}, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return nativeBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return object.NewInteger(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows a Monkey integer", rv.Uint())
+		}
+		return object.NewInteger(int64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: rv.Float()}, nil
+	case reflect.String:
+		return &object.String{Value: rv.String()}, nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]object.Object, rv.Len())
+		for i := range elements {
+			el, err := ToObject(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = el
+		}
+		return &object.Array{Elements: elements}, nil
+	case reflect.Map:
+		pairs := make(map[object.HashKey]object.HashPair, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := ToObject(iter.Key().Interface())
+			if err != nil {
+				return nil, err
+			}
+			hashKey, ok := key.(object.Hashable)
+			if !ok {
+				return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+			}
+			value, err := ToObject(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			pairs[hashKey.HashKey()] = object.HashPair{Key: key, Value: value}
+		}
+		return &object.Hash{Pairs: pairs}, nil
+	case reflect.Func:
+		if rv.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return goFunction(rv), nil
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return evaluator.NULL, nil
+		}
+		if rv.Kind() == reflect.Pointer && rv.Elem().Kind() == reflect.Struct {
+			return &HostObject{value: rv}, nil
+		}
+	case reflect.Struct:
+		// a struct passed by value is copied, so writes do not reach the caller's value
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		return &HostObject{value: ptr}, nil
+	}
+
+	return nil, fmt.Errorf("cannot convert %T to a Monkey value", v)
+}
+
+// ToGo converts a Monkey value to a Go value:
+//
+//	null                              -> nil
+//	integer, boolean, string          -> int64, bool, string
+//	float                             -> float64
+//	array                             -> []any
+//	hash                              -> map[any]any
+//	function, builtin, bound method   -> func(args ...any) (any, error)
+//	HostObject                        -> the pointer it wraps
+//
+// Any other value (structs, enums, results, ...) is returned as the object itself.
+func ToGo(obj object.Object) any {
+	switch obj := obj.(type) {
+	case nil, *object.Null:
+		return nil
+	case *object.Integer:
+		return obj.Value
+	case *object.Float:
+		return obj.Value
+	case *object.Boolean:
+		return obj.Value
+	case *object.String:
+		return obj.Value
+	case *object.Array:
+		elements := make([]any, len(obj.Elements))
+		for i, el := range obj.Elements {
+			elements[i] = ToGo(el)
+		}
+		return elements
+	case *object.Hash:
+		pairs := make(map[any]any, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			pairs[ToGo(pair.Key)] = ToGo(pair.Value)
+		}
+		return pairs
+	case *object.Function, *object.Builtin, *object.BoundMethod:
+		return func(args ...any) (any, error) {
+			return call(obj, args)
+		}
+	case *HostObject:
+		return obj.Value()
+	}
+	return obj
+}
+
+// fromObject converts obj to a Go value of type t (the parameter of a Go function).
+func fromObject(obj object.Object, t reflect.Type) (reflect.Value, error) {
+	// object.Objectや*object.Hashなどを受け取る関数にはそのまま渡す
+	if t != anyType && reflect.TypeOf(obj).AssignableTo(t) {
+		return reflect.ValueOf(obj), nil
+	}
+	if h, ok := obj.(*HostObject); ok {
+		switch {
+		case h.value.Type().AssignableTo(t):
+			return h.value, nil
+		case h.value.Elem().Type().AssignableTo(t):
+			return h.value.Elem(), nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			v := ToGo(obj)
+			if v == nil {
+				return reflect.Zero(t), nil
+			}
+			return reflect.ValueOf(v), nil
+		}
+	case reflect.Bool:
+		if b, ok := obj.(*object.Boolean); ok {
+			return reflect.ValueOf(b.Value).Convert(t), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := obj.(*object.Integer); ok {
+			v := reflect.New(t).Elem()
+			if v.OverflowInt(i.Value) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", i.Value, t)
+			}
+			v.SetInt(i.Value)
+			return v, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := obj.(*object.Integer); ok {
+			v := reflect.New(t).Elem()
+			if i.Value < 0 || v.OverflowUint(uint64(i.Value)) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", i.Value, t)
+			}
+			v.SetUint(uint64(i.Value))
+			return v, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		// 整数はfloatの引数にも渡せる
+		switch n := obj.(type) {
+		case *object.Float:
+			return reflect.ValueOf(n.Value).Convert(t), nil
+		case *object.Integer:
+			return reflect.ValueOf(float64(n.Value)).Convert(t), nil
+		}
+	case reflect.String:
+		if s, ok := obj.(*object.String); ok {
+			return reflect.ValueOf(s.Value).Convert(t), nil
+		}
+	case reflect.Slice:
+		if arr, ok := obj.(*object.Array); ok {
+			v := reflect.MakeSlice(t, len(arr.Elements), len(arr.Elements))
+			for i, el := range arr.Elements {
+				ev, err := fromObject(el, t.Elem())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				v.Index(i).Set(ev)
+			}
+			return v, nil
+		}
+	case reflect.Map:
+		if hash, ok := obj.(*object.Hash); ok {
+			v := reflect.MakeMapWithSize(t, len(hash.Pairs))
+			for _, pair := range hash.Pairs {
+				key, err := fromObject(pair.Key, t.Key())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				value, err := fromObject(pair.Value, t.Elem())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				v.SetMapIndex(key, value)
+			}
+			return v, nil
+		}
+	case reflect.Func:
+		switch obj.(type) {
+		case *object.Function, *object.Builtin, *object.BoundMethod:
+			return monkeyFunction(obj, t), nil
+		}
+	}
+
+	return reflect.Value{}, fmt.Errorf("cannot use %s as %s", obj.Type(), t)
+}
+
+// goFunction wraps a Go func so Monkey code can call it.
+func goFunction(fn reflect.Value) *object.Builtin {
+	t := fn.Type()
+
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		if (!t.IsVariadic() && len(args) != t.NumIn()) || (t.IsVariadic() && len(args) < t.NumIn()-1) {
+			return &object.Error{Kind: object.ARGUMENT_ERROR, Message: fmt.Sprintf("wrong number of arguments: want=%d, got=%d", t.NumIn(), len(args))}
+		}
+
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			var pt reflect.Type
+			if t.IsVariadic() && i >= t.NumIn()-1 {
+				pt = t.In(t.NumIn() - 1).Elem()
+			} else {
+				pt = t.In(i)
+			}
+			v, err := fromObject(arg, pt)
+			if err != nil {
+				return &object.Error{Kind: object.TYPE_MISMATCH_ERROR, Message: fmt.Sprintf("argument %d: %s", i+1, err)}
+			}
+			in[i] = v
+		}
+
+		out := fn.Call(in)
+		if n := len(out); n > 0 && t.Out(n-1) == errorType {
+			if err, _ := out[n-1].Interface().(error); err != nil {
+				return &object.Error{Kind: object.HOST_ERROR, Message: err.Error()}
+			}
+			out = out[:n-1]
+		}
+
+		results := make([]object.Object, len(out))
+		for i, v := range out {
+			obj, err := ToObject(v.Interface())
+			if err != nil {
+				return &object.Error{Kind: object.HOST_ERROR, Message: err.Error()}
+			}
+			results[i] = obj
+		}
+
+		switch len(results) {
+		case 0:
+			return evaluator.NULL
+		case 1:
+			return results[0]
+		default:
+			return &object.Array{Elements: results}
+		}
+	}}
+}
+
+// monkeyFunction makes a Go func of type t that calls the Monkey function fn.
+// When t returns an error as its last result, errors raised by fn are returned there;
+// otherwise they panic.
+func monkeyFunction(fn object.Object, t reflect.Type) reflect.Value {
+	return reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		args := make([]object.Object, len(in))
+		for i, v := range in {
+			arg, err := ToObject(v.Interface())
+			if err != nil {
+				return failure(t, err)
+			}
+			args[i] = arg
+		}
+
+		obj, err := result(evaluator.CallFunction(fn, args...))
+		if err != nil {
+			return failure(t, err)
+		}
+
+		out := make([]reflect.Value, t.NumOut())
+		for i := range out {
+			out[i] = reflect.Zero(t.Out(i))
+		}
+		if t.NumOut() > 0 && t.Out(0) != errorType {
+			v, err := fromObject(obj, t.Out(0))
+			if err != nil {
+				return failure(t, err)
+			}
+			out[0] = v
+		}
+		return out
+	})
+}
+
+func failure(t reflect.Type, err error) []reflect.Value {
+	n := t.NumOut()
+	if n == 0 || t.Out(n-1) != errorType {
+		panic(err)
+	}
+
+	out := make([]reflect.Value, n)
+	for i := range out {
+		out[i] = reflect.Zero(t.Out(i))
+	}
+	out[n-1] = reflect.ValueOf(&err).Elem()
+	return out
+}
@@ -0,0 +1,145 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+	"sync/atomic"
+	"time"
+)
+
+// setTimeoutはapplyFunctionを参照するので、initで登録する
+func init() {
+	builtins["setTimeout"] = &object.Builtin{Fn: builtinSetTimeout}
+	builtins["clearTimeout"] = &object.Builtin{Fn: builtinClearTimeout}
+}
+
+// async fnの本体はgeneratorと同じく別のgoroutineで動かす
+// awaitで止まると呼んだ側(event loop)に戻り、promiseが決まったらloopのtaskとして再開する
+// 本体の環境にcoroutineKeyで束縛しておき、awaitはそれを探す
+const coroutineKey = "*async*"
+
+type coroutine struct {
+	resume  chan struct{}
+	paused  chan struct{} // awaitで止まったか、本体が終わった
+	running atomic.Bool
+}
+
+func (co *coroutine) Type() object.ObjectType { return "COROUTINE" }
+func (co *coroutine) Inspect() string         { return "coroutine" }
+
+// 本体が止まるまで進める
+func (co *coroutine) step() {
+	co.running.Store(true)
+	co.resume <- struct{}{}
+	<-co.paused
+}
+
+// async fnを呼んだとき。最初のawaitまではその場で実行する
+func callAsync(fn *object.Function, args []object.Object) object.Object {
+	loop := loopOf(fn.Env)
+	if loop == nil {
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "async functions need an event loop")
+	}
+
+	p := &object.Promise{}
+	co := &coroutine{resume: make(chan struct{}), paused: make(chan struct{})}
+	env := extendFunctionEnv(fn, args)
+	env.Set(coroutineKey, co)
+
+	go func() {
+		<-co.resume
+		var result object.Object
+		if fn.Code != nil {
+			result = fn.Code(env)
+		} else {
+			result = Eval(fn.Body, env)
+		}
+		loop.settle(p, unwrapReturnValue(result))
+
+		co.running.Store(false)
+		co.paused <- struct{}{}
+	}()
+	co.step()
+
+	return p
+}
+
+// await value
+// async fnの中ではpromiseが決まるまで本体を止め、それ以外(top levelなど)ではloopを回して待つ
+// promiseでない値はそのまま
+func evalAwaitExpression(ae *ast.AwaitExpression, env *object.Environment, eval evalFunc) object.Object {
+	val := eval(ae.Value, env)
+	if isAbrupt(val) {
+		return val
+	}
+	p, ok := val.(*object.Promise)
+	if !ok {
+		return val
+	}
+
+	if p.State == object.PROMISE_PENDING {
+		obj, _ := env.Get(coroutineKey)
+		if co, ok := obj.(*coroutine); ok && co.running.Load() {
+			p.Callbacks = append(p.Callbacks, co.step)
+			co.running.Store(false)
+			co.paused <- struct{}{}
+			<-co.resume
+		} else {
+			loop := loopOf(env)
+			if loop == nil {
+				return setErrorPosition(newError(object.UNKNOWN_OPERATOR_ERROR, "await needs an event loop"), ae.Token)
+			}
+			if !loop.wait(p) {
+				return setErrorPosition(newError(object.UNKNOWN_OPERATOR_ERROR, "await on a promise that is never settled"), ae.Token)
+			}
+		}
+	}
+
+	if p.State == object.PROMISE_REJECTED {
+		// 同じpromiseを何度awaitしてもstackが積み重ならないようにcopyする
+		err := *p.Value.(*object.Error)
+		err.Stack = append([]object.StackFrame(nil), err.Stack...)
+		return &err
+	}
+	return p.Value
+}
+
+// setTimeout(fn, ms) ms後にfnを呼び、その結果のpromiseを返す
+// loopはfnの環境から探す
+func builtinSetTimeout(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `setTimeout`: want=2, got=%d", len(args))
+	}
+	ms, ok := args[1].(*object.Integer)
+	if !ok || ms.Value < 0 {
+		return newError(object.ARGUMENT_ERROR, "second argument to `setTimeout` must be a non-negative INTEGER, got %s", args[1].Inspect())
+	}
+
+	var env *object.Environment
+	switch fn := args[0].(type) {
+	case *object.Function:
+		env = fn.Env
+	case *object.BoundMethod:
+		env = fn.Method.Env
+	default:
+		return newError(object.ARGUMENT_ERROR, "first argument to `setTimeout` must be a function, got %s", typeName(args[0]))
+	}
+	loop := loopOf(env)
+	if loop == nil {
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "setTimeout needs an event loop")
+	}
+
+	return loop.setTimeout(args[0], time.Duration(ms.Value)*time.Millisecond)
+}
+
+// clearTimeout(p) まだ発火していなければ止めてtrue。pはCancelled errorでrejectされる
+func builtinClearTimeout(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `clearTimeout`: want=1, got=%d", len(args))
+	}
+	p, ok := args[0].(*object.Promise)
+	if !ok || p.Cancel == nil {
+		return newError(object.ARGUMENT_ERROR, "argument to `clearTimeout` must be a promise from setTimeout, got %s", typeName(args[0]))
+	}
+	return nativeBoolToBooleanObject(p.Cancel())
+}
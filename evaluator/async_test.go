@@ -0,0 +1,254 @@
+package evaluator
+
+import (
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// testEvalと同じく両方のbackendで評価する。それぞれfake clockのloopを使う
+func testEvalAsync(input string) object.Object {
+	return testEvalWith(input, func(env *object.Environment) {
+		NewEventLoop(NewFakeClock(epoch)).Install(env)
+	})
+}
+
+func TestAsyncFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let f = async fn(x) { x + 1 }; await f(1)`, 2},
+		{`let f = async fn(x) { if (x) { return 1 } 2 }; await f(true)`, 1},
+		{`let f = async fn() { let a = await setTimeout(fn() { 10 }, 100); let b = await setTimeout(fn() { 5 }, 50); a + b }; await f()`, 15},
+		{`await 5`, 5},
+		{`await setTimeout(fn() { 3 }, 0)`, 3},
+		// asyncの中のfnでもawaitできる
+		{`let f = async fn() { let g = fn() { await setTimeout(fn() { 7 }, 5) }; g() + 1 }; await f()`, 8},
+		{`let f = async fn(x) { x * 2 }; let g = async fn() { await f(await f(1)) }; await g()`, 4},
+		// 最初のawaitまでは呼んだときに実行される
+		{`let c = channel(1); let f = async fn() { send(c, 1); await setTimeout(fn() { 0 }, 10); 2 }; let p = f(); recv(c)`, 1},
+		// timerは時刻の順、同じ時刻なら登録した順に発火する
+		{`
+let log = channel(10);
+setTimeout(fn() { send(log, 3) }, 30);
+setTimeout(fn() { send(log, 1) }, 10);
+setTimeout(fn() { send(log, 2) }, 20);
+setTimeout(fn() { send(log, 4) }, 30);
+await setTimeout(fn() { 0 }, 40);
+recv(log) * 1000 + recv(log) * 100 + recv(log) * 10 + recv(log)`, 1234},
+		// awaitしている間に他のasync fnが進む
+		{`
+let log = channel(10);
+let worker = async fn(id, delay) { await setTimeout(fn() { 0 }, delay); send(log, id); id };
+let a = worker(1, 30);
+let b = worker(2, 10);
+let total = await a + await b;
+total * 100 + recv(log) * 10 + recv(log)`, 321},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalAsync(tt.input)
+		integer, ok := tt.expected.(int)
+
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestClearTimeout(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`let t = setTimeout(fn() { 1 }, 10); clearTimeout(t)`, true},
+		{`let t = setTimeout(fn() { 1 }, 10); await t; clearTimeout(t)`, false},
+		{`let t = setTimeout(fn() { 1 }, 10); clearTimeout(t); clearTimeout(t)`, false},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEvalAsync(tt.input), tt.expected)
+	}
+}
+
+func TestAsyncErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`let f = async fn() { 1 + true }; await f()`, "type mismatch: INTEGER + BOOLEAN"},
+		{`let f = async fn() { await setTimeout(fn() { 0 }, 10); throw "late" }; await f()`, "late"},
+		{`let t = setTimeout(fn() { 1 }, 10); clearTimeout(t); await t`, "timer cancelled"},
+		{`setTimeout(1, 10)`, "first argument to `setTimeout` must be a function, got INTEGER"},
+		{`setTimeout(fn() { 1 }, -1)`, "second argument to `setTimeout` must be a non-negative INTEGER, got -1"},
+		{`clearTimeout(1)`, "argument to `clearTimeout` must be a promise from setTimeout, got INTEGER"},
+		// 自分自身を待つasync fnは終わらない
+		{`let c = channel(1); let f = async fn() { await setTimeout(fn() { 0 }, 1); await recv(c) }; let p = f(); send(c, p); await p`, "await on a promise that is never settled"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalAsync(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q. got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestAsyncWithoutEventLoop(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`let f = async fn() { 1 }; f()`, "async functions need an event loop"},
+		{`setTimeout(fn() { 1 }, 10)`, "setTimeout needs an event loop"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong result for %q. want=%q, got=%+v", tt.input, tt.expectedMessage, evaluated)
+		}
+	}
+}
+
+func TestAwaitCatchesRejection(t *testing.T) {
+	evaluated := testEvalAsync(`let f = async fn() { throw "boom" }; try { await f() } catch (e) { e.message }`)
+	str, ok := evaluated.(*object.String)
+	if !ok || str.Value != "boom" {
+		t.Errorf("wrong result. got=%T(%+v)", evaluated, evaluated)
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	loop := NewEventLoop(clock)
+	env := object.NewEnvironment()
+	loop.Install(env)
+
+	evaluated := Eval(parser.New(lexer.New("let p = setTimeout(fn() { 1 }, 1500); p")).ParseProgram(), env)
+	p, ok := evaluated.(*object.Promise)
+	if !ok {
+		t.Fatalf("setTimeout should return a promise. got=%T", evaluated)
+	}
+
+	// Pollは時計を進めない
+	loop.Poll()
+	if p.State != object.PROMISE_PENDING {
+		t.Fatalf("the timer fired before its time")
+	}
+	clock.Advance(time.Second)
+	loop.Poll()
+	if p.State != object.PROMISE_PENDING {
+		t.Fatalf("the timer fired before its time")
+	}
+	clock.Advance(500 * time.Millisecond)
+	loop.Poll()
+	if p.State != object.PROMISE_FULFILLED {
+		t.Fatalf("the timer did not fire")
+	}
+
+	// Runは次のtimerの時刻まで時計を進める
+	Eval(parser.New(lexer.New("setTimeout(fn() { 1 }, 2000)")).ParseProgram(), env)
+	loop.Run()
+	if elapsed := clock.Now().Sub(epoch); elapsed != 3500*time.Millisecond {
+		t.Errorf("wrong time after Run. want=3.5s, got=%s", elapsed)
+	}
+}
+
+func TestHostPromises(t *testing.T) {
+	loop := NewEventLoop(NewFakeClock(epoch))
+	env := object.NewEnvironment()
+	loop.Install(env)
+
+	env.Set("double", loop.AsyncBuiltin(func(args ...object.Object) object.Object {
+		time.Sleep(time.Millisecond)
+		return object.NewInteger(args[0].(*object.Integer).Value * 2)
+	}))
+	env.Set("fail", loop.AsyncBuiltin(func(args ...object.Object) object.Object {
+		return newError(object.HOST_ERROR, "unavailable")
+	}))
+
+	p, resolve := loop.NewPromise()
+	env.Set("later", p)
+	go func() {
+		time.Sleep(time.Millisecond)
+		resolve(object.NewInteger(100))
+		resolve(object.NewInteger(200))
+	}()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = double(1); let b = double(2); await a + await b", "6"},
+		{"await later", "100"},
+		{"let f = async fn(x) { await double(x) + await later }; await f(5)", "110"},
+		{"try { await fail() } catch (e) { e.kind + \": \" + e.message }", "HostError: unavailable"},
+	}
+
+	for _, tt := range tests {
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. want=%s, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+// Runはhostがresolveするまで待つ
+func TestRunWaitsForHostPromises(t *testing.T) {
+	loop := NewEventLoop(SystemClock)
+	env := object.NewEnvironment()
+	loop.Install(env)
+
+	p, resolve := loop.NewPromise()
+	env.Set("p", p)
+	Eval(parser.New(lexer.New("let q = async fn() { await p + 1 }();")).ParseProgram(), env)
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		resolve(object.NewInteger(41))
+	}()
+	loop.Run()
+
+	q, _ := env.Get("q")
+	if q.Inspect() != "promise(42)" {
+		t.Errorf("wrong promise after Run. got=%s", q.Inspect())
+	}
+}
+
+// Serveはやることがなくてもstopが閉じられるまで待ち、その間に時間になったtimerを発火させる
+func TestServe(t *testing.T) {
+	loop := NewEventLoop(SystemClock)
+	env := object.NewEnvironment()
+	loop.Install(env)
+	Eval(parser.New(lexer.New("let p = setTimeout(fn() { 1 }, 5); let later = setTimeout(fn() { 2 }, 3600000);")).ParseProgram(), env)
+
+	stop := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(stop)
+	}()
+	loop.Serve(stop)
+
+	p, _ := env.Get("p")
+	if p.Inspect() != "promise(1)" {
+		t.Errorf("the timer did not fire while serving. got=%s", p.Inspect())
+	}
+	later, _ := env.Get("later")
+	if later.Inspect() != "promise(pending)" {
+		t.Errorf("a later timer fired too early. got=%s", later.Inspect())
+	}
+}
@@ -0,0 +1,120 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"sort"
+)
+
+// resolverに渡す組み込み関数の名前
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins)+len(runtimeBuiltins)+len(envBuiltins)+len(modules))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	for name := range runtimeBuiltins {
+		names = append(names, name)
+	}
+	for name := range envBuiltins {
+		names = append(names, name)
+	}
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 組み込み関数と組み込みのmoduleの型。typesのCheckerとInferはどちらもここから型を作る
+// aやbは型変数(Checkerではany)、[string]はstringのlist(Checkerではarray)
+// 組み込み関数を足したらここにも足す(TestBuiltinTypesで確かめる)
+var builtinTypes = map[string]string{
+	"len": "(a) -> int",
+	"ok":  "(a) -> b",
+	"err": "(a) -> b",
+	"str": "(a) -> string",
+	"int": "(a) -> b",
+
+	"channel": "(int) -> channel",
+	"send":    "(channel, a) -> null",
+	"recv":    "(channel) -> a",
+	"close":   "(channel) -> null",
+	"spawn":   "(() -> a) -> channel",
+
+	"setTimeout":   "(() -> a, int) -> promise",
+	"clearTimeout": "(promise) -> bool",
+
+	"now":    "() -> int",
+	"random": "(int) -> int",
+
+	"readFile":  "(string) -> string",
+	"writeFile": "(string, string) -> null",
+	"listDir":   "(string) -> [string]",
+	"exists":    "(string) -> bool",
+	"readLines": "(string) -> generator",
+
+	"exec": "(string, [string]) -> hash",
+	"env":  "(string) -> a",
+	"exit": "(int) -> a",
+
+	"math":    "module",
+	"strings": "module",
+	"json":    "module",
+}
+
+// 名前から型の文字列へ(書き換えられないようにcopyを返す)
+func BuiltinTypes() map[string]string {
+	types := make(map[string]string, len(builtinTypes))
+	for name, t := range builtinTypes {
+		types[name] = t
+	}
+	return types
+}
+
+// functions implemented in Go
+// evalIdentifierでenvに見つからなかった場合にここから探す
+var builtins = map[string]*object.Builtin{
+	"len": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `len`: want=1, got=%d", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.String:
+				return object.NewInteger(int64(len(arg.Value)))
+			case *object.Array:
+				return object.NewInteger(int64(len(arg.Elements)))
+			case *object.Hash:
+				return object.NewInteger(int64(len(arg.Pairs)))
+			default:
+				return newError(object.ARGUMENT_ERROR, "argument to `len` not supported, got %s", typeName(args[0]))
+			}
+		},
+	},
+	"ok": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `ok`: want=1, got=%d", len(args))
+			}
+			return &object.Result{Ok: true, Value: args[0]}
+		},
+	},
+	"err": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `err`: want=1, got=%d", len(args))
+			}
+			return &object.Result{Ok: false, Value: args[0]}
+		},
+	},
+	"str": {Fn: builtinStr},
+	"int": {Fn: builtinInt},
+}
+
+// math.sqrtのように名前でまとめた組み込み関数と定数
+// builtinsと同じく、evalIdentifierでenvに見つからなかった場合にここから探す
+var modules = map[string]*object.Module{
+	"math":    mathModule,
+	"strings": stringsModule,
+	"json":    jsonModule,
+}
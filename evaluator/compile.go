@@ -0,0 +1,434 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+// compileしたnode。Evalと同じ値を返す
+type code func(*object.Frame) object.Object
+
+// programを一度だけ辿ってGoのclosureの木にする
+// 実行のたびにnodeの型や演算子の文字列でswitchしなくて済む
+// 結果はEval(program, env)と同じになる
+func Compile(program *ast.Program) func(*object.Frame) object.Object {
+	c := &compiler{codes: map[ast.Node]code{}}
+	return c.compileProgram(program)
+}
+
+// codesはevalTryExpressionなどEvalと共有している関数に渡す子nodeのcode
+// compileが終わったあとは読むだけ
+type compiler struct {
+	codes map[ast.Node]code
+}
+
+// evalFuncとしてEvalの代わりに渡す
+func (c *compiler) run(node ast.Node, f *object.Frame) object.Object {
+	return c.codes[node](f)
+}
+
+// evalFuncに渡す子nodeをcompileしておく
+func (c *compiler) prepare(nodes ...ast.Node) {
+	for _, node := range nodes {
+		c.codes[node] = c.compile(node)
+	}
+}
+
+func (c *compiler) compile(node ast.Node) code {
+	switch node := node.(type) {
+	case *ast.Program:
+		return c.compileProgram(node)
+	case *ast.BlockStatement:
+		return c.compileBlock(node)
+	case *ast.ReturnStatement:
+		value := c.compile(node.ReturnValue)
+		return func(f *object.Frame) object.Object {
+			val := value(f)
+			if isAbrupt(val) {
+				return val
+			}
+			return &object.ReturnValue{Value: val}
+		}
+	case *ast.ThrowStatement:
+		c.prepare(node.Value)
+		return func(f *object.Frame) object.Object {
+			return evalThrowStatement(node, f, c.run)
+		}
+	case *ast.AssignStatement:
+		c.prepare(node.Target.Left, node.Value)
+		return func(f *object.Frame) object.Object {
+			return evalAssignStatement(node, f, c.run)
+		}
+	case *ast.StructStatement:
+		return func(f *object.Frame) object.Object {
+			return evalStructStatement(node, f)
+		}
+	case *ast.EnumStatement:
+		return func(f *object.Frame) object.Object {
+			return evalEnumStatement(node, f)
+		}
+	case *ast.ImplStatement:
+		bodies := make([]func(*object.Frame) object.Object, len(node.Methods))
+		for i, m := range node.Methods {
+			bodies[i] = c.compileBlock(m.Body)
+		}
+		return func(f *object.Frame) object.Object {
+			return setErrorPosition(evalImplStatement(node, f, bodies), node.Token)
+		}
+	case *ast.ExpressionStatement:
+		return c.compile(node.Expression)
+	case *ast.PrefixExpression:
+		v := c.compilePrefix(node)
+		return func(f *object.Frame) object.Object { return v(f).object() }
+	case *ast.InfixExpression:
+		v := c.compileInfix(node)
+		return func(f *object.Frame) object.Object { return v(f).object() }
+	case *ast.PostfixExpression:
+		left := c.compile(node.Left)
+		return func(f *object.Frame) object.Object {
+			l := left(f)
+			if isAbrupt(l) {
+				return l
+			}
+			return setErrorPosition(evalPostfixExpression(node.Operator, l), node.Token)
+		}
+	case *ast.IfExpression:
+		return c.compileIf(node)
+	case *ast.TryExpression:
+		c.prepare(node.Block)
+		if node.Catch != nil {
+			c.prepare(node.Catch)
+		}
+		if node.Finally != nil {
+			c.prepare(node.Finally)
+		}
+		return func(f *object.Frame) object.Object {
+			return evalTryExpression(node, f, c.run)
+		}
+	case *ast.MatchExpression:
+		c.prepare(node.Subject)
+		for _, arm := range node.Arms {
+			if arm.Guard != nil {
+				c.prepare(arm.Guard)
+			}
+			c.prepare(arm.Body)
+		}
+		return func(f *object.Frame) object.Object {
+			return evalMatchExpression(node, f, c.run)
+		}
+	case *ast.SelectExpression:
+		for _, sc := range node.Cases {
+			if sc.Channel != nil {
+				c.prepare(sc.Channel)
+			}
+			if sc.Value != nil {
+				c.prepare(sc.Value)
+			}
+			c.prepare(sc.Body)
+		}
+		return func(f *object.Frame) object.Object {
+			return evalSelectExpression(node, f, c.run)
+		}
+	case *ast.AwaitExpression:
+		c.prepare(node.Value)
+		return func(f *object.Frame) object.Object {
+			return evalAwaitExpression(node, f, c.run)
+		}
+	case *ast.YieldExpression:
+		if node.Value != nil {
+			c.prepare(node.Value)
+		}
+		return func(f *object.Frame) object.Object {
+			return evalYieldExpression(node, f, c.run)
+		}
+	case *ast.IntegerLiteral:
+		// Integerは書き換えられないので毎回同じものを返してよい
+		val := object.NewInteger(node.Value)
+		return func(*object.Frame) object.Object { return val }
+	case *ast.FloatLiteral:
+		val := &object.Float{Value: node.Value}
+		return func(*object.Frame) object.Object { return val }
+	case *ast.StringLiteral:
+		val := &object.String{Value: node.Value}
+		return func(*object.Frame) object.Object { return val }
+	case *ast.ArrayLiteral:
+		elements := c.compileExpressions(node.Elements)
+		return func(f *object.Frame) object.Object {
+			els := elements(f)
+			if len(els) == 1 && isAbrupt(els[0]) {
+				return els[0]
+			}
+			return &object.Array{Elements: els}
+		}
+	case *ast.HashLiteral:
+		for i := range node.Keys {
+			c.prepare(node.Keys[i], node.Values[i])
+		}
+		return func(f *object.Frame) object.Object {
+			return evalHashLiteral(node, f, c.run)
+		}
+	case *ast.StructLiteral:
+		for _, v := range node.Values {
+			c.prepare(v)
+		}
+		return func(f *object.Frame) object.Object {
+			return setErrorPosition(evalStructLiteral(node, f, c.run), node.Token)
+		}
+	case *ast.Boolean:
+		val := nativeBoolToBooleanObject(node.Value)
+		return func(*object.Frame) object.Object { return val }
+	case *ast.LetStatement:
+		return c.compileLet(node)
+	case *ast.Identifier:
+		return c.compileIdentifier(node)
+	case *ast.FunctionLiteral:
+		body := c.compileBlock(node.Body)
+		return func(f *object.Frame) object.Object {
+			return &object.Function{Parameters: node.Parameters, Env: f, Body: node.Body, Name: node.Name, Code: body, Generator: node.Generator, Async: node.Async}
+		}
+	case *ast.CallExpression:
+		function := c.compile(node.Function)
+		args := c.compileExpressions(node.Arguments)
+		return func(f *object.Frame) object.Object {
+			fn := function(f)
+			if isAbrupt(fn) {
+				return fn
+			}
+			a := args(f)
+			if len(a) == 1 && isAbrupt(a[0]) {
+				return a[0]
+			}
+			return callFunction(fn, a, node.Token)
+		}
+	case *ast.IndexExpression:
+		left := c.compile(node.Left)
+		index := c.compile(node.Index)
+		return func(f *object.Frame) object.Object {
+			l := left(f)
+			if isAbrupt(l) {
+				return l
+			}
+			i := index(f)
+			if isAbrupt(i) {
+				return i
+			}
+			return setErrorPosition(evalIndexExpression(l, i), node.Token)
+		}
+	case *ast.FieldExpression:
+		left := c.compile(node.Left)
+		return func(f *object.Frame) object.Object {
+			l := left(f)
+			if isAbrupt(l) {
+				return l
+			}
+			return setErrorPosition(evalFieldExpression(l, node.Field.Value), node.Token)
+		}
+	}
+
+	return func(*object.Frame) object.Object { return nil }
+}
+
+func (c *compiler) compileStatements(stmts []ast.Statement) []code {
+	codes := make([]code, len(stmts))
+	for i, s := range stmts {
+		codes[i] = c.compile(s)
+	}
+	return codes
+}
+
+// evalProgramと同じ
+func (c *compiler) compileProgram(program *ast.Program) code {
+	stmts := c.compileStatements(program.Statements)
+	return func(f *object.Frame) object.Object {
+		var result object.Object
+		for _, stmt := range stmts {
+			result = stmt(f)
+
+			switch r := result.(type) {
+			case *object.ReturnValue:
+				return r.Value
+			case *object.Error:
+				return r
+			}
+		}
+		return result
+	}
+}
+
+// evalBlockStatementと同じ
+func (c *compiler) compileBlock(block *ast.BlockStatement) code {
+	stmts := c.compileStatements(block.Statements)
+	return func(f *object.Frame) object.Object {
+		var result object.Object
+		for _, stmt := range stmts {
+			result = stmt(f)
+
+			if result != nil {
+				rt := result.Type()
+				if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+					return result
+				}
+			}
+		}
+		return result
+	}
+}
+
+// evalExpressionsと同じ
+func (c *compiler) compileExpressions(exps []ast.Expression) func(*object.Frame) []object.Object {
+	codes := make([]code, len(exps))
+	for i, e := range exps {
+		codes[i] = c.compile(e)
+	}
+	return func(f *object.Frame) []object.Object {
+		result := make([]object.Object, 0, len(codes))
+		for _, e := range codes {
+			evaluated := e(f)
+			if isAbrupt(evaluated) {
+				return []object.Object{evaluated}
+			}
+			result = append(result, evaluated)
+		}
+		return result
+	}
+}
+
+func (c *compiler) compileLet(node *ast.LetStatement) code {
+	value := c.compile(node.Value)
+	return func(f *object.Frame) object.Object {
+		val := value(f)
+		if isAbrupt(val) {
+			return val
+		}
+		if node.Pattern != nil {
+			return evalDestructuringLet(node, val, f)
+		}
+		bind(f, node.Name, val)
+		return nil
+	}
+}
+
+// resolverを通した識別子はslotを直接引く
+func (c *compiler) compileIdentifier(node *ast.Identifier) code {
+	if !node.Resolved {
+		return func(f *object.Frame) object.Object {
+			return setErrorPosition(evalIdentifier(node, f), node.Token)
+		}
+	}
+
+	depth, slot := node.Depth, node.Slot
+	return func(f *object.Frame) object.Object {
+		if val, ok := f.GetAt(depth, slot); ok {
+			return val
+		}
+		return setErrorPosition(evalIdentifier(node, f), node.Token)
+	}
+}
+
+func (c *compiler) compileIf(node *ast.IfExpression) code {
+	condition := c.compileValue(node.Condition)
+	consequence := c.compileBlock(node.Consequence)
+	alternative := func(*object.Frame) object.Object { return NULL }
+	if node.Alternative != nil {
+		alternative = c.compileBlock(node.Alternative)
+	}
+
+	return func(f *object.Frame) object.Object {
+		cond := condition(f)
+		if cond.abrupt() {
+			return cond.obj
+		}
+		if cond.truthy() {
+			return consequence(f)
+		}
+		return alternative(f)
+	}
+}
+
+// 途中の結果をvalueのまま受け渡すcode
+type valueCode func(*object.Frame) value
+
+// 整数や真偽値を返しうる式はboxせずに評価する
+func (c *compiler) compileValue(node ast.Expression) valueCode {
+	switch node := node.(type) {
+	case *ast.IntegerLiteral:
+		v := intVal(node.Value)
+		return func(*object.Frame) value { return v }
+	case *ast.Boolean:
+		v := boolVal(node.Value)
+		return func(*object.Frame) value { return v }
+	case *ast.PrefixExpression:
+		return c.compilePrefix(node)
+	case *ast.InfixExpression:
+		return c.compileInfix(node)
+	}
+
+	code := c.compile(node)
+	return func(f *object.Frame) value { return valueOf(code(f)) }
+}
+
+func (c *compiler) compilePrefix(node *ast.PrefixExpression) valueCode {
+	right := c.compileValue(node.Right)
+	negate, not := node.Operator == "-", node.Operator == "!"
+
+	return func(f *object.Frame) value {
+		r := right(f)
+		if r.abrupt() {
+			return r
+		}
+		switch {
+		case negate && r.kind == intValue:
+			return intVal(-r.num)
+		case not && r.kind == boolValue:
+			return boolVal(r.num == 0)
+		}
+
+		obj := r.object()
+		if result, ok := evalOverloadedPrefixExpression(node, obj); ok {
+			return valueOf(result)
+		}
+		return valueOf(setErrorPosition(evalPrefixExpression(node.Operator, obj), node.Token))
+	}
+}
+
+// 整数どうしの演算はcompileするときに選んだ関数で直接計算する
+// それ以外(文字列やoverloadなど)はEvalと同じ経路に任せる
+func (c *compiler) compileInfix(node *ast.InfixExpression) valueCode {
+	left := c.compileValue(node.Left)
+	right := c.compileValue(node.Right)
+	integer := integerOperators[node.Operator]
+	division := node.Operator == "/"
+
+	return func(f *object.Frame) value {
+		l := left(f)
+		if l.abrupt() {
+			return l
+		}
+		r := right(f)
+		if r.abrupt() {
+			return r
+		}
+		// 0で割るときはEvalと同じerrorにするため遅い経路へ
+		if integer != nil && l.kind == intValue && r.kind == intValue && !(division && r.num == 0) {
+			return integer(l.num, r.num)
+		}
+
+		lo, ro := l.object(), r.object()
+		if result, ok := evalOverloadedInfixExpression(node, lo, ro); ok {
+			return valueOf(result)
+		}
+		return valueOf(setErrorPosition(evalInfixExpression(node.Operator, lo, ro), node.Token))
+	}
+}
+
+// evalIntegerInfixExpressionと同じ
+var integerOperators = map[string]func(a, b int64) value{
+	"+":  func(a, b int64) value { return intVal(a + b) },
+	"-":  func(a, b int64) value { return intVal(a - b) },
+	"*":  func(a, b int64) value { return intVal(a * b) },
+	"/":  func(a, b int64) value { return intVal(a / b) },
+	"<":  func(a, b int64) value { return boolVal(a < b) },
+	">":  func(a, b int64) value { return boolVal(a > b) },
+	"==": func(a, b int64) value { return boolVal(a == b) },
+	"!=": func(a, b int64) value { return boolVal(a != b) },
+}
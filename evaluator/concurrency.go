@@ -0,0 +1,208 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+	"reflect"
+)
+
+// spawnはapplyFunctionを参照するので、builtinsの初期化の循環を避けてinitで登録する
+func init() {
+	builtins["channel"] = &object.Builtin{Fn: builtinChannel}
+	builtins["send"] = &object.Builtin{Fn: builtinSend}
+	builtins["recv"] = &object.Builtin{Fn: builtinRecv}
+	builtins["close"] = &object.Builtin{Fn: builtinClose}
+	builtins["spawn"] = &object.Builtin{Fn: builtinSpawn}
+}
+
+// channel(n) バッファがn個のチャネル。channel(0)はsendとrecvが待ち合わせる
+func builtinChannel(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `channel`: want=1, got=%d", len(args))
+	}
+	size, ok := args[0].(*object.Integer)
+	if !ok || size.Value < 0 {
+		return newError(object.ARGUMENT_ERROR, "argument to `channel` must be a non-negative INTEGER, got %s", args[0].Inspect())
+	}
+	return &object.Channel{Ch: make(chan object.Object, size.Value)}
+}
+
+// send(ch, v) 受け取られるか、バッファに空きができるまで待つ
+func builtinSend(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `send`: want=2, got=%d", len(args))
+	}
+	ch, err := channelArgument("send", args[0])
+	if err != nil {
+		return err
+	}
+	if !trySend(ch, args[1]) {
+		return newError(object.ARGUMENT_ERROR, "send on closed channel")
+	}
+	return NULL
+}
+
+// closeしたチャネルへのsendはGoではpanicなので、errorにする
+func trySend(ch *object.Channel, val object.Object) (sent bool) {
+	defer func() {
+		if recover() != nil {
+			sent = false
+		}
+	}()
+	ch.Ch <- val
+	return true
+}
+
+// recv(ch) 値が来るまで待つ。closeされていて空ならNULL
+// spawnした関数のerrorはrecvしたところでerrorになる
+func builtinRecv(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `recv`: want=1, got=%d", len(args))
+	}
+	ch, err := channelArgument("recv", args[0])
+	if err != nil {
+		return err
+	}
+	val, ok := <-ch.Ch
+	if !ok {
+		return NULL
+	}
+	return val
+}
+
+func builtinClose(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `close`: want=1, got=%d", len(args))
+	}
+	ch, err := channelArgument("close", args[0])
+	if err != nil {
+		return err
+	}
+	if !tryClose(ch) {
+		return newError(object.ARGUMENT_ERROR, "close of closed channel")
+	}
+	return NULL
+}
+
+func tryClose(ch *object.Channel) (closed bool) {
+	defer func() {
+		if recover() != nil {
+			closed = false
+		}
+	}()
+	close(ch.Ch)
+	return true
+}
+
+// spawn(fn) fnを別のgoroutineで呼び、結果を1つだけ送るチャネルを返す
+// recv(spawn(fn))で終わるのを待って結果を受け取れる
+func builtinSpawn(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `spawn`: want=1, got=%d", len(args))
+	}
+	fn := args[0]
+	switch fn.(type) {
+	case *object.Function, *object.Builtin, *object.BoundMethod:
+	default:
+		return newError(object.NOT_A_FUNCTION_ERROR, "not a function: %s", typeName(fn))
+	}
+
+	result := &object.Channel{Ch: make(chan object.Object, 1)}
+	go func() {
+		val := applyFunction(fn, nil)
+		if val == nil {
+			val = NULL
+		}
+		result.Ch <- val
+		close(result.Ch)
+	}()
+	return result
+}
+
+func channelArgument(name string, arg object.Object) (*object.Channel, *object.Error) {
+	ch, ok := arg.(*object.Channel)
+	if !ok {
+		return nil, newError(object.ARGUMENT_ERROR, "argument to `%s` must be CHANNEL, got %s", name, typeName(arg))
+	}
+	return ch, nil
+}
+
+// select { v = recv(a) => ..., send(b, 1) => ..., _ => ... }
+// 準備できたcaseのbodyを評価する。複数準備できていればどれかをランダムに選ぶ
+// _ があればどれも準備できていないときにそれを選び、なければ待つ
+func evalSelectExpression(se *ast.SelectExpression, env *object.Environment, eval evalFunc) object.Object {
+	cases := make([]reflect.SelectCase, len(se.Cases))
+	for i, sc := range se.Cases {
+		if sc.IsDefault() {
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectDefault}
+			continue
+		}
+
+		val := eval(sc.Channel, env)
+		if isAbrupt(val) {
+			return val
+		}
+		ch, ok := val.(*object.Channel)
+		if !ok {
+			return setErrorPosition(newError(object.TYPE_MISMATCH_ERROR, "not a channel: %s", typeName(val)), sc.Token)
+		}
+
+		if !sc.IsSend() {
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch.Ch)}
+			continue
+		}
+		val = eval(sc.Value, env)
+		if isAbrupt(val) {
+			return val
+		}
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(ch.Ch), Send: reflect.ValueOf(&val).Elem()}
+	}
+
+	chosen, received, ok, closed := selectCase(cases, runtimeOf(env))
+	if closed {
+		return setErrorPosition(newError(object.ARGUMENT_ERROR, "send on closed channel"), se.Token)
+	}
+	sc := se.Cases[chosen]
+
+	// recvと同じく、closeされたチャネルからはNULL、errorはここでerrorになる
+	var val object.Object = NULL
+	if ok && cases[chosen].Dir == reflect.SelectRecv {
+		val = received.Interface().(object.Object)
+	}
+	if err, isErr := val.(*object.Error); isErr {
+		return err
+	}
+
+	caseEnv := object.NewEnclosedEnvironment(env)
+	if sc.Name != nil {
+		bind(caseEnv, sc.Name, val)
+	}
+
+	return eval(sc.Body, caseEnv)
+}
+
+// 準備のできたcaseが複数あるとき、reflect.Selectは自分の乱数で選んでしまう
+// rtの乱数で決めた順にcaseを一つずつ待たずに試し、どれもだめなら全部で待つ(defaultがあればdefault)
+func selectCase(cases []reflect.SelectCase, rt Runtime) (chosen int, received reflect.Value, ok bool, closed bool) {
+	for _, i := range shuffledIndices(len(cases), rt) {
+		if cases[i].Dir == reflect.SelectDefault {
+			continue
+		}
+		c, received, ok, closed := trySelect([]reflect.SelectCase{cases[i], {Dir: reflect.SelectDefault}})
+		if closed || c == 0 {
+			return i, received, ok, closed
+		}
+	}
+	return trySelect(cases)
+}
+
+// closedはcloseしたチャネルへのsendが選ばれたとき(どのcaseかはわからない)
+func trySelect(cases []reflect.SelectCase) (chosen int, received reflect.Value, ok bool, closed bool) {
+	defer func() {
+		if recover() != nil {
+			closed = true
+		}
+	}()
+	chosen, received, ok = reflect.Select(cases)
+	return chosen, received, ok, false
+}
@@ -0,0 +1,187 @@
+package evaluator
+
+import (
+	"fmt"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"sync"
+	"testing"
+)
+
+// go test -race で確かめる
+
+func TestSpawn(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`recv(spawn(fn() { 1 + 2 }))`, 3},
+		{`let x = 10; recv(spawn(fn() { x * 2 }))`, 20},
+		{`let add = fn(a, b) { a + b }; recv(spawn(fn() { add(4, 5) }))`, 9},
+		{`recv(spawn(fn() { let a = 1; }))`, nil},
+		// 結果を1つ送ったらcloseされる
+		{`let done = spawn(fn() { 1 }); recv(done); recv(done)`, nil},
+		{`let a = spawn(fn() { 1 }); let b = spawn(fn() { 2 }); recv(a) + recv(b)`, 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestChannels(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let c = channel(2); send(c, 1); send(c, 2); recv(c) * 10 + recv(c)`, 12},
+		{`let c = channel(1); send(c, 5); close(c); recv(c)`, 5},
+		{`let c = channel(1); send(c, 5); close(c); recv(c); recv(c)`, nil},
+		{`
+let ping = channel(0);
+let pong = channel(0);
+spawn(fn() { send(pong, recv(ping) * 2) });
+send(ping, 21);
+recv(pong)`, 42},
+		{`
+let c = channel(0);
+let produce = fn(i) { if (i > 0) { send(c, i); produce(i - 1) } else { close(c) } };
+spawn(fn() { produce(100) });
+let consume = fn(acc) { let v = recv(c); if (v) { consume(acc + v) } else { acc } };
+consume(0)`, 5050},
+		// 複数のgoroutineが同じチャネルに送る
+		{`
+let c = channel(0);
+let start = fn(i) { if (i > 0) { spawn(fn() { send(c, i) }); start(i - 1) } };
+start(10);
+let collect = fn(n, acc) { if (n == 0) { acc } else { collect(n - 1, acc + recv(c)) } };
+collect(10, 0)`, 55},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let c = channel(1); select { recv(c) => 1, _ => 2 }`, 2},
+		{`let c = channel(1); send(c, 7); select { v = recv(c) => v, _ => 0 }`, 7},
+		{`let c = channel(1); select { send(c, 3) => recv(c), _ => 0 }`, 3},
+		{`let c = channel(0); select { send(c, 3) => 1, _ => 0 }`, 0},
+		{`select { v = recv(spawn(fn() { 9 })) => v + 1 }`, 10},
+		{`let c = channel(0); close(c); select { v = recv(c) => v }`, nil},
+		{`let c = channel(1); send(c, 1); select { recv(c) => { let x = 5; x } }`, 5},
+		// caseで束縛した名前は外に出ない
+		{`let v = 1; let c = channel(1); send(c, 2); select { v = recv(c) => v }; v`, 1},
+		{`let f = fn(c) { select { v = recv(c) => { return v * 2 } }; 0 }; let c = channel(1); send(c, 4); f(c)`, 8},
+		{`select { _ => 4 }`, 4},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestConcurrencyErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`recv(spawn(fn() { 1 + true }))`, "type mismatch: INTEGER + BOOLEAN"},
+		{`recv(spawn(fn() { throw "boom" }))`, "boom"},
+		{`select { v = recv(spawn(fn() { missing })) => v }`, "identifier not found: missing"},
+		{`spawn(1)`, "not a function: INTEGER"},
+		{`recv(spawn(fn(x) { x }))`, "wrong number of arguments: want=1, got=0"},
+		{`channel(-1)`, "argument to `channel` must be a non-negative INTEGER, got -1"},
+		{`recv(1)`, "argument to `recv` must be CHANNEL, got INTEGER"},
+		{`let c = channel(1); close(c); send(c, 1)`, "send on closed channel"},
+		{`let c = channel(1); close(c); close(c)`, "close of closed channel"},
+		{`let c = channel(1); close(c); select { send(c, 1) => 1 }`, "send on closed channel"},
+		{`select { recv(1) => 1 }`, "not a channel: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q. got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestSpawnedErrorCanBeCaught(t *testing.T) {
+	evaluated := testEval(`try { recv(spawn(fn() { throw "boom" })) } catch (e) { e.message }`)
+	str, ok := evaluated.(*object.String)
+	if !ok || str.Value != "boom" {
+		t.Errorf("wrong result. got=%T(%+v)", evaluated, evaluated)
+	}
+}
+
+// 同じ環境を複数のgoroutineから読みながら、別の名前を書き込む
+func TestConcurrentEnvironment(t *testing.T) {
+	env := object.NewEnvironment()
+	Eval(parser.New(lexer.New("let base = 100; let double = fn(x) { x * 2 };")).ParseProgram(), env)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		i := i
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			program := parser.New(lexer.New("double(base)")).ParseProgram()
+			testIntegerObject(t, Eval(program, object.NewEnclosedEnvironment(env)), 200)
+		}()
+		go func() {
+			defer wg.Done()
+			env.Set(fmt.Sprintf("name%d", i), object.NewInteger(int64(i)))
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 20; i++ {
+		if _, ok := env.Get(fmt.Sprintf("name%d", i)); !ok {
+			t.Errorf("name%d was not set", i)
+		}
+	}
+}
+
+// spawnした関数が外側の環境を読んでいる間に、外側でletする
+func TestSpawnSharesEnvironment(t *testing.T) {
+	evaluated := testEval(`
+let step = 2;
+let reader = fn(i, acc) { if (i == 0) { acc } else { reader(i - 1, acc + step) } };
+let done = spawn(fn() { reader(50, 0) });
+let a = 1; let b = 2; let d = 3;
+recv(done)`)
+	testIntegerObject(t, evaluated, 100)
+}
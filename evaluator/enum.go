@@ -0,0 +1,113 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+// enum Shape { Circle(r), Rect(w, h), Empty }
+// Shapeと各variant(Circle, Rect, Empty)を定義する
+// fieldのあるvariantはconstructor、ないvariantはそのまま値になる
+func evalEnumStatement(es *ast.EnumStatement, env *object.Environment) object.Object {
+	et := &object.EnumType{Name: es.Name.Value, Methods: map[string]*object.Function{}}
+
+	for _, v := range es.Variants {
+		fields := []string{}
+		for _, f := range v.Fields {
+			fields = append(fields, f.Value)
+		}
+
+		variant := &object.Variant{Enum: et, Name: v.Name.Value, Fields: fields}
+		if len(fields) == 0 {
+			variant.Unit = &object.EnumValue{Variant: variant, Values: []object.Object{}}
+		}
+		et.Variants = append(et.Variants, variant)
+	}
+
+	bind(env, es.Name, et)
+	for i, variant := range et.Variants {
+		bind(env, es.Variants[i].Name, variantObject(variant))
+	}
+
+	return nil
+}
+
+// Circle -> constructor, Empty -> 値
+func variantObject(variant *object.Variant) object.Object {
+	if variant.Unit != nil {
+		return variant.Unit
+	}
+	return variant
+}
+
+// Circle(2)
+func applyVariant(variant *object.Variant, args []object.Object) object.Object {
+	if variant.Unit != nil {
+		return newError(object.NOT_A_FUNCTION_ERROR, "not a function: %s", typeName(variant.Unit))
+	}
+	if len(args) != len(variant.Fields) {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to %s: want=%d, got=%d", variant.Name, len(variant.Fields), len(args))
+	}
+
+	return &object.EnumValue{Variant: variant, Values: args}
+}
+
+func evalEnumInfixExpression(operator string, left, right object.Object) object.Object {
+	switch operator {
+	case "==":
+		return nativeBoolToBooleanObject(objectsEqual(left, right))
+	case "!=":
+		return nativeBoolToBooleanObject(!objectsEqual(left, right))
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "unknown operator: %s %s %s", typeName(left), operator, typeName(right))
+	}
+}
+
+// enumの値はvariantとpayloadを比べる(payloadの中も同様)
+// それ以外は==と同じ比較(整数と文字列は値、その他はpointer)
+func objectsEqual(left, right object.Object) bool {
+	switch left := left.(type) {
+	case *object.Integer:
+		r, ok := right.(*object.Integer)
+		return ok && left.Value == r.Value
+	case *object.String:
+		r, ok := right.(*object.String)
+		return ok && left.Value == r.Value
+	case *object.EnumValue:
+		r, ok := right.(*object.EnumValue)
+		if !ok || left.Variant != r.Variant || len(left.Values) != len(r.Values) {
+			return false
+		}
+		for i := range left.Values {
+			if !objectsEqual(left.Values[i], r.Values[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return left == right
+	}
+}
+
+// Shape.Circle, shape.r, shape.area()
+func evalEnumFieldExpression(left object.Object, name string) (object.Object, bool) {
+	switch left := left.(type) {
+	case *object.EnumType:
+		if variant, ok := left.Variant(name); ok {
+			return variantObject(variant), true
+		}
+		if method, ok := left.Methods[name]; ok {
+			return method, true
+		}
+	case *object.EnumValue:
+		for i, f := range left.Variant.Fields {
+			if f == name {
+				return left.Values[i], true
+			}
+		}
+		if method, ok := left.Variant.Enum.Methods[name]; ok {
+			return &object.BoundMethod{Receiver: left, Method: method}, true
+		}
+	}
+	return nil, false
+}
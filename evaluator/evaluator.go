@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"monkey/ast"
 	"monkey/object"
+	"monkey/token"
 )
 
 // Not to duplicate same meaning instance
@@ -13,6 +14,9 @@ var (
 	FALSE = &object.Boolean{Value: false}
 )
 
+// 子のnodeの評価の仕方。tree-walkerではEvalそのもの、compileしたときは前もって作ったclosureを呼ぶ
+type evalFunc func(ast.Node, *object.Environment) object.Object
+
 // some are instanciated and others not(like bool)
 func Eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
@@ -22,42 +26,121 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalBlockStatement(node, env)
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
-		if isError(val) {
+		if isAbrupt(val) {
 			return val
 		}
 		return &object.ReturnValue{Value: val}
+	case *ast.ThrowStatement:
+		return evalThrowStatement(node, env, Eval)
+	case *ast.AssignStatement:
+		return evalAssignStatement(node, env, Eval)
+	case *ast.StructStatement:
+		return evalStructStatement(node, env)
+	case *ast.EnumStatement:
+		return evalEnumStatement(node, env)
+	case *ast.ImplStatement:
+		return setErrorPosition(evalImplStatement(node, env, nil), node.Token)
 	case *ast.ExpressionStatement:
 		return Eval(node.Expression, env)
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
-		if isError(right) {
+		if isAbrupt(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		if result, ok := evalOverloadedPrefixExpression(node, right); ok {
+			return result
+		}
+		return setErrorPosition(evalPrefixExpression(node.Operator, right), node.Token)
 	case *ast.InfixExpression:
 		left := Eval(node.Left, env)
-		if isError(left) {
+		if isAbrupt(left) {
 			return left
 		}
 		right := Eval(node.Right, env)
-		if isError(right) {
+		if isAbrupt(right) {
 			return right
 		}
-		return evalInfixExpression(node.Operator, left, right)
+		if result, ok := evalOverloadedInfixExpression(node, left, right); ok {
+			return result
+		}
+		return setErrorPosition(evalInfixExpression(node.Operator, left, right), node.Token)
+	case *ast.PostfixExpression:
+		left := Eval(node.Left, env)
+		if isAbrupt(left) {
+			return left
+		}
+		return setErrorPosition(evalPostfixExpression(node.Operator, left), node.Token)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
+	case *ast.TryExpression:
+		return evalTryExpression(node, env, Eval)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env, Eval)
+	case *ast.SelectExpression:
+		return evalSelectExpression(node, env, Eval)
+	case *ast.YieldExpression:
+		return evalYieldExpression(node, env, Eval)
+	case *ast.AwaitExpression:
+		return evalAwaitExpression(node, env, Eval)
 	case *ast.IntegerLiteral:
-		return &object.Integer{Value: node.Value}
+		return object.NewInteger(node.Value)
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isAbrupt(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env, Eval)
+	case *ast.StructLiteral:
+		return setErrorPosition(evalStructLiteral(node, env, Eval), node.Token)
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 	case *ast.LetStatement:
 		val := Eval(node.Value, env)
-		if isError(val) {
+		if isAbrupt(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if node.Pattern != nil {
+			return evalDestructuringLet(node, val, env)
+		}
+		bind(env, node.Name, val)
 	case *ast.Identifier:
-		return evalIdentifier(node, env)
+		return setErrorPosition(evalIdentifier(node, env), node.Token)
+	case *ast.FunctionLiteral:
+		params := node.Parameters
+		body := node.Body
+		return &object.Function{Parameters: params, Env: env, Body: body, Name: node.Name, Generator: node.Generator, Async: node.Async}
+	case *ast.CallExpression:
+		function := Eval(node.Function, env)
+		if isAbrupt(function) {
+			return function
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isAbrupt(args[0]) {
+			return args[0]
+		}
+		return callFunction(function, args, node.Token)
+	case *ast.IndexExpression:
+		left := Eval(node.Left, env)
+		if isAbrupt(left) {
+			return left
+		}
+		index := Eval(node.Index, env)
+		if isAbrupt(index) {
+			return index
+		}
+		return setErrorPosition(evalIndexExpression(left, index), node.Token)
+	case *ast.FieldExpression:
+		left := Eval(node.Left, env)
+		if isAbrupt(left) {
+			return left
+		}
+		return setErrorPosition(evalFieldExpression(left, node.Field.Value), node.Token)
 	}
 
 	return nil
@@ -105,7 +188,7 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 	case "-":
 		return evalMinuxPrefixOperatorExpression(right)
 	default:
-		return newError("unknown operator: %s%s", operator, right.Type())
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "unknown operator: %s%s", operator, typeName(right))
 	}
 }
 
@@ -123,11 +206,37 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinuxPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
-		return newError("unknown operator: -%s", right.Type())
+	switch right := right.(type) {
+	case *object.Integer:
+		return object.NewInteger(-right.Value)
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "unknown operator: -%s", typeName(right))
+	}
+}
+
+func evalPostfixExpression(operator string, left object.Object) object.Object {
+	switch operator {
+	case "?":
+		return evalPropagateOperatorExpression(left)
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "unknown operator: %s%s", typeName(left), operator)
+	}
+}
+
+// ok(v)? -> v
+// err(e)? -> 関数からerr(e)をreturnする(ReturnValueとしてevalBlockStatementを抜ける)
+func evalPropagateOperatorExpression(left object.Object) object.Object {
+	result, ok := left.(*object.Result)
+	if !ok {
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "unknown operator: %s?", typeName(left))
+	}
+
+	if result.Ok {
+		return result.Value
 	}
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
+	return &object.ReturnValue{Value: result}
 }
 
 func nativeBoolToBooleanObject(input bool) *object.Boolean {
@@ -141,14 +250,20 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left, right)
+	case isEnumValue(left) && isEnumValue(right):
+		return evalEnumInfixExpression(operator, left, right)
 	case operator == "==":
 		return nativeBoolToBooleanObject(left == right)
 	case operator == "!=":
 		return nativeBoolToBooleanObject(left != right)
-	case left.Type() != right.Type():
-		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	case left.Type() != right.Type() || typeName(left) != typeName(right):
+		return newError(object.TYPE_MISMATCH_ERROR, "type mismatch: %s %s %s", typeName(left), operator, typeName(right))
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "unknown operator: %s %s %s", typeName(left), operator, typeName(right))
 	}
 }
 
@@ -158,13 +273,47 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		return object.NewInteger(leftVal + rightVal)
+	case "-":
+		return object.NewInteger(leftVal - rightVal)
+	case "*":
+		return object.NewInteger(leftVal * rightVal)
+	case "/":
+		if rightVal == 0 {
+			return newError(object.DIVISION_BY_ZERO_ERROR, "division by zero: %d / 0", leftVal)
+		}
+		return object.NewInteger(leftVal / rightVal)
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "unknown operator: %s %s %s", typeName(left), operator, typeName(right))
+	}
+}
+
+// どちらかがFloatなら、もう片方もFloatにして計算する
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		return &object.Float{Value: leftVal - rightVal}
 	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+		return &object.Float{Value: leftVal * rightVal}
 	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
+		// 整数と同じく、0で割ったら+Infではなくerror
+		if rightVal == 0 {
+			return newError(object.DIVISION_BY_ZERO_ERROR, "division by zero: %s / %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: leftVal / rightVal}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
@@ -174,13 +323,45 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	case "!=":
 		return nativeBoolToBooleanObject(leftVal != rightVal)
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "unknown operator: %s %s %s", typeName(left), operator, typeName(right))
+	}
+}
+
+func isNumber(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Integer, *object.Float:
+		return true
+	}
+	return false
+}
+
+// isNumberなものだけを渡す
+func toFloat(obj object.Object) float64 {
+	if i, ok := obj.(*object.Integer); ok {
+		return float64(i.Value)
+	}
+	return obj.(*object.Float).Value
+}
+
+func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "unknown operator: %s %s %s", typeName(left), operator, typeName(right))
 	}
 }
 
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
-	if isError(condition) {
+	if isAbrupt(condition) {
 		return condition
 	}
 
@@ -193,15 +374,307 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	}
 }
 
-func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
-	val, ok := env.Get(node.Value)
+// tryの中で起きたerrorはcatchにExceptionとして渡される
+// catchの変数は外側の環境を汚さないように新しい環境に束縛する
+// exit()はcatchせずにfinallyだけを実行して外へ出る
+func evalTryExpression(te *ast.TryExpression, env *object.Environment, eval evalFunc) object.Object {
+	result := eval(te.Block, env)
+
+	err, failed := result.(*object.Error)
+	exiting := failed && err.Kind == object.EXIT_ERROR
+	if failed && te.Catch != nil && !exiting {
+		catchEnv := object.NewEnclosedEnvironment(env)
+		bind(catchEnv, te.CatchParameter, &object.Exception{Error: err})
+		result = eval(te.Catch, catchEnv)
+	}
+
+	if te.Finally != nil {
+		// finallyの中でreturnやerrorが起きた場合はそちらが優先される(exitの途中なら、exitが優先)
+		finally := eval(te.Finally, env)
+		if finally != nil && !exiting {
+			rt := finally.Type()
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+				return finally
+			}
+		}
+	}
+
+	return result
+}
+
+func evalThrowStatement(ts *ast.ThrowStatement, env *object.Environment, eval evalFunc) object.Object {
+	val := eval(ts.Value, env)
+	if isAbrupt(val) {
+		return val
+	}
+	if val == nil {
+		val = NULL
+	}
+
+	// catchしたものを投げ直す場合は元のerrorを引き継ぐ
+	if exception, ok := val.(*object.Exception); ok {
+		err := *exception.Error
+		err.Stack = append([]object.StackFrame{}, err.Stack...)
+		return &err
+	}
+
+	return &object.Error{
+		Kind:    object.THROWN_ERROR,
+		Message: val.Inspect(),
+		Line:    ts.Token.Line,
+		Column:  ts.Token.Column,
+		Value:   val,
+	}
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.HASH_OBJ:
+		return evalHashIndexExpression(left, index)
+	case left.Type() == object.EXCEPTION_OBJ && index.Type() == object.STRING_OBJ:
+		return evalExceptionIndexExpression(left, index)
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "index operator not supported: %s", typeName(left))
+	}
+}
+
+// 範囲外はNULL
+func evalArrayIndexExpression(array, index object.Object) object.Object {
+	arrayObject := array.(*object.Array)
+	idx := index.(*object.Integer).Value
+	max := int64(len(arrayObject.Elements) - 1)
+
+	if idx < 0 || idx > max {
+		return NULL
+	}
+
+	return arrayObject.Elements[idx]
+}
+
+func evalHashIndexExpression(hash, index object.Object) object.Object {
+	hashObject := hash.(*object.Hash)
+
+	key, ok := index.(object.Hashable)
 	if !ok {
-		return newError("identifier not found: " + node.Value)
+		return newError(object.TYPE_MISMATCH_ERROR, "unusable as hash key: %s", typeName(index))
+	}
+
+	pair, ok := hashObject.Pairs[key.HashKey()]
+	if !ok {
+		return NULL
+	}
+
+	return pair.Value
+}
+
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment, eval evalFunc) object.Object {
+	pairs := make(map[object.HashKey]object.HashPair)
+
+	for i, keyNode := range node.Keys {
+		key := eval(keyNode, env)
+		if isAbrupt(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return setErrorPosition(newError(object.TYPE_MISMATCH_ERROR, "unusable as hash key: %s", typeName(key)), node.Token)
+		}
+
+		value := eval(node.Values[i], env)
+		if isAbrupt(value) {
+			return value
+		}
+
+		pairs[hashKey.HashKey()] = object.HashPair{Key: key, Value: value}
 	}
 
+	return &object.Hash{Pairs: pairs}
+}
+
+// e["message"], e["kind"], e["line"], e["column"], e["value"]
+func evalExceptionIndexExpression(exception, index object.Object) object.Object {
+	field := index.(*object.String).Value
+
+	val, ok := exceptionField(exception.(*object.Exception), field)
+	if !ok {
+		return newError(object.INDEX_ERROR, "unknown field: %s[%q]", typeName(exception), field)
+	}
 	return val
 }
 
+// e.message や e["message"] で参照できる値
+func exceptionField(exception *object.Exception, field string) (object.Object, bool) {
+	err := exception.Error
+
+	switch field {
+	case "message":
+		return &object.String{Value: err.Message}, true
+	case "kind":
+		return &object.String{Value: err.Kind}, true
+	case "line":
+		return object.NewInteger(int64(err.Line)), true
+	case "column":
+		return object.NewInteger(int64(err.Column)), true
+	case "value":
+		if err.Value == nil {
+			return NULL, true
+		}
+		return err.Value, true
+	default:
+		return nil, false
+	}
+}
+
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	// resolverを通したprogramではslotで引く(まだ束縛されていなければ名前で探す)
+	if node.Resolved {
+		if val, ok := env.GetAt(node.Depth, node.Slot); ok {
+			return val
+		}
+	}
+
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+
+	if builtin, ok := builtins[node.Value]; ok {
+		return builtin
+	}
+	if _, ok := runtimeBuiltins[node.Value]; ok {
+		return runtimeObjectOf(env).builtins[node.Value]
+	}
+	if makeBuiltin, ok := envBuiltins[node.Value]; ok {
+		return &object.Builtin{Fn: makeBuiltin(env)}
+	}
+	if module, ok := modules[node.Value]; ok {
+		return module
+	}
+
+	return newError(object.UNKNOWN_IDENTIFIER_ERROR, "identifier not found: "+node.Value)
+}
+
+func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	result := make([]object.Object, 0, len(exps))
+
+	for _, e := range exps {
+		evaluated := Eval(e, env)
+		if isAbrupt(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+
+	return result
+}
+
+// 関数の中で起きたerrorには呼び出し元(tokの位置)のframeを積んでいく
+func callFunction(fn object.Object, args []object.Object, tok token.Token) object.Object {
+	result := setErrorPosition(applyFunction(fn, args), tok)
+
+	if bound, ok := fn.(*object.BoundMethod); ok {
+		fn = bound.Method
+	}
+
+	if err, ok := result.(*object.Error); ok {
+		if function, ok := fn.(*object.Function); ok {
+			err.Stack = append(err.Stack, object.StackFrame{
+				Function: functionName(function),
+				Line:     tok.Line,
+				Column:   tok.Column,
+			})
+		}
+	}
+
+	return result
+}
+
+// Goから関数の値(Function, Builtin, BoundMethod, Variant)を呼ぶ
+func CallFunction(fn object.Object, args ...object.Object) object.Object {
+	return callFunction(fn, args, token.Token{})
+}
+
+func applyFunction(fn object.Object, args []object.Object) object.Object {
+	switch fn := fn.(type) {
+	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			return newError(object.ARGUMENT_ERROR, "wrong number of arguments: want=%d, got=%d", len(fn.Parameters), len(args))
+		}
+		if fn.Generator {
+			return newGenerator(fn, args)
+		}
+		if fn.Async {
+			return callAsync(fn, args)
+		}
+		extendedEnv := extendFunctionEnv(fn, args)
+		var evaluated object.Object
+		if fn.Code != nil {
+			evaluated = fn.Code(extendedEnv)
+		} else {
+			evaluated = Eval(fn.Body, extendedEnv)
+		}
+		return unwrapReturnValue(evaluated)
+	case *object.Builtin:
+		return fn.Fn(args...)
+	case *object.BoundMethod:
+		return applyFunction(fn.Method, append([]object.Object{fn.Receiver}, args...))
+	case *object.Variant:
+		return applyVariant(fn, args)
+	default:
+		return newError(object.NOT_A_FUNCTION_ERROR, "not a function: %s", typeName(fn))
+	}
+}
+
+func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+	env := object.NewEnclosedEnvironment(fn.Env)
+
+	for paramIdx, param := range fn.Parameters {
+		bind(env, param, args[paramIdx])
+	}
+
+	return env
+}
+
+// resolverがslotを割り当てた名前はslotでも引けるように束縛する
+func bind(env *object.Environment, ident *ast.Identifier, val object.Object) {
+	if ident.Resolved {
+		env.SetAt(ident.Slot, ident.Value, val)
+		return
+	}
+	env.Set(ident.Value, val)
+}
+
+// return文で関数の評価を止めるが、呼び出し元の評価までは止めない
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+
+	return obj
+}
+
+func functionName(fn *object.Function) string {
+	if fn.Name == "" {
+		return "<anonymous>"
+	}
+	return fn.Name
+}
+
+// error messageに出す型の名前。structとenumの値は宣言した名前(Type()はINSTANCEとENUM_VALUE)
+func typeName(obj object.Object) object.ObjectType {
+	if named, ok := obj.(interface{ TypeName() string }); ok {
+		return object.ObjectType(named.TypeName())
+	}
+	return obj.Type()
+}
+
+func isEnumValue(obj object.Object) bool {
+	_, ok := obj.(*object.EnumValue)
+	return ok
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
@@ -215,13 +688,23 @@ func isTruthy(obj object.Object) bool {
 	}
 }
 
-func newError(format string, a ...interface{}) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, a...)}
+func newError(kind string, format string, a ...interface{}) *object.Error {
+	return &object.Error{Kind: kind, Message: fmt.Sprintf(format, a...)}
+}
+
+// errorが発生した位置を記録する(内側ですでに記録されていればそのまま)
+func setErrorPosition(obj object.Object, tok token.Token) object.Object {
+	if err, ok := obj.(*object.Error); ok && err.Line == 0 {
+		err.Line, err.Column = tok.Line, tok.Column
+	}
+	return obj
 }
 
-func isError(obj object.Object) bool {
+// errorか、?による早期returnであれば式の評価をそこで打ち切る
+func isAbrupt(obj object.Object) bool {
 	if obj != nil {
-		return obj.Type() == object.ERROR_OBJ
+		rt := obj.Type()
+		return rt == object.ERROR_OBJ || rt == object.RETURN_VALUE_OBJ
 	}
 	return false
 }
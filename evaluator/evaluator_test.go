@@ -1,19 +1,72 @@
 package evaluator
 
 import (
+	"fmt"
 	"monkey/lexer"
 	"monkey/object"
 	"monkey/parser"
+	"monkey/resolver"
+	"sort"
+	"strings"
 	"testing"
 )
 
+// tree-walkerとCompileの両方で評価する
+// 結果が食い違っていればそのことを示すErrorを返すので、どのテストも両方を確かめることになる
 func testEval(input string) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
 	program := p.ParseProgram()
 	env := object.NewEnvironment()
+	evaluated := Eval(program, env)
 
-	return Eval(program, env)
+	compiled := Compile(parser.New(lexer.New(input)).ParseProgram())(object.NewEnvironment())
+	if describe(evaluated) != describe(compiled) {
+		return &object.Error{Message: fmt.Sprintf("compiled result differs: eval=%s, compiled=%s", describe(evaluated), describe(compiled))}
+	}
+
+	return evaluated
+}
+
+// 結果を比べるための文字列(hashはkeyの順に並べる)
+func describe(obj object.Object) string {
+	switch obj := obj.(type) {
+	case nil:
+		return "nil"
+	case *object.Error:
+		return fmt.Sprintf("%s %d:%d %s", obj.Kind, obj.Line, obj.Column, obj.Inspect())
+	case *object.Array:
+		elements := []string{}
+		for _, el := range obj.Elements {
+			elements = append(elements, describe(el))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *object.Hash:
+		pairs := []string{}
+		for _, pair := range obj.Pairs {
+			pairs = append(pairs, describe(pair.Key)+": "+describe(pair.Value))
+		}
+		sort.Strings(pairs)
+		return "{" + strings.Join(pairs, ", ") + "}"
+	default:
+		return string(obj.Type()) + " " + obj.Inspect()
+	}
+}
+
+// setupした環境で評価する(組み込み関数などをglobalな表に足さずに使うため)
+func testEvalWith(input string, setup func(env *object.Environment)) object.Object {
+	env := object.NewEnvironment()
+	setup(env)
+	evaluated := Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+
+	env = object.NewEnvironment()
+	setup(env)
+	compiled := Compile(parser.New(lexer.New(input)).ParseProgram())(env)
+
+	if describe(evaluated) != describe(compiled) {
+		return &object.Error{Message: "compiled result differs: eval=" + describe(evaluated) + ", compiled=" + describe(compiled)}
+	}
+	return evaluated
 }
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -240,6 +293,349 @@ func TestErrorHandling(t *testing.T) {
 			"foobar",
 			"identifier not found: foobar",
 		},
+		{
+			"1 / 0",
+			"division by zero: 1 / 0",
+		},
+		{
+			"let x = 0; let f = fn(n) { n / x }; f(5)",
+			"division by zero: 5 / 0",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q. got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestFunctionObject(t *testing.T) {
+	input := "fn(x) { x + 2; };"
+
+	evaluated := testEval(input)
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("object is not Function. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(fn.Parameters) != 1 {
+		t.Fatalf("function has wrong parameters. Parameters=%+v", fn.Parameters)
+	}
+
+	if fn.Parameters[0].String() != "x" {
+		t.Fatalf("parameter is not 'x'. got=%q", fn.Parameters[0])
+	}
+
+	expectedBody := "(x + 2)"
+
+	if fn.Body.String() != expectedBody {
+		t.Fatalf("body is not %q. got=%q", expectedBody, fn.Body.String())
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let identity = fn(x) { return x; }; identity(5);", 5},
+		{"let double = fn(x) { x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn(x) { x; }(5)", 5},
+		{"let f = fn() { return 1 }; f() + 1;", 2},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestClosures(t *testing.T) {
+	input := `
+let newAdder = fn(x) {
+	fn(y) { x + y };
+};
+
+let addTwo = newAdder(2);
+addTwo(2);`
+
+	testIntegerObject(t, testEval(input), 4)
+}
+
+func TestErrorStackTrace(t *testing.T) {
+	input := `let add = fn(a, b) { a + b };
+let twice = fn(x) {
+  add(x, true)
+};
+fn() { twice(1) }();`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expected := []object.StackFrame{
+		{Function: "add", Line: 3, Column: 6},
+		{Function: "twice", Line: 5, Column: 13},
+		{Function: "<anonymous>", Line: 5, Column: 18},
+	}
+	if len(errObj.Stack) != len(expected) {
+		t.Fatalf("wrong number of frames. want=%d, got=%d (%+v)", len(expected), len(errObj.Stack), errObj.Stack)
+	}
+	for i, frame := range expected {
+		if errObj.Stack[i] != frame {
+			t.Errorf("frame[%d] wrong. want=%+v, got=%+v", i, frame, errObj.Stack[i])
+		}
+	}
+
+	expectedInspect := `ERROR: type mismatch: INTEGER + BOOLEAN
+
+add(...)
+	at 3:6
+twice(...)
+	at 5:13
+<anonymous>(...)
+	at 5:18`
+	if errObj.Inspect() != expectedInspect {
+		t.Errorf("wrong Inspect. want=%q, got=%q", expectedInspect, errObj.Inspect())
+	}
+}
+
+func TestWrongNumberOfArguments(t *testing.T) {
+	evaluated := testEval("let f = fn(a, b) { a }; f(1);")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "wrong number of arguments: want=2, got=1" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestStringLiteral(t *testing.T) {
+	evaluated := testEval(`"Hello World!"`)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello World!" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestStringConcatenation(t *testing.T) {
+	evaluated := testEval(`"Hello" + " " + "World!"`)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello World!" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+
+	testBooleanObject(t, testEval(`"a" + "b" == "ab"`), true)
+	testBooleanObject(t, testEval(`"a" != "a"`), false)
+}
+
+func TestTryCatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`try { 1 } catch (e) { 2 }`, 1},
+		{`try { 1 + true } catch (e) { 2 }`, 2},
+		{`try { missing } catch (e) { 0 }`, 0},
+		{`try { throw 5; 1 } catch (e) { e["value"] + 1 }`, 6},
+		{`try { throw "boom" } catch (e) { e["message"] }`, "boom"},
+		{`try { throw "boom" } catch (e) { e["kind"] }`, "Thrown"},
+		{`try { 1 + true } catch (e) { e["kind"] }`, "TypeMismatch"},
+		{`try { -true } catch (e) { e["message"] }`, "unknown operator: -BOOLEAN"},
+		{`try { missing } catch (e) { e["kind"] }`, "UnknownIdentifier"},
+		{`let x = 0; try { 1 / x } catch (e) { e["kind"] }`, "DivisionByZero"},
+		{"1;\n  try { 1 +\n true } catch (e) { e[\"line\"] * 100 + e[\"column\"] }", 211},
+		{`let f = fn() { throw "inner" }; try { f() } catch (e) { e["message"] }`, "inner"},
+		{`let f = fn(x) { try { x + true } catch (e) { return 7 }; 0 }; f(1)`, 7},
+		{`try { try { throw 1 } catch (e) { throw e } } catch (e) { e["value"] }`, 1},
+		{`let e = 10; try { throw 1 } catch (e) { 0 }; e`, 10},
+		{`let f = fn() { try { return 1 } finally { 2 } }; f()`, 1},
+		{`let f = fn() { try { return 1 } finally { return 2 } }; f()`, 2},
+		{`let x = try { 1 } finally { 2 }; x`, 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("String has wrong value. want=%q, got=%q", expected, str.Value)
+			}
+		}
+	}
+}
+
+func TestUncaughtErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedKind    string
+		expectedMessage string
+	}{
+		{`throw "boom"`, object.THROWN_ERROR, "boom"},
+		{`try { 1 } finally { throw 2 }`, object.THROWN_ERROR, "2"},
+		{`try { throw 1 } finally { 2 }`, object.THROWN_ERROR, "1"},
+		{`try { throw 1 } catch (e) { e["nope"] }`, object.INDEX_ERROR, `unknown field: EXCEPTION["nope"]`},
+		{`1[0]`, object.UNKNOWN_OPERATOR_ERROR, "index operator not supported: INTEGER"},
+		{`5(1)`, object.NOT_A_FUNCTION_ERROR, "not a function: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Kind != tt.expectedKind {
+			t.Errorf("wrong error kind. expected=%q. got=%q", tt.expectedKind, errObj.Kind)
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q. got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestResultPropagation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`ok(5)`, "ok(5)"},
+		{`err("bad")`, "err(bad)"},
+		{`ok(5)? + 1`, "6"},
+		{`err("bad")?; 1`, "err(bad)"},
+		{`let half = fn(x) { if (x / 2 * 2 == x) { ok(x / 2) } else { err("odd") } };
+		  let quarter = fn(x) { let h = half(x)?; ok(half(h)?) };
+		  quarter(8)`, "ok(2)"},
+		{`let half = fn(x) { if (x / 2 * 2 == x) { ok(x / 2) } else { err("odd") } };
+		  let quarter = fn(x) { let h = half(x)?; ok(half(h)?) };
+		  quarter(6)`, "err(odd)"},
+		{`let f = fn() { let x = err(1)?; 99 }; let g = fn() { f(); 2 }; g()`, "2"},
+		{`let f = fn(r) { try { r? } catch (e) { 0 } }; f(err(1))`, "err(1)"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil {
+			t.Errorf("evaluated is nil for %q", tt.input)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestPropagateNonResult(t *testing.T) {
+	evaluated := testEval("5?")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "unknown operator: INTEGER?" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestBuiltinReturningResult(t *testing.T) {
+	half := &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			n := args[0].(*object.Integer).Value
+			if n%2 != 0 {
+				return object.ResultFromError(newError(object.ARGUMENT_ERROR, "%d is odd", n))
+			}
+			return &object.Result{Ok: true, Value: &object.Integer{Value: n / 2}}
+		},
+	}
+	withHalf := func(env *object.Environment) { env.Set("half", half) }
+
+	testIntegerObject(t, testEvalWith(`let f = fn(n) { ok(half(n)? + 1) }; f(4)?`, withHalf), 3)
+
+	evaluated := testEvalWith(`let f = fn(n) { ok(half(n)? + 1) }; f(3)`, withHalf)
+	result, ok := evaluated.(*object.Result)
+	if !ok || result.Ok {
+		t.Fatalf("expected err result. got=%T(%+v)", evaluated, evaluated)
+	}
+	exception, ok := result.Value.(*object.Exception)
+	if !ok {
+		t.Fatalf("err payload is not Exception. got=%T", result.Value)
+	}
+	if exception.Error.Message != "3 is odd" || exception.Error.Kind != object.ARGUMENT_ERROR {
+		t.Errorf("wrong error. got=%+v", exception.Error)
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`match (1) { 1 => 10, _ => 20 }`, 10},
+		{`match (5) { 1 => 10, _ => 20 }`, 20},
+		{`match (-1) { -1 => 10, _ => 20 }`, 10},
+		{`match (3) { 1 | 2 | 3 => 10, _ => 20 }`, 10},
+		{`match (7) { 1 => 10, n => n * 2 }`, 14},
+		{`match (7) { n if n < 5 => 1, n if n < 10 => 2, _ => 3 }`, 2},
+		{`match ("b") { "a" => 1, "b" => 2 }`, 2},
+		{`match (true) { false => 1, true => 2 }`, 2},
+		{`match (5) { 1 => 10 }`, nil},
+		{`match (1 < 2) { true => { let x = 5; x * 2 } }`, 10},
+		{`match (ok(5)) { ok(v) => v, err(e) => 0 }`, 5},
+		{`match (err(5)) { ok(v) => v, err(e) => e + 1 }`, 6},
+		{`match (ok(1)) { ok(2) => 2, ok(1) | err(1) => 1 }`, 1},
+		{`let f = fn(x) { match (x) { 0 => { return 100 }, _ => 1 }; 2 }; f(0)`, 100},
+		{`let n = 1; match (2) { n => n }; n`, 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`match (1) { foo(x) => 1 }`, "unknown constructor in pattern: foo"},
+		{`match (ok(1)) { ok(a, b) => 1 }`, "wrong number of arguments in pattern ok: want=1, got=2"},
+		{`match (1) { n if n + true => 1 }`, "type mismatch: INTEGER + BOOLEAN"},
+		{`match (missing) { _ => 1 }`, "identifier not found: missing"},
 	}
 
 	for _, tt := range tests {
@@ -249,9 +645,742 @@ func TestErrorHandling(t *testing.T) {
 			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
 			continue
 		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q. got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestArrayLiterals(t *testing.T) {
+	evaluated := testEval("[1, 2 * 2, 3 + 3]")
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(result.Elements) != 3 {
+		t.Fatalf("array has wrong num of elements. got=%d", len(result.Elements))
+	}
+
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
+}
+
+func TestIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][2]", 3},
+		{"let i = 0; [1][i];", 1},
+		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
+		{"[1, 2, 3][3]", nil},
+		{"[1, 2, 3][-1]", nil},
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`len({"a": 1, "b": 2}) + len([1]) + len("abc")`, 6},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestDestructuringLet(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = [1, 2]; a + b", "3"},
+		{"let [a, ...rest] = [1, 2, 3]; rest", "[2, 3]"},
+		{"let [a, ...rest] = [1]; rest", "[]"},
+		{"let [_, b] = [1, 2]; b", "2"},
+		{"let [x = 10] = []; x", "10"},
+		{"let [x = 10] = [1]; x", "1"},
+		{"let [a, b = a * 2] = [4]; b", "8"},
+		{"let [a, [b, c]] = [1, [2, 3]]; a + b + c", "6"},
+		{`let {name, age: years} = {"name": "monkey", "age": 3}; name`, "monkey"},
+		{`let {name, age: years} = {"name": "monkey", "age": 3}; years`, "3"},
+		{`let {nick = "none"} = {"name": "monkey"}; nick`, "none"},
+		{`let {pos: [x, y]} = {"pos": [3, 4]}; x * y`, "12"},
+		{`let {a: {b}} = {"a": {"b": 7}}; b`, "7"},
+		{`let [{id}, {id: second}] = [{"id": 1}, {"id": 2}]; id + second`, "3"},
+		{`let f = fn(pair) { let [k, v] = pair; v }; f(["k", 9])`, "9"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil {
+			t.Errorf("evaluated is nil for %q", tt.input)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"let [a, b] = 5;", "cannot destructure INTEGER as ARRAY"},
+		{"let [a, b] = [1];", "not enough elements: want at least 2, got 1"},
+		{"let [a] = [1, 2];", "too many elements: want 1, got 2"},
+		{"let {a} = [1];", "cannot destructure ARRAY as HASH"},
+		{`let {name} = {"age": 1};`, `missing key "name" in HASH`},
+		{`let {pos: [x, y]} = {"pos": 1};`, "cannot destructure INTEGER as ARRAY"},
+		{"let [x = missing] = [];", "identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q. got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestDestructuringIsAtomic(t *testing.T) {
+	input := `
+let a = 1;
+let b = 2;
+try { let [a, b, c] = [10, 20]; } catch (e) { 0 };
+a + b`
+
+	testIntegerObject(t, testEval(input), 3)
+}
+
+func TestMatchStructuralPatterns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match ([1, 2]) { [] => "empty", [x] => "one", [x, ...rest] => rest }`, "[2]"},
+		{`match ([]) { [] => "empty", [x] => "one" }`, "empty"},
+		{`match ({"kind": "circle", "r": 2}) { {kind: "square", side} => side, {kind: "circle", r} => r * 3 }`, "6"},
+		{`match (5) { [x] => x, _ => "other" }`, "other"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil {
+			t.Errorf("evaluated is nil for %q", tt.input)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct Point { x, y }; Point { x: 1, y: 2 }", "Point { x: 1, y: 2 }"},
+		{"struct Point { x, y }; Point { y: 2, x: 1 }", "Point { x: 1, y: 2 }"},
+		{"struct Point { x, y }; let p = Point { x: 3, y: 4 }; p.x * p.y", "12"},
+		{"struct Point { x, y }; let x = 5; let y = 6; Point { x, y }.y", "6"},
+		{`struct Point { x, y };
+		  impl Point {
+		    fn lenSq(self) { self.x * self.x + self.y * self.y }
+		    fn scale(self, n) { Point { x: self.x * n, y: self.y * n } }
+		  };
+		  let p = Point { x: 3, y: 4 };
+		  p.scale(2).lenSq()`, "100"},
+		{`struct Point { x, y }; impl Point { fn sum(self) { self.x + self.y } }; Point.sum(Point { x: 1, y: 2 })`, "3"},
+		{`struct Box { v }; let b = Box { v: Box { v: 7 } }; b.v.v`, "7"},
+		{`try { throw "boom" } catch (e) { e.message }`, "boom"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil {
+			t.Errorf("evaluated is nil for %q", tt.input)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestInstanceType(t *testing.T) {
+	evaluated := testEval("struct Point { x, y }; Point { x: 1, y: 2 }")
+	instance, ok := evaluated.(*object.Instance)
+	if !ok {
+		t.Fatalf("object is not Instance. got=%T (%+v)", evaluated, evaluated)
+	}
+	if instance.Type() != object.INSTANCE_OBJ || instance.TypeName() != "Point" {
+		t.Errorf("wrong instance type. Type()=%q TypeName()=%q", instance.Type(), instance.TypeName())
+	}
+
+	errObj, ok := testEval("struct Point { x }; Point { x: 1 } + 1").(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned")
+	}
+	if errObj.Message != "type mismatch: Point + INTEGER" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+// 組み込みの型と同じ名前のstructも、その型の値としては扱わない
+func TestStructNamedLikeBuiltinType(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct INTEGER { v }; INTEGER { v: 1 } + 1", "type mismatch: INTEGER + INTEGER"},
+		{"struct INTEGER { v }; INTEGER { v: 1 } + INTEGER { v: 2 }", "unknown operator: INTEGER + INTEGER"},
+		{"struct STRING { v }; STRING { v: 1 } + \"a\"", "type mismatch: STRING + STRING"},
+		{"struct FLOAT { v }; FLOAT { v: 1 } * 1.5", "type mismatch: FLOAT * FLOAT"},
+		{"struct ARRAY { v }; ARRAY { v: 1 }[0]", "index operator not supported: ARRAY"},
+		{"struct ERROR { v }; let f = fn() { ERROR { v: 1 }; 2 }; f()", "2"},
+		{"struct Point { x }; struct Vec { x }; Point { x: 1 } + Vec { x: 1 }", "type mismatch: Point + Vec"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		got := evaluated.Inspect()
+		if errObj, ok := evaluated.(*object.Error); ok {
+			got = errObj.Message
+		}
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedKind    string
+		expectedMessage string
+	}{
+		{"struct Point { x, y }; Point { x: 1, y: 2 }.z", object.FIELD_ERROR, "unknown field z on Point"},
+		{"struct Point { x, y }; Point { x: 1 }", object.FIELD_ERROR, "missing field y in Point"},
+		{"struct Point { x }; Point { x: 1, z: 2 }", object.FIELD_ERROR, "unknown field z on Point"},
+		{"let Point = 1; Point { x: 1 }", object.TYPE_MISMATCH_ERROR, "not a struct: INTEGER"},
+		{"Nope { x: 1 }", object.UNKNOWN_IDENTIFIER_ERROR, "identifier not found: Nope"},
+		{"impl Nope { fn f(self) { 1 } }", object.UNKNOWN_IDENTIFIER_ERROR, "identifier not found: Nope"},
+		{"struct P { x }; impl P { fn x(self) { 1 } }", object.FIELD_ERROR, "P already has a field named x"},
+		{"5.x", object.UNKNOWN_OPERATOR_ERROR, "field access not supported: INTEGER"},
+		{"struct P { x }; impl P { fn f(self) { self.y } }; P { x: 1 }.f()", object.FIELD_ERROR, "unknown field y on P"},
+		{"struct P { x }; let p = P { x: 1 }; p.x = 2;", object.FIELD_ERROR, "cannot assign to field x of P"},
+		{"let a = [1]; a.x = 2;", object.FIELD_ERROR, "cannot assign to field x of ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Kind != tt.expectedKind {
+			t.Errorf("wrong error kind. expected=%q. got=%q", tt.expectedKind, errObj.Kind)
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q. got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestMethodStackFrame(t *testing.T) {
+	evaluated := testEval("struct P { x }; impl P { fn f(self) { self.x + true } }; P { x: 1 }.f()")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if len(errObj.Stack) != 1 || errObj.Stack[0].Function != "P.f" {
+		t.Errorf("wrong stack. got=%+v", errObj.Stack)
+	}
+}
+
+func TestEnums(t *testing.T) {
+	decl := "enum Shape { Circle(r), Rect(w, h), Empty };\n"
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Circle(2)", "Circle(2)"},
+		{"Rect(1, 2)", "Rect(1, 2)"},
+		{"Empty", "Empty"},
+		{"Shape.Rect(3, 4)", "Rect(3, 4)"},
+		{"Shape.Empty == Empty", "true"},
+		{"Circle(2) == Circle(2)", "true"},
+		{"Circle(2) == Circle(3)", "false"},
+		{"Circle(2) != Circle(3)", "true"},
+		{"Circle(2) == Empty", "false"},
+		{"Circle(Rect(1, 2)) == Circle(Rect(1, 2))", "true"},
+		{`Circle("a") == Circle("a")`, "true"},
+		{"Rect(3, 4).h", "4"},
+		{`let area = fn(s) {
+		    match (s) { Circle(r) => 3 * r * r, Rect(w, h) => w * h, Empty => 0 }
+		  };
+		  area(Circle(2)) + area(Rect(3, 4)) + area(Empty)`, "24"},
+		{"match (Empty) { x => x }", "Empty"},
+		{"match (Circle(1)) { Empty => 0, Circle(_) => 1 }", "1"},
+		{"match (Rect(1, 2)) { Rect(1, h) => h, _ => 0 }", "2"},
+		{`impl Shape { fn isEmpty(self) { self == Empty } }; [Empty.isEmpty(), Circle(1).isEmpty()]`, "[true, false]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(decl + tt.input)
+		if evaluated == nil {
+			t.Errorf("evaluated is nil for %q", tt.input)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEnumValueType(t *testing.T) {
+	evaluated := testEval("enum Shape { Circle(r) }; Circle(1)")
+	value, ok := evaluated.(*object.EnumValue)
+	if !ok {
+		t.Fatalf("object is not EnumValue. got=%T (%+v)", evaluated, evaluated)
+	}
+	if value.Type() != object.ENUM_VALUE_OBJ || value.TypeName() != "Shape" || value.Variant.Name != "Circle" {
+		t.Errorf("wrong enum value. Type()=%q TypeName()=%q variant=%q", value.Type(), value.TypeName(), value.Variant.Name)
+	}
+
+	// 組み込みの型と同じ名前のenumも、その型の値としては扱わない
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum INTEGER { V(n) }; V(1) + 1", "type mismatch: INTEGER + INTEGER"},
+		{"enum STRING { S }; S + \"a\"", "type mismatch: STRING + STRING"},
+		{"enum HASH { H }; H[\"a\"]", "index operator not supported: HASH"},
+		{"enum Shape { Circle(r) }; enum Size { Big }; Circle(1) == Big", "false"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		got := evaluated.Inspect()
+		if errObj, ok := evaluated.(*object.Error); ok {
+			got = errObj.Message
+		}
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestEnumErrors(t *testing.T) {
+	decl := "enum Shape { Circle(r), Rect(w, h), Empty };\n"
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"Circle(1, 2)", "wrong number of arguments to Circle: want=1, got=2"},
+		{"Empty(1)", "not a function: Shape"},
+		{"Circle(1) + 1", "type mismatch: Shape + INTEGER"},
+		{"Circle(1) < Circle(2)", "unknown operator: Shape < Shape"},
+		{"Circle(1).x", "unknown field x on Shape"},
+		{"Shape.Square", "unknown field Square on ENUM"},
+		{"match (Empty) { Circle(a, b) => 1 }", "wrong number of arguments in pattern Circle: want=1, got=2"},
+		{"impl Shape { fn r(self) { 1 } }", "Shape already has a field named r"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(decl + tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q. got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestOperatorOverloading(t *testing.T) {
+	decl := `struct Vec { x, y };
+	impl Vec {
+	  fn add(self, o) { Vec { x: self.x + o.x, y: self.y + o.y } }
+	  fn sub(self, o) { Vec { x: self.x - o.x, y: self.y - o.y } }
+	  fn mul(self, n) { Vec { x: self.x * n, y: self.y * n } }
+	  fn rmul(self, n) { self * n }
+	  fn div(self, n) { Vec { x: self.x / n, y: self.y / n } }
+	  fn eq(self, o) { if (self.x == o.x) { self.y == o.y } else { false } }
+	  fn neg(self) { Vec { x: -self.x, y: -self.y } }
+	};
+	struct Money { cents };
+	impl Money {
+	  fn lt(self, o) { self.cents < o.cents }
+	  fn gt(self, o) { self.cents > o.cents }
+	  fn not(self) { self.cents == 0 }
+	};
+	struct Limit { max };
+	impl Limit {
+	  fn lt(self, n) { self.max < n }
+	  fn gt(self, n) { self.max > n }
+	};
+	let a = Vec { x: 1, y: 2 };
+	let b = Vec { x: 3, y: 4 };
+	`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a + b", "Vec { x: 4, y: 6 }"},
+		{"b - a", "Vec { x: 2, y: 2 }"},
+		{"a * 3", "Vec { x: 3, y: 6 }"},
+		{"b / 2", "Vec { x: 1, y: 2 }"},
+		{"-a", "Vec { x: -1, y: -2 }"},
+		{"a + b * 2", "Vec { x: 7, y: 10 }"},
+		{"a == Vec { x: 1, y: 2 }", "true"},
+		{"a == b", "false"},
+		{"a != b", "true"},
+		{"a != Vec { x: 1, y: 2 }", "false"},
+		{"Money { cents: 100 } < Money { cents: 250 }", "true"},
+		{"Money { cents: 100 } > Money { cents: 250 }", "false"},
+		{"!Money { cents: 0 }", "true"},
+		{"!Money { cents: 5 }", "false"},
+		// 左辺が定義していなければ右辺のrmulなどを使う。比較は向きを入れ替える
+		{"3 * a", "Vec { x: 3, y: 6 }"},
+		{"2 * a + b", "Vec { x: 5, y: 8 }"},
+		{"Vec { x: 1, y: 2 } == a", "true"},
+		{"5 < Limit { max: 10 }", "true"},
+		{"5 > Limit { max: 10 }", "false"},
+		{"1 + 2", "3"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(decl + tt.input)
+		if evaluated == nil {
+			t.Errorf("evaluated is nil for %q", tt.input)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestOperatorOverloadingOnEnums(t *testing.T) {
+	input := `enum Coin { Penny, Dime };
+	impl Coin {
+	  fn value(self) { match (self) { Penny => 1, Dime => 10 } }
+	  fn add(self, o) { self.value() + o.value() }
+	};
+	Dime + Penny + 0`
+	testIntegerObject(t, testEval(input), 11)
+}
+
+func TestOperatorOverloadingErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+		expectedStack   int
+	}{
+		{"struct M { c }; M { c: 1 } * 2", "type mismatch: M * INTEGER", 0},
+		{"struct M { c }; impl M { fn mul(self, n) { M { c: self.c * n } } }; 2 * M { c: 1 }", "type mismatch: INTEGER * M", 0},
+		{"struct M { c }; impl M { fn add(self, o) { self.c + o } }; M { c: 1 } + true", "type mismatch: INTEGER + BOOLEAN", 1},
+		{"struct M { c }; impl M { fn add(self) { 1 } }; M { c: 1 } + 1", "wrong number of arguments: want=1, got=2", 1},
+	}
 
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
 		if errObj.Message != tt.expectedMessage {
 			t.Errorf("wrong error message. expected=%q. got=%q", tt.expectedMessage, errObj.Message)
 		}
+		if len(errObj.Stack) != tt.expectedStack {
+			t.Errorf("wrong stack for %q. got=%+v", tt.input, errObj.Stack)
+		}
+	}
+}
+
+// Go側で演算子を定義する型
+type celsius struct{ degrees int64 }
+
+func (c *celsius) Type() object.ObjectType { return "Celsius" }
+func (c *celsius) Inspect() string         { return fmt.Sprintf("%dC", c.degrees) }
+
+func (c *celsius) InfixOperator(operator string, right object.Object) (object.Object, bool) {
+	other, ok := right.(*celsius)
+	if !ok {
+		return nil, false
+	}
+	switch operator {
+	case "+":
+		return &celsius{degrees: c.degrees + other.degrees}, true
+	case "<":
+		return nativeBoolToBooleanObject(c.degrees < other.degrees), true
+	}
+	return nil, false
+}
+
+func (c *celsius) PrefixOperator(operator string) (object.Object, bool) {
+	if operator != "-" {
+		return nil, false
+	}
+	return &celsius{degrees: -c.degrees}, true
+}
+
+func TestHostOperatorOverloading(t *testing.T) {
+	withCelsius := func(env *object.Environment) {
+		env.Set("celsius", &object.Builtin{Fn: func(args ...object.Object) object.Object {
+			return &celsius{degrees: args[0].(*object.Integer).Value}
+		}})
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"celsius(20) + celsius(5)", "25C"},
+		{"-celsius(3)", "-3C"},
+		{"celsius(1) < celsius(2)", "true"},
+		{"celsius(1) + 1", "type mismatch: Celsius + INTEGER"},
+		{"celsius(1) * celsius(2)", "unknown operator: Celsius * Celsius"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWith(tt.input, withCelsius)
+		got := evaluated.Inspect()
+		if errObj, ok := evaluated.(*object.Error); ok {
+			got = errObj.Message
+		}
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+// fieldを名前で読み書きできるGoの値
+type record struct {
+	fields map[string]object.Object
+}
+
+func (r *record) Type() object.ObjectType { return "Record" }
+func (r *record) Inspect() string         { return "record" }
+
+func (r *record) GetField(name string) (object.Object, bool) {
+	val, ok := r.fields[name]
+	return val, ok
+}
+
+func (r *record) SetField(name string, value object.Object) error {
+	if _, ok := value.(*object.Integer); !ok {
+		return fmt.Errorf("%s must be an integer, got %s", name, value.Type())
+	}
+	r.fields[name] = value
+	return nil
+}
+
+func TestHostFields(t *testing.T) {
+	withRecord := func(env *object.Environment) {
+		env.Set("record", &object.Builtin{Fn: func(args ...object.Object) object.Object {
+			return &record{fields: map[string]object.Object{"n": args[0]}}
+		}})
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"record(1).n", "1"},
+		{"let r = record(1); r.n = r.n + 1; r.n", "2"},
+		{"let r = record(1); r.m = 5; r.m * 2", "10"},
+		{"let r = record(1); if (true) { r.n = 7 }; r.n", "7"},
+		{"record(1).m", "unknown field m on Record"},
+		{"let r = record(1); r.n = true;", "n must be an integer, got BOOLEAN"},
+		{"let r = record(1); r.n = x;", "identifier not found: x"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWith(tt.input, withRecord)
+		got := evaluated.Inspect()
+		if errObj, ok := evaluated.(*object.Error); ok {
+			got = errObj.Message
+		}
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func testEvalResolved(t *testing.T, input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if errs := resolver.New(BuiltinNames()).Resolve(program); len(errs) != 0 {
+		t.Fatalf("resolver errors for %q: %v", input, errs)
+	}
+	env := object.NewEnvironment()
+	evaluated := Eval(program, env)
+
+	compiled := Compile(program)(object.NewEnvironment())
+	if describe(evaluated) != describe(compiled) {
+		t.Errorf("compiled result differs for %q: eval=%s, compiled=%s", input, describe(evaluated), describe(compiled))
+	}
+
+	return evaluated
+}
+
+// resolverを通しても通さなくても同じ結果になる
+func TestResolvedEvaluation(t *testing.T) {
+	tests := []string{
+		"let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib(15)",
+		"let x = 1; let x = x + 1; x",
+		"let f = fn() { g() }; let g = fn() { 42 }; f()",
+		"let x = 1; let f = fn() { x }; let x = 2; f()",
+		"let x = 1; let f = fn() { let y = x; let x = 10; y + x }; f()",
+		"let adder = fn(a) { fn(b) { a + b } }; adder(2)(3)",
+		"if (false) { let y = 1 }; y",
+		"let f = fn() { if (false) { let z = 1 }; z }; f()",
+		"let len = fn(x) { 0 }; len([1, 2])",
+		"let f = fn(x) { len(x) }; let g = f([1, 2]); let len = fn(x) { 0 }; [g, f([1])]",
+		"let [a, b = a * 10] = [1]; a + b",
+		"let a = 1; let [a] = [5]; a",
+		"let {name, age = 3} = {\"name\": \"x\"}; name + \"!\"",
+		"enum Shape { Circle(r), Empty }; let area = fn(s) { match (s) { Circle(r) => r * r, Empty => 0 } }; area(Circle(3)) + area(Empty)",
+		"enum E { A, B }; let A = 5; match (B) { A => 1, other => other }",
+		"let k = 2; match ([1, 2, 3]) { [x, ...rest] if x < k => fn() { rest[1] + x + k }() }",
+		"struct P { x }; impl P { fn add(self, o) { P { x: self.x + o.x } } }; (P { x: 1 } + P { x: 2 }).x",
+		"try { throw \"boom\" } catch (e) { let m = e.message; fn() { m }() }",
+		"let r = fn(x) { let v = x?; ok(v + 1) }; [r(ok(1)), r(err(2))]",
+	}
+
+	for _, input := range tests {
+		want := testEval(input)
+		got := testEvalResolved(t, input)
+		if want == nil || got == nil {
+			if want != got {
+				t.Errorf("wrong result for %q. want=%v, got=%v", input, want, got)
+			}
+			continue
+		}
+		if got.Inspect() != want.Inspect() {
+			t.Errorf("wrong result for %q. want=%q, got=%q", input, want.Inspect(), got.Inspect())
+		}
+	}
+}
+
+// 小さな整数と真偽値だけの式はどちらのbackendでもallocateしない
+func TestArithmeticDoesNotAllocate(t *testing.T) {
+	tests := []string{
+		"1 + 2 * 3 - 4 / 2",
+		"-5 + 10 < 20",
+		"!(3 * 3 == 9) != true",
+	}
+
+	for _, input := range tests {
+		program := parser.New(lexer.New(input)).ParseProgram()
+		env := object.NewEnvironment()
+		code := Compile(program)
+
+		if allocs := testing.AllocsPerRun(100, func() { Eval(program, env) }); allocs != 0 {
+			t.Errorf("Eval allocated %v times for %q", allocs, input)
+		}
+		if allocs := testing.AllocsPerRun(100, func() { code(env) }); allocs != 0 {
+			t.Errorf("compiled code allocated %v times for %q", allocs, input)
+		}
+	}
+}
+
+func BenchmarkFib(b *testing.B) {
+	benchmarkBackends(b, "let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib(25)")
+}
+
+// ループがないので末尾再帰で足していく
+func BenchmarkLoopSum(b *testing.B) {
+	benchmarkBackends(b, "let sum = fn(i, acc) { if (i == 0) { acc } else { sum(i - 1, acc + i) } }; sum(10000, 0)")
+}
+
+// 整数の演算ばかりのprogram(allocateの回数を見る)
+func BenchmarkArithmetic(b *testing.B) {
+	benchmarkBackends(b, "let f = fn(i, acc) { if (i == 0) { acc } else { f(i - 1, acc + (i * 3 - 1) / 2 - i) } }; f(1000, 0)")
+}
+
+// 同じ計算を、結果がobject.NewIntegerのcacheに入る範囲の整数と入らない範囲の整数で比べる
+// (uncachedのallocs/opとの差がcacheで減ったallocateの回数)
+func BenchmarkSmallIntegerCache(b *testing.B) {
+	const sum = "let f = fn(i, acc) { if (i == base) { acc } else { f(i - 1, acc + i - i + 1) } }; f(base + 500, base)"
+	for _, bc := range []struct {
+		name string
+		base string
+	}{
+		{"cached", "0"},
+		{"uncached", "1000000"},
+	} {
+		program := parser.New(lexer.New("let base = " + bc.base + "; " + sum)).ParseProgram()
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Eval(program, object.NewEnvironment())
+			}
+		})
+	}
+}
+
+// tree-walkerとCompileを、resolverを通した場合と通さない場合で比べる
+func benchmarkBackends(b *testing.B, input string) {
+	for _, resolve := range []bool{false, true} {
+		program := parser.New(lexer.New(input)).ParseProgram()
+		name := ""
+		if resolve {
+			resolver.New(BuiltinNames()).Resolve(program)
+			name = "resolved/"
+		}
+
+		b.Run(name+"eval", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Eval(program, object.NewEnvironment())
+			}
+		})
+		b.Run(name+"compiled", func(b *testing.B) {
+			code := Compile(program)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				code(object.NewEnvironment())
+			}
+		})
+	}
+}
+
+// 組み込み関数にはどれも型がある(typesのCheckerとInferが使う)
+func TestBuiltinTypes(t *testing.T) {
+	types := BuiltinTypes()
+	for _, name := range BuiltinNames() {
+		if _, ok := types[name]; !ok {
+			t.Errorf("builtin %s has no type", name)
+		}
+		delete(types, name)
+	}
+	for name := range types {
+		t.Errorf("type given for %s, which is not a builtin", name)
 	}
 }
@@ -0,0 +1,311 @@
+package evaluator
+
+import (
+	"container/heap"
+	"monkey/object"
+	"sync"
+	"time"
+)
+
+// EventLoopが使う時計
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// 実際の時刻
+var SystemClock Clock = systemClock{}
+
+// test用の時計。Advanceしたときだけ進む
+// loopがtimerを待つときは眠らずにその時刻まで進むので、timerはすぐに順番どおり発火する
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.Advance(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+// timerのcallbackを呼び、awaitで止まっているasync fnを再開し、hostが解決したpromiseを反映する
+// Monkeyのコードは一度に一つしか動かない(Run, Poll, Evalを呼んだgoroutineとasync fnのgoroutineが交代で動く)
+// 他のgoroutineからはpromiseに触らず、NewPromiseが返すresolveで解決する
+type EventLoop struct {
+	clock Clock
+
+	mu      sync.Mutex
+	tasks   []func()
+	timers  timerQueue
+	seq     int
+	pending int           // NewPromiseで作ってまだ解決されていないpromiseの数
+	wake    chan struct{} // 他のgoroutineからtaskが積まれたとき
+}
+
+// loopはenvに束縛して、async fnやsetTimeoutから探す
+// 識別子に*は使えないので、scriptの名前とはぶつからない
+const loopKey = "*loop*"
+
+func NewEventLoop(clock Clock) *EventLoop {
+	return &EventLoop{clock: clock, wake: make(chan struct{}, 1)}
+}
+
+func (l *EventLoop) Type() object.ObjectType { return "EVENT_LOOP" }
+func (l *EventLoop) Inspect() string         { return "event loop" }
+
+// envとその内側で評価するコードからloopを使えるようにする
+func (l *EventLoop) Install(env *object.Environment) {
+	env.Set(loopKey, l)
+}
+
+func loopOf(env *object.Environment) *EventLoop {
+	if env == nil {
+		return nil
+	}
+	obj, _ := env.Get(loopKey)
+	l, _ := obj.(*EventLoop)
+	return l
+}
+
+// task、timer、NewPromiseで作った未解決のpromiseがなくなるまで回す
+func (l *EventLoop) Run() {
+	l.runUntil(nil)
+}
+
+// 今実行できるtaskと時間になったtimerだけを実行する(待たない)
+func (l *EventLoop) Poll() {
+	for l.runOne() {
+	}
+}
+
+// stopが閉じられるまで、taskと時間になったtimerを実行し続ける(やることがなくても戻らない)
+// REPLが入力を待っている間にtimerを発火させるため
+func (l *EventLoop) Serve(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if l.runOne() {
+			continue
+		}
+
+		l.mu.Lock()
+		var timeout <-chan time.Time
+		if len(l.timers) > 0 {
+			timeout = l.clock.After(l.timers[0].when.Sub(l.clock.Now()))
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-stop:
+			return
+		case <-l.wake:
+		case <-timeout:
+		}
+	}
+}
+
+// Goから解決するpromise。resolveはどのgoroutineから呼んでもよく、最初の一回だけ有効
+// *object.Errorを渡すとreject、それ以外はその値でfulfillする。Runはresolveされるまで待つ
+func (l *EventLoop) NewPromise() (*object.Promise, func(object.Object)) {
+	p := &object.Promise{}
+
+	l.mu.Lock()
+	l.pending++
+	l.mu.Unlock()
+
+	var once sync.Once
+	resolve := func(val object.Object) {
+		once.Do(func() {
+			l.post(func() { l.settle(p, val) }, true)
+		})
+	}
+	return p, resolve
+}
+
+// fnを別のgoroutineで実行し、結果のpromiseを返す組み込み関数にする
+func (l *EventLoop) AsyncBuiltin(fn object.BuiltinFunction) *object.Builtin {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		p, resolve := l.NewPromise()
+		go func() { resolve(fn(args...)) }()
+		return p
+	}}
+}
+
+// taskを積む。hostはNewPromiseのresolveから
+func (l *EventLoop) post(task func(), host bool) {
+	l.mu.Lock()
+	l.tasks = append(l.tasks, task)
+	if host {
+		l.pending--
+	}
+	l.mu.Unlock()
+
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// resultがerrorならreject、それ以外はfulfill。待っているcallbackはtaskとして積む
+func (l *EventLoop) settle(p *object.Promise, result object.Object) {
+	if p.State != object.PROMISE_PENDING {
+		return
+	}
+	if result == nil {
+		result = NULL
+	}
+
+	p.State, p.Value = object.PROMISE_FULFILLED, result
+	if _, ok := result.(*object.Error); ok {
+		p.State = object.PROMISE_REJECTED
+	}
+
+	callbacks := p.Callbacks
+	p.Callbacks = nil
+	for _, callback := range callbacks {
+		l.post(callback, false)
+	}
+}
+
+// 積まれたtaskか、時間になったtimerを一つ実行する
+func (l *EventLoop) runOne() bool {
+	l.mu.Lock()
+	if len(l.tasks) > 0 {
+		task := l.tasks[0]
+		l.tasks = l.tasks[1:]
+		l.mu.Unlock()
+		task()
+		return true
+	}
+	if len(l.timers) > 0 && !l.timers[0].when.After(l.clock.Now()) {
+		t := heap.Pop(&l.timers).(*timer)
+		l.mu.Unlock()
+		t.fire()
+		return true
+	}
+	l.mu.Unlock()
+	return false
+}
+
+// doneがtrueになるか、やることがなくなるまで回す。doneになればtrue
+func (l *EventLoop) runUntil(done func() bool) bool {
+	for {
+		if done != nil && done() {
+			return true
+		}
+		if l.runOne() {
+			continue
+		}
+
+		l.mu.Lock()
+		var timeout <-chan time.Time
+		if len(l.timers) > 0 {
+			timeout = l.clock.After(l.timers[0].when.Sub(l.clock.Now()))
+		}
+		idle := timeout == nil && l.pending == 0
+		l.mu.Unlock()
+
+		if idle {
+			return false
+		}
+		select {
+		case <-l.wake:
+		case <-timeout:
+		}
+	}
+}
+
+// 他のtaskを実行しながらpが決まるのを待つ
+func (l *EventLoop) wait(p *object.Promise) bool {
+	return l.runUntil(func() bool { return p.State != object.PROMISE_PENDING })
+}
+
+// setTimeout(fn, ms)
+func (l *EventLoop) setTimeout(fn object.Object, d time.Duration) *object.Promise {
+	p := &object.Promise{}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	t := &timer{when: l.clock.Now().Add(d), seq: l.seq, fire: func() {
+		l.settle(p, applyFunction(fn, nil))
+	}}
+	l.seq++
+	heap.Push(&l.timers, t)
+
+	p.Cancel = func() bool {
+		l.mu.Lock()
+		if t.index < 0 {
+			l.mu.Unlock()
+			return false
+		}
+		heap.Remove(&l.timers, t.index)
+		l.mu.Unlock()
+
+		l.settle(p, newError(object.CANCELLED_ERROR, "timer cancelled"))
+		return true
+	}
+	return p
+}
+
+type timer struct {
+	when  time.Time
+	seq   int // 同じ時刻なら登録した順
+	fire  func()
+	index int // timerQueueの中の位置(取り出したら-1)
+}
+
+// 時刻の早い順に取り出すheap
+type timerQueue []*timer
+
+func (q timerQueue) Len() int { return len(q) }
+func (q timerQueue) Less(i, j int) bool {
+	if q[i].when.Equal(q[j].when) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].when.Before(q[j].when)
+}
+func (q timerQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+func (q *timerQueue) Push(x any) {
+	t := x.(*timer)
+	t.index = len(*q)
+	*q = append(*q, t)
+}
+func (q *timerQueue) Pop() any {
+	old := *q
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	t.index = -1
+	*q = old[:len(old)-1]
+	return t
+}
@@ -0,0 +1,163 @@
+package evaluator
+
+import (
+	"bufio"
+	"io"
+	"monkey/object"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ファイルを扱う組み込み関数。pathはPermissionsで許したdirectoryの中だけ
+func init() {
+	envBuiltins["readFile"] = fileBuiltin("readFile", 1, readFile)
+	envBuiltins["writeFile"] = fileBuiltin("writeFile", 2, writeFile)
+	envBuiltins["listDir"] = fileBuiltin("listDir", 1, listDir)
+	envBuiltins["exists"] = fileBuiltin("exists", 1, exists)
+	envBuiltins["readLines"] = fileBuiltin("readLines", 1, readLines)
+}
+
+// 引数の数と、最初の引数(path)が文字列であることを確かめてからfnを呼ぶ
+func fileBuiltin(name string, arity int, fn func(p *Permissions, path string, args []object.Object) object.Object) func(env *object.Environment) object.BuiltinFunction {
+	return func(env *object.Environment) object.BuiltinFunction {
+		p := permissionsOf(env)
+		return func(args ...object.Object) object.Object {
+			if len(args) != arity {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `%s`: want=%d, got=%d", name, arity, len(args))
+			}
+			path, ok := args[0].(*object.String)
+			if !ok {
+				return newError(object.ARGUMENT_ERROR, "first argument to `%s` must be STRING, got %s", name, typeName(args[0]))
+			}
+			return fn(p, path.Value, args)
+		}
+	}
+}
+
+func ioError(name string, err error) *object.Error {
+	return newError(object.IO_ERROR, "%s: %s", name, err)
+}
+
+// readFile(path) ファイルの中身全体を文字列で
+func readFile(p *Permissions, path string, args []object.Object) object.Object {
+	canonical, denied := p.checkRead("readFile", path)
+	if denied != nil {
+		return denied
+	}
+	data, err := os.ReadFile(canonical)
+	if err != nil {
+		return ioError("readFile", err)
+	}
+	return &object.String{Value: string(data)}
+}
+
+// writeFile(path, content) ファイルを作るか、中身を置き換える
+func writeFile(p *Permissions, path string, args []object.Object) object.Object {
+	content, ok := args[1].(*object.String)
+	if !ok {
+		return newError(object.ARGUMENT_ERROR, "second argument to `writeFile` must be STRING, got %s", typeName(args[1]))
+	}
+	canonical, denied := p.checkWrite("writeFile", path)
+	if denied != nil {
+		return denied
+	}
+	// 確かめたあとにsymlinkに置き換えられていたら、その先には書かない
+	if info, err := os.Lstat(canonical); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return newError(object.PERMISSION_ERROR, "writeFile: %s is a symbolic link", canonical)
+	}
+	if err := os.WriteFile(canonical, []byte(content.Value), 0o644); err != nil {
+		return ioError("writeFile", err)
+	}
+	return NULL
+}
+
+// listDir(path) directoryの中の名前を名前の順に
+func listDir(p *Permissions, path string, args []object.Object) object.Object {
+	canonical, denied := p.checkRead("listDir", path)
+	if denied != nil {
+		return denied
+	}
+	entries, err := os.ReadDir(canonical)
+	if err != nil {
+		return ioError("listDir", err)
+	}
+	names := make([]object.Object, len(entries))
+	for i, entry := range entries {
+		names[i] = &object.String{Value: entry.Name()}
+	}
+	return &object.Array{Elements: names}
+}
+
+// exists(path) 読めるdirectoryの中にだけ尋ねられる
+func exists(p *Permissions, path string, args []object.Object) object.Object {
+	canonical, denied := p.checkRead("exists", path)
+	if denied != nil {
+		return denied
+	}
+	_, err := os.Stat(canonical)
+	return nativeBoolToBooleanObject(err == nil)
+}
+
+// readLines(path) 一行ずつ返すgenerator(fn*のgeneratorと同じくnextとcloseを持つ)
+// 行末の改行は含まない。最後まで読むかcloseするとファイルを閉じる
+func readLines(p *Permissions, path string, args []object.Object) object.Object {
+	canonical, denied := p.checkRead("readLines", path)
+	if denied != nil {
+		return denied
+	}
+	file, err := os.Open(canonical)
+	if err != nil {
+		return ioError("readLines", err)
+	}
+
+	lr := &lineReader{file: file, reader: bufio.NewReader(file)}
+	return &object.Generator{Next: lr.next, Close: lr.close}
+}
+
+type lineReader struct {
+	mu     sync.Mutex
+	file   *os.File
+	reader *bufio.Reader
+	done   bool
+}
+
+func (lr *lineReader) next(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `next`: want=0 or 1, got=%d", len(args))
+	}
+
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	if lr.done {
+		return generatorResult(NULL, true)
+	}
+	line, err := lr.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		lr.finish()
+		if err == io.EOF {
+			return generatorResult(NULL, true)
+		}
+		return ioError("next", err)
+	}
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	return generatorResult(&object.String{Value: line}, false)
+}
+
+func (lr *lineReader) close(args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `close`: want=0, got=%d", len(args))
+	}
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.finish()
+	return NULL
+}
+
+func (lr *lineReader) finish() {
+	if !lr.done {
+		lr.done = true
+		lr.file.Close()
+	}
+}
@@ -0,0 +1,192 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// dir/data(読める)、dir/out(書ける)、dir/secret(どちらでもない)とdata/link -> secret
+func setupSandbox(t *testing.T) (dir string, perms *Permissions) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sub := range []string{"data", "out", "secret"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{"data/in.txt": "a\nb\r\n\nc", "data/empty.txt": "", "secret/key": "s"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "secret"), filepath.Join(dir, "data", "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	perms = NewPermissions()
+	if err := perms.AllowRead(filepath.Join(dir, "data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := perms.AllowWrite(filepath.Join(dir, "out")); err != nil {
+		t.Fatal(err)
+	}
+	return dir, perms
+}
+
+func TestFileBuiltins(t *testing.T) {
+	dir, perms := setupSandbox(t)
+	path := func(name string) string { return strconv.Quote(filepath.Join(dir, name)) }
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`readFile(` + path("data/in.txt") + `)`, "STRING a\nb\r\n\nc"},
+		{`readFile(` + path("data/empty.txt") + `)`, "STRING "},
+		{`listDir(` + path("data") + `)`, "[STRING empty.txt, STRING in.txt, STRING link]"},
+		{`[exists(` + path("data/in.txt") + `), exists(` + path("data/nope") + `)]`, "[BOOLEAN true, BOOLEAN false]"},
+		{`writeFile(` + path("out/new.txt") + `, "hello")`, "NULL null"},
+		{`let g = readLines(` + path("data/in.txt") + `); [g.next()["value"], g.next()["value"], g.next()["value"], g.next()["value"], g.next()["done"], g.next()["done"]]`,
+			"[STRING a, STRING b, STRING , STRING c, BOOLEAN true, BOOLEAN true]"},
+		{`readLines(` + path("data/empty.txt") + `).next()["done"]`, "BOOLEAN true"},
+		{`let g = readLines(` + path("data/in.txt") + `); g.close(); g.next()["done"]`, "BOOLEAN true"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWith(tt.input, func(env *object.Environment) { InstallPermissions(env, perms) })
+		if got := describe(evaluated); got != tt.expected {
+			t.Errorf("wrong result for %q.\nwant=%s\ngot= %s", tt.input, tt.expected, got)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out", "new.txt"))
+	if err != nil || string(data) != "hello" {
+		t.Errorf("writeFile did not write the file. got=%q, %v", data, err)
+	}
+}
+
+func TestFileAccessIsSandboxed(t *testing.T) {
+	dir, perms := setupSandbox(t)
+	secret := filepath.Join(dir, "secret", "key")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`readFile("` + filepath.Join(dir, "secret/key") + `")`, "readFile: read access to " + secret + " denied: it is outside the directories allowed for reading"},
+		// 許したdirectoryの外へは..でもsymlinkでも出られない
+		{`readFile("` + filepath.Join(dir, "data") + `/../secret/key")`, "readFile: read access to " + secret + " denied: it is outside the directories allowed for reading"},
+		{`readFile("` + filepath.Join(dir, "data/link/key") + `")`, "readFile: read access to " + secret + " denied: it is outside the directories allowed for reading"},
+		{`listDir("` + filepath.Join(dir, "data/link") + `")`, "listDir: read access to " + filepath.Join(dir, "secret") + " denied: it is outside the directories allowed for reading"},
+		{`exists("` + secret + `")`, "exists: read access to " + secret + " denied: it is outside the directories allowed for reading"},
+		{`readLines("` + secret + `")`, "readLines: read access to " + secret + " denied: it is outside the directories allowed for reading"},
+		// 書けるdirectoryでも読めない、読めるdirectoryには書けない
+		{`readFile("` + filepath.Join(dir, "out/x") + `")`, "readFile: read access to " + filepath.Join(dir, "out/x") + " denied: it is outside the directories allowed for reading"},
+		{`writeFile("` + filepath.Join(dir, "data/x") + `", "")`, "writeFile: write access to " + filepath.Join(dir, "data/x") + " denied: it is outside the directories allowed for writing"},
+		{`writeFile("` + filepath.Join(dir, "out/../data/x") + `", "")`, "writeFile: write access to " + filepath.Join(dir, "data/x") + " denied: it is outside the directories allowed for writing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWith(tt.input, func(env *object.Environment) { InstallPermissions(env, perms) })
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Kind != object.PERMISSION_ERROR || errObj.Message != tt.expected {
+			t.Errorf("wrong result for %q.\nwant=%s\ngot= %s", tt.input, tt.expected, describe(evaluated))
+		}
+	}
+
+	// 何も許していなければ何も読めない
+	evaluated := testEval(`readFile("` + filepath.Join(dir, "data/in.txt") + `")`)
+	if errObj, ok := evaluated.(*object.Error); !ok || errObj.Kind != object.PERMISSION_ERROR {
+		t.Errorf("expected a PermissionDenied error without grants. got=%s", describe(evaluated))
+	}
+}
+
+// 行き先のまだないsymlinkを通して、許したdirectoryの外にファイルを作れない
+func TestWriteThroughDanglingSymlink(t *testing.T) {
+	dir, perms := setupSandbox(t)
+	outside := filepath.Join(dir, "secret", "pwned.txt")
+	if err := os.Symlink(outside, filepath.Join(dir, "out", "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../secret/rel.txt", filepath.Join(dir, "out", "rel")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`writeFile("` + filepath.Join(dir, "out/link") + `", "escaped")`, "writeFile: write access to " + outside + " denied: it is outside the directories allowed for writing"},
+		{`writeFile("` + filepath.Join(dir, "out/rel") + `", "escaped")`, "writeFile: write access to " + filepath.Join(dir, "secret/rel.txt") + " denied: it is outside the directories allowed for writing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWith(tt.input, func(env *object.Environment) { InstallPermissions(env, perms) })
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Kind != object.PERMISSION_ERROR || errObj.Message != tt.expected {
+			t.Errorf("wrong result for %q.\nwant=%s\ngot= %s", tt.input, tt.expected, describe(evaluated))
+		}
+	}
+	for _, name := range []string{"pwned.txt", "rel.txt"} {
+		if _, err := os.Lstat(filepath.Join(dir, "secret", name)); err == nil {
+			t.Errorf("%s was created outside the sandbox", name)
+		}
+	}
+}
+
+// /を許せば、その下のどこでも読める
+func TestAllowRoot(t *testing.T) {
+	dir, _ := setupSandbox(t)
+	perms := NewPermissions()
+	if err := perms.AllowRead(string(filepath.Separator)); err != nil {
+		t.Fatal(err)
+	}
+
+	evaluated := testEvalWith(`readFile("`+filepath.Join(dir, "secret/key")+`")`, func(env *object.Environment) { InstallPermissions(env, perms) })
+	if got := describe(evaluated); got != "STRING s" {
+		t.Errorf("wrong result. got=%s", got)
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	dir, perms := setupSandbox(t)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`readFile("` + filepath.Join(dir, "data/nope") + `")`, "readFile: open " + filepath.Join(dir, "data/nope") + ": no such file or directory"},
+		{`readFile(1)`, "first argument to `readFile` must be STRING, got INTEGER"},
+		{`writeFile("x")`, "wrong number of arguments to `writeFile`: want=2, got=1"},
+		{`writeFile("` + filepath.Join(dir, "out/x") + `", 1)`, "second argument to `writeFile` must be STRING, got INTEGER"},
+		{`readLines("` + filepath.Join(dir, "data/in.txt") + `").next(1, 2)`, "wrong number of arguments to `next`: want=0 or 1, got=2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWith(tt.input, func(env *object.Environment) { InstallPermissions(env, perms) })
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("wrong result for %q.\nwant=%s\ngot= %s", tt.input, tt.expected, describe(evaluated))
+		}
+	}
+}
+
+func TestAllowRequiresADirectory(t *testing.T) {
+	dir, _ := setupSandbox(t)
+	perms := NewPermissions()
+
+	if err := perms.AllowRead(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("expected an error for a missing directory")
+	}
+	err := perms.AllowWrite(filepath.Join(dir, "data", "in.txt"))
+	if err == nil || !strings.HasSuffix(err.Error(), "is not a directory") {
+		t.Errorf("expected an error for a file. got=%v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// generatorの本体は別のgoroutineで動かし、yieldのたびにnextを呼んだ側と値を受け渡す
+// 本体の環境にgeneratorKeyで束縛しておき、yieldはそれを探す(fn*の中のfnからもyieldできる)
+// 識別子に*は使えないので、scriptの名前とはぶつからない
+const generatorKey = "*generator*"
+
+type generatorState struct {
+	mu       sync.Mutex // nextの間持っている。その間の(本体の中からや、他のgoroutineからの)nextとcloseはerror
+	fn       *object.Function
+	env      *object.Environment
+	started  bool
+	finished bool
+	running  atomic.Bool // 本体が動いている間(nextが結果を待っている間)だけtrue
+
+	resume chan object.Object // next(v)のv
+	steps  chan generatorStep
+	stop   chan struct{} // closeされたら、止まっているyieldから抜けてgoroutineを終える
+}
+
+type generatorStep struct {
+	value object.Object
+	done  bool
+}
+
+// envに入れるのでObjectにしておく(scriptからは見えない)
+func (g *generatorState) Type() object.ObjectType { return "GENERATOR_STATE" }
+func (g *generatorState) Inspect() string         { return "generator state" }
+
+// fn*の関数を呼んだとき。本体は最初のnextまで動かさない
+// 参照されなくなったGeneratorはfinalizerで止めるので、途中で捨ててもgoroutineは残らない
+func newGenerator(fn *object.Function, args []object.Object) *object.Generator {
+	g := &generatorState{
+		fn:     fn,
+		env:    extendFunctionEnv(fn, args),
+		resume: make(chan object.Object),
+		steps:  make(chan generatorStep),
+		stop:   make(chan struct{}),
+	}
+	g.env.Set(generatorKey, g)
+
+	// goroutineからはgだけを参照し、generatorは参照しない(参照するとfinalizerが呼ばれない)
+	generator := &object.Generator{Next: g.next, Close: g.close}
+	runtime.SetFinalizer(generator, func(*object.Generator) { g.shutdown() })
+	return generator
+}
+
+// g.next() / g.next(v)
+func (g *generatorState) next(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `next`: want=0 or 1, got=%d", len(args))
+	}
+	var sent object.Object = NULL
+	if len(args) == 1 {
+		sent = args[0]
+	}
+	// 本体が動いている間(本体の中から呼んだときも)はerror。待つと本体は自分が返すstepを待つことになって止まる
+	if !g.mu.TryLock() {
+		return errGeneratorRunning("next")
+	}
+	defer g.mu.Unlock()
+
+	if g.finished {
+		return generatorResult(NULL, true)
+	}
+
+	g.running.Store(true)
+	if !g.started {
+		// 最初のnextに渡した値はどのyieldにも届かない
+		g.started = true
+		go g.run()
+	} else {
+		g.resume <- sent
+	}
+
+	step := <-g.steps
+	if step.done {
+		g.finished = true
+	}
+	if err, ok := step.value.(*object.Error); ok {
+		return err
+	}
+	return generatorResult(step.value, step.done)
+}
+
+// g.close() 止まっているyieldから先は実行しない(finallyも実行されない)
+func (g *generatorState) close(args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `close`: want=0, got=%d", len(args))
+	}
+	if !g.mu.TryLock() {
+		return errGeneratorRunning("close")
+	}
+	defer g.mu.Unlock()
+	g.stopLocked()
+	return NULL
+}
+
+func errGeneratorRunning(name string) *object.Error {
+	return newError(object.VALUE_ERROR, "%s: generator is already running", name)
+}
+
+// finalizerから。参照されていないのでnextの途中ではない
+func (g *generatorState) shutdown() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stopLocked()
+}
+
+func (g *generatorState) stopLocked() {
+	if g.started && !g.finished {
+		close(g.stop)
+	}
+	g.finished = true
+}
+
+func (g *generatorState) run() {
+	var result object.Object
+	if g.fn.Code != nil {
+		result = g.fn.Code(g.env)
+	} else {
+		result = Eval(g.fn.Body, g.env)
+	}
+	result = unwrapReturnValue(result)
+	if result == nil {
+		result = NULL
+	}
+
+	g.running.Store(false)
+	g.steps <- generatorStep{value: result, done: true}
+}
+
+// 本体のgoroutineから呼ばれる。nextに値を渡し、次のnextまで止まる
+func (g *generatorState) yield(value object.Object) object.Object {
+	// 終わったgeneratorの中のfnを外から呼んだときなど
+	if !g.running.CompareAndSwap(true, false) {
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "yield outside of a running generator")
+	}
+	g.steps <- generatorStep{value: value}
+
+	select {
+	case sent := <-g.resume:
+		return sent
+	case <-g.stop:
+		runtime.Goexit()
+		return nil
+	}
+}
+
+func evalYieldExpression(ye *ast.YieldExpression, env *object.Environment, eval evalFunc) object.Object {
+	var value object.Object = NULL
+	if ye.Value != nil {
+		value = eval(ye.Value, env)
+		if isAbrupt(value) {
+			return value
+		}
+	}
+
+	obj, _ := env.Get(generatorKey)
+	g, ok := obj.(*generatorState)
+	if !ok {
+		return setErrorPosition(newError(object.UNKNOWN_OPERATOR_ERROR, "yield outside of a running generator"), ye.Token)
+	}
+	return setErrorPosition(g.yield(value), ye.Token)
+}
+
+// {"value": value, "done": done}
+func generatorResult(value object.Object, done bool) *object.Hash {
+	valueKey := &object.String{Value: "value"}
+	doneKey := &object.String{Value: "done"}
+	return &object.Hash{Pairs: map[object.HashKey]object.HashPair{
+		valueKey.HashKey(): {Key: valueKey, Value: value},
+		doneKey.HashKey():  {Key: doneKey, Value: nativeBoolToBooleanObject(done)},
+	}}
+}
@@ -0,0 +1,171 @@
+package evaluator
+
+import (
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const generatorPrelude = `
+let nat = fn*(start) { let loop = fn(i) { yield i; loop(i + 1) }; loop(start) };
+let sum = fn(g, n, acc) { if (n == 0) { acc } else { sum(g, n - 1, acc + g.next()["value"]) } };
+let map = fn*(g, f) {
+  let loop = fn() { let step = g.next(); if (!step["done"]) { yield f(step["value"]); loop() } };
+  loop()
+};
+`
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`sum(nat(1), 10, 0)`, 55},
+		{`sum(map(nat(1), fn(x) { x * x }), 3, 0)`, 14},
+		{`let g = nat(5); g.next(); g.next()["value"]`, 6},
+		// 2つのgeneratorは別々に進む
+		{`let a = nat(0); let b = nat(100); a.next(); a.next(); b.next()["value"] + a.next()["value"]`, 102},
+		// next(v)のvがyieldの値になる
+		{`
+let acc = fn*() { let loop = fn(total) { let x = yield total; loop(total + x) }; loop(0) };
+let g = acc();
+g.next(); g.next(5);
+g.next(10)["value"]`, 15},
+		{`let g = fn*() { yield 1; return 7; }(); g.next(); g.next()["value"]`, 7},
+		{`let g = fn*() { yield 1; 2 }(); g.next(); g.next(); g.next()["value"]`, nil},
+		{`let g = fn*() { yield; }(); g.next()["value"]`, nil},
+		// 最初のnextまで本体は動かない
+		{`let c = channel(1); let g = fn*() { send(c, 1); yield 2 }(); select { recv(c) => 1, _ => 0 }`, 0},
+		{`let c = channel(1); let g = fn*() { send(c, 1); yield 2 }(); g.next(); select { recv(c) => 1, _ => 0 }`, 1},
+		{`let g = nat(1); g.next(); g.close(); g.next()["value"]`, nil},
+		{`let g = nat(1); g.close(); g.next()["value"]`, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(generatorPrelude + tt.input)
+		integer, ok := tt.expected.(int)
+
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestGeneratorProtocol(t *testing.T) {
+	evaluated := testEval(`let g = fn*() { yield 1; 2 }(); [g.next(), g.next(), g.next()]`)
+	expected := "[{STRING done: BOOLEAN false, STRING value: INTEGER 1}, " +
+		"{STRING done: BOOLEAN true, STRING value: INTEGER 2}, " +
+		"{STRING done: BOOLEAN true, STRING value: NULL null}]"
+	if describe(evaluated) != expected {
+		t.Errorf("wrong result.\nwant=%s\ngot= %s", expected, describe(evaluated))
+	}
+
+	if evaluated := testEval(`fn*() { }()`); evaluated.Type() != object.GENERATOR_OBJ {
+		t.Errorf("calling fn* should return a generator. got=%s", evaluated.Type())
+	}
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`fn*() { yield 1 + true }().next()`, "type mismatch: INTEGER + BOOLEAN"},
+		{`let g = fn*() { throw "boom" }(); g.next()`, "boom"},
+		{`fn*() { yield 1 }().next(1, 2)`, "wrong number of arguments to `next`: want=0 or 1, got=2"},
+		{`fn*() { yield 1 }().close(1)`, "wrong number of arguments to `close`: want=0, got=1"},
+		{`fn*() { yield 1 }().foo`, "unknown field foo on GENERATOR"},
+		{`fn*(a) { yield a }()`, "wrong number of arguments: want=1, got=0"},
+		// 終わったgeneratorの中のfnからはyieldできない
+		{`let g = fn*() { let inner = fn() { yield 1 }; yield inner }(); let inner = g.next()["value"]; inner()`, "yield outside of a running generator"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q. got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestGeneratorErrorFinishesIt(t *testing.T) {
+	evaluated := testEval(`let g = fn*() { throw "boom" }(); try { g.next() } catch (e) { 0 }; g.next()["done"]`)
+	testBooleanObject(t, evaluated, true)
+}
+
+// 本体の中から自分のnextやcloseを呼んでも止まらずにerrorになる
+func TestGeneratorReentry(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let gen = fn*() { yield 1; g.next(); yield 2 }; let g = gen(); g.next(); g.next()`,
+			"ValueError 1:34 ERROR: next: generator is already running"},
+		{`let gen = fn*() { yield 1; yield try { g.next() } catch (e) { e.message } }; let g = gen(); g.next(); g.next()["value"]`,
+			"STRING next: generator is already running"},
+		{`let gen = fn*() { yield try { g.close() } catch (e) { e.message }; yield 2 }; let g = gen(); [g.next()["value"], g.next()["value"]]`,
+			"[STRING close: generator is already running, INTEGER 2]"},
+	}
+
+	for _, tt := range tests {
+		done := make(chan string)
+		go func() { done <- describe(testEval(tt.input)) }()
+		select {
+		case got := <-done:
+			if got != tt.expected {
+				t.Errorf("wrong result for %q.\nwant=%s\ngot= %s", tt.input, tt.expected, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("generator deadlocked for %q", tt.input)
+		}
+	}
+}
+
+// 途中で捨てたgeneratorのgoroutineは、GCされたときに終わる
+func TestAbandonedGeneratorsDoNotLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	abandon := func() {
+		input := generatorPrelude + `let start = fn(n) { if (n > 0) { nat(n).next(); start(n - 1) } }; start(50);`
+		Eval(parser.New(lexer.New(input)).ParseProgram(), object.NewEnvironment())
+	}
+	abandon()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("generator goroutines leaked. before=%d, after=%d", before, runtime.NumGoroutine())
+		}
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClosedGeneratorStopsGoroutine(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	env := object.NewEnvironment()
+	Eval(parser.New(lexer.New(generatorPrelude+"let g = nat(1); g.next();")).ParseProgram(), env)
+	if runtime.NumGoroutine() <= before {
+		t.Fatalf("expected the generator to be running")
+	}
+	Eval(parser.New(lexer.New("g.close()")).ParseProgram(), env)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("closed generator is still running. before=%d, after=%d", before, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,450 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"monkey/object"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// objectはHash、arrayはArray、nullはNULL、true/falseはTRUE/FALSE
+// 数は小数点か指数があればFloat、なければInteger(int64に収まらなければFloat)
+var jsonModule = &object.Module{Name: "json", Members: map[string]object.Object{
+	"parse":     &object.Builtin{Fn: builtinJSONParse},
+	"stringify": &object.Builtin{Fn: builtinJSONStringify},
+}}
+
+// json.parse(s) 壊れたJSONは位置(行:列)つきのValueError
+func builtinJSONParse(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `json.parse`: want=1, got=%d", len(args))
+	}
+	s, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.ARGUMENT_ERROR, "argument to `json.parse` must be STRING, got %s", typeName(args[0]))
+	}
+
+	p := &jsonParser{src: s.Value}
+	value := p.parseValue(0)
+	if p.err == nil {
+		p.skipSpace()
+		if p.pos < len(p.src) {
+			p.fail("unexpected %s after the value", p.describeNext())
+		}
+	}
+	if p.err != nil {
+		return p.err
+	}
+	return value
+}
+
+// 入れ子をたどる再帰が深くなりすぎないように
+const maxJSONDepth = 1000
+
+// json.stringifyのindentの最大の文字数
+const maxJSONIndent = 10
+
+type jsonParser struct {
+	src string
+	pos int
+	err *object.Error
+}
+
+func (p *jsonParser) parseValue(depth int) object.Object {
+	if depth > maxJSONDepth {
+		p.fail("nesting is deeper than %d", maxJSONDepth)
+		return nil
+	}
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		p.fail("unexpected end of input, expected a value")
+		return nil
+	}
+
+	switch c := p.src[p.pos]; {
+	case c == '{':
+		return p.parseObject(depth)
+	case c == '[':
+		return p.parseArray(depth)
+	case c == '"':
+		if s, ok := p.parseString(); ok {
+			return &object.String{Value: s}
+		}
+		return nil
+	case c == '-' || isDigit(c):
+		return p.parseNumber()
+	case strings.HasPrefix(p.src[p.pos:], "true"):
+		p.pos += len("true")
+		return TRUE
+	case strings.HasPrefix(p.src[p.pos:], "false"):
+		p.pos += len("false")
+		return FALSE
+	case strings.HasPrefix(p.src[p.pos:], "null"):
+		p.pos += len("null")
+		return NULL
+	default:
+		p.fail("unexpected %s, expected a value", p.describeNext())
+		return nil
+	}
+}
+
+func (p *jsonParser) parseObject(depth int) object.Object {
+	p.pos++ // {
+	pairs := map[object.HashKey]object.HashPair{}
+
+	p.skipSpace()
+	if p.consume('}') {
+		return &object.Hash{Pairs: pairs}
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] != '"' {
+			p.fail("unexpected %s, expected a string key", p.describeNext())
+			return nil
+		}
+		s, ok := p.parseString()
+		if !ok {
+			return nil
+		}
+		p.skipSpace()
+		if !p.consume(':') {
+			p.fail("unexpected %s, expected ':' after the key", p.describeNext())
+			return nil
+		}
+		value := p.parseValue(depth + 1)
+		if p.err != nil {
+			return nil
+		}
+		// 同じkeyが何度も出てきたら最後のもの
+		key := &object.String{Value: s}
+		pairs[key.HashKey()] = object.HashPair{Key: key, Value: value}
+
+		p.skipSpace()
+		if p.consume('}') {
+			return &object.Hash{Pairs: pairs}
+		}
+		if !p.consume(',') {
+			p.fail("unexpected %s, expected ',' or '}'", p.describeNext())
+			return nil
+		}
+	}
+}
+
+func (p *jsonParser) parseArray(depth int) object.Object {
+	p.pos++ // [
+	elements := []object.Object{}
+
+	p.skipSpace()
+	if p.consume(']') {
+		return &object.Array{Elements: elements}
+	}
+	for {
+		value := p.parseValue(depth + 1)
+		if p.err != nil {
+			return nil
+		}
+		elements = append(elements, value)
+
+		p.skipSpace()
+		if p.consume(']') {
+			return &object.Array{Elements: elements}
+		}
+		if !p.consume(',') {
+			p.fail("unexpected %s, expected ',' or ']'", p.describeNext())
+			return nil
+		}
+	}
+}
+
+// 範囲を確かめてから、escapeの解釈はencoding/jsonに任せる(\uのsurrogate pairなど)
+func (p *jsonParser) parseString() (string, bool) {
+	start := p.pos
+	p.pos++ // "
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == '"':
+			p.pos++
+			var s string
+			if err := json.Unmarshal([]byte(p.src[start:p.pos]), &s); err != nil {
+				p.failAt(start, "invalid string: %s", err)
+				return "", false
+			}
+			return s, true
+		case c == '\\':
+			if !p.checkEscape() {
+				return "", false
+			}
+		case c < 0x20:
+			p.fail("control character %q in string", c)
+			return "", false
+		default:
+			p.pos++
+		}
+	}
+	p.failAt(start, "unterminated string")
+	return "", false
+}
+
+func (p *jsonParser) checkEscape() bool {
+	escape := p.pos
+	p.pos++ // \
+	if p.pos >= len(p.src) {
+		p.failAt(escape, "unterminated escape sequence")
+		return false
+	}
+	switch p.src[p.pos] {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		p.pos++
+		return true
+	case 'u':
+		p.pos++
+		for i := 0; i < 4; i++ {
+			if p.pos >= len(p.src) || !isHexDigit(p.src[p.pos]) {
+				p.failAt(escape, "invalid escape sequence: \\u must be followed by four hex digits")
+				return false
+			}
+			p.pos++
+		}
+		return true
+	default:
+		p.failAt(escape, "invalid escape sequence \\%c", p.src[p.pos])
+		return false
+	}
+}
+
+// -?(0|[1-9][0-9]*)(.[0-9]+)?([eE][+-]?[0-9]+)?
+func (p *jsonParser) parseNumber() object.Object {
+	start := p.pos
+	isFloat := false
+
+	p.consume('-')
+	switch {
+	case p.consume('0'):
+	case p.pos < len(p.src) && isDigit(p.src[p.pos]):
+		p.skipDigits()
+	default:
+		p.fail("unexpected %s, expected a digit", p.describeNext())
+		return nil
+	}
+	if p.consume('.') {
+		isFloat = true
+		if !p.skipDigits() {
+			p.fail("unexpected %s, expected a digit after '.'", p.describeNext())
+			return nil
+		}
+	}
+	if p.consume('e') || p.consume('E') {
+		isFloat = true
+		if !p.consume('+') {
+			p.consume('-')
+		}
+		if !p.skipDigits() {
+			p.fail("unexpected %s, expected a digit in the exponent", p.describeNext())
+			return nil
+		}
+	}
+
+	literal := p.src[start:p.pos]
+	if !isFloat {
+		if value, err := strconv.ParseInt(literal, 10, 64); err == nil {
+			return object.NewInteger(value)
+		}
+	}
+	value, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		p.failAt(start, "number %s is out of range", literal)
+		return nil
+	}
+	return &object.Float{Value: value}
+}
+
+func (p *jsonParser) skipDigits() bool {
+	start := p.pos
+	for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsonParser) consume(c byte) bool {
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *jsonParser) describeNext() string {
+	if p.pos >= len(p.src) {
+		return "end of input"
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return fmt.Sprintf("character %q", r)
+}
+
+func (p *jsonParser) fail(format string, a ...interface{}) {
+	p.failAt(p.pos, format, a...)
+}
+
+// 行と列は1から。列は文字数で数える
+func (p *jsonParser) failAt(offset int, format string, a ...interface{}) {
+	line, column := 1, 1
+	for _, r := range p.src[:offset] {
+		if r == '\n' {
+			line, column = line+1, 1
+		} else {
+			column++
+		}
+	}
+	p.err = newError(object.VALUE_ERROR, "json.parse: %d:%d: %s", line, column, fmt.Sprintf(format, a...))
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// json.stringify(v) / json.stringify(v, indent) indentは空白の数か、そのまま使う文字列
+// JavaScriptと同じく、indentは10文字までに切り詰める
+// objectのkeyは名前の順に並べるので、同じ値からはいつも同じ文字列になる
+func builtinJSONStringify(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `json.stringify`: want=1 to 2, got=%d", len(args))
+	}
+	indent := ""
+	if len(args) == 2 {
+		switch arg := args[1].(type) {
+		case *object.Integer:
+			if arg.Value < 0 {
+				return newError(object.ARGUMENT_ERROR, "indent passed to `json.stringify` must not be negative, got %d", arg.Value)
+			}
+			width := arg.Value
+			if width > maxJSONIndent {
+				width = maxJSONIndent
+			}
+			indent = strings.Repeat(" ", int(width))
+		case *object.String:
+			indent = arg.Value
+			if runes := []rune(indent); len(runes) > maxJSONIndent {
+				indent = string(runes[:maxJSONIndent])
+			}
+		default:
+			return newError(object.ARGUMENT_ERROR, "second argument to `json.stringify` must be INTEGER or STRING, got %s", typeName(args[1]))
+		}
+	}
+
+	s := &jsonStringifier{indent: indent}
+	if err := s.write(args[0], "value", 0); err != nil {
+		return err
+	}
+	return &object.String{Value: s.out.String()}
+}
+
+type jsonStringifier struct {
+	out    strings.Builder
+	indent string
+}
+
+// pathはerrorでどの値かを示すため(value["items"][2]など)
+func (s *jsonStringifier) write(obj object.Object, path string, depth int) *object.Error {
+	if depth > maxJSONDepth {
+		return newError(object.VALUE_ERROR, "json.stringify: %s is nested deeper than %d", path, maxJSONDepth)
+	}
+
+	switch obj := obj.(type) {
+	case *object.Null:
+		s.out.WriteString("null")
+	case *object.Boolean:
+		s.out.WriteString(strconv.FormatBool(obj.Value))
+	case *object.Integer:
+		s.out.WriteString(strconv.FormatInt(obj.Value, 10))
+	case *object.Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return newError(object.VALUE_ERROR, "json.stringify: cannot serialize %s at %s", obj.Inspect(), path)
+		}
+		// 1.0は1にしない(parseし直してもFloatのまま)
+		s.out.WriteString(obj.Inspect())
+	case *object.String:
+		s.writeString(obj.Value)
+	case *object.Array:
+		if len(obj.Elements) == 0 {
+			s.out.WriteString("[]")
+			break
+		}
+		s.out.WriteByte('[')
+		for i, el := range obj.Elements {
+			if i > 0 {
+				s.out.WriteByte(',')
+			}
+			s.newline(depth + 1)
+			if err := s.write(el, fmt.Sprintf("%s[%d]", path, i), depth+1); err != nil {
+				return err
+			}
+		}
+		s.newline(depth)
+		s.out.WriteByte(']')
+	case *object.Hash:
+		if len(obj.Pairs) == 0 {
+			s.out.WriteString("{}")
+			break
+		}
+		s.out.WriteByte('{')
+		for i, pair := range obj.SortedPairs() {
+			key, ok := pair.Key.(*object.String)
+			if !ok {
+				return newError(object.VALUE_ERROR, "json.stringify: object keys must be STRING, got %s key %s in %s", typeName(pair.Key), pair.Key.Inspect(), path)
+			}
+			if i > 0 {
+				s.out.WriteByte(',')
+			}
+			s.newline(depth + 1)
+			s.writeString(key.Value)
+			s.out.WriteByte(':')
+			if s.indent != "" {
+				s.out.WriteByte(' ')
+			}
+			if err := s.write(pair.Value, fmt.Sprintf("%s[%q]", path, key.Value), depth+1); err != nil {
+				return err
+			}
+		}
+		s.newline(depth)
+		s.out.WriteByte('}')
+	default:
+		return newError(object.VALUE_ERROR, "json.stringify: cannot serialize %s at %s", typeName(obj), path)
+	}
+	return nil
+}
+
+func (s *jsonStringifier) newline(depth int) {
+	if s.indent != "" {
+		s.out.WriteByte('\n')
+		s.out.WriteString(strings.Repeat(s.indent, depth))
+	}
+}
+
+// <や&もそのまま書く(json.Marshalは\u003cなどにする)
+func (s *jsonStringifier) writeString(value string) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(value)
+	s.out.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
@@ -0,0 +1,143 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"strings"
+	"testing"
+)
+
+// srcにJSONを束縛して評価する(Monkeyの文字列に書くとescapeが読みにくいので)
+func testEvalJSON(input, src string) object.Object {
+	return testEvalWith(input, func(env *object.Environment) {
+		env.Set("src", &object.String{Value: src})
+	})
+}
+
+func TestJSONParse(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{`{"name": "monkey", "tags": ["a", "b"], "age": 3, "ok": true}`,
+			`{STRING age: INTEGER 3, STRING name: STRING monkey, STRING ok: BOOLEAN true, STRING tags: [STRING a, STRING b]}`},
+		{`[1, -2, 1.5, 1e3, -0.25E-2, 0]`, `[INTEGER 1, INTEGER -2, FLOAT 1.5, FLOAT 1000.0, FLOAT -0.0025, INTEGER 0]`},
+		// int64に収まらない整数はFloat
+		{`123456789012345678901234`, `FLOAT 1.2345678901234569e+23`},
+		{`[null, false]`, `[NULL null, BOOLEAN false]`},
+		{` "a\"b\\c\/\né😀" `, "STRING a\"b\\c/\né😀"},
+		{`{}`, `{}`},
+		{`[]`, `[]`},
+		{`{"a": 1, "a": 2}`, `{STRING a: INTEGER 2}`},
+		{"\t[\r\n{ \"x\" : [ ] } ]\n", `[{STRING x: []}]`},
+	}
+
+	for _, tt := range tests {
+		if got := describe(testEvalJSON(`json.parse(src)`, tt.src)); got != tt.expected {
+			t.Errorf("wrong result for %s.\nwant=%s\ngot= %s", tt.src, tt.expected, got)
+		}
+	}
+
+	// nullとtrue/falseはevaluatorのものと同じ
+	if v := testEvalJSON(`json.parse(src)`, `null`); v != NULL {
+		t.Errorf("json null should be NULL. got=%s", describe(v))
+	}
+	if v := testEvalJSON(`json.parse(src)`, `true`); v != TRUE {
+		t.Errorf("json true should be TRUE. got=%s", describe(v))
+	}
+}
+
+func TestJSONParseErrors(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{``, "json.parse: 1:1: unexpected end of input, expected a value"},
+		{`{"a": 1,}`, `json.parse: 1:9: unexpected character '}', expected a string key`},
+		{`{"a" 1}`, `json.parse: 1:6: unexpected character '1', expected ':' after the key`},
+		{"[1,\n 2\n 3]", `json.parse: 3:2: unexpected character '3', expected ',' or ']'`},
+		{`[1, 2`, `json.parse: 1:6: unexpected end of input, expected ',' or ']'`},
+		{`{"é": tru}`, `json.parse: 1:7: unexpected character 't', expected a value`},
+		{`"abc`, `json.parse: 1:1: unterminated string`},
+		{`["a\qb"]`, `json.parse: 1:4: invalid escape sequence \q`},
+		{`"\u12"`, `json.parse: 1:2: invalid escape sequence: \u must be followed by four hex digits`},
+		{"\"a\nb\"", `json.parse: 1:3: control character '\n' in string`},
+		{`01`, `json.parse: 1:2: unexpected character '1' after the value`},
+		{`1.`, `json.parse: 1:3: unexpected end of input, expected a digit after '.'`},
+		{`-x`, `json.parse: 1:2: unexpected character 'x', expected a digit`},
+		{`1e+`, `json.parse: 1:4: unexpected end of input, expected a digit in the exponent`},
+		{`1e400`, `json.parse: 1:1: number 1e400 is out of range`},
+		{`{} {}`, `json.parse: 1:4: unexpected character '{' after the value`},
+		{strings.Repeat("[", 2000), "json.parse: 1:1002: nesting is deeper than 1000"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalJSON(`json.parse(src)`, tt.src)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Kind != object.VALUE_ERROR || errObj.Message != tt.expected {
+			t.Errorf("wrong result for %q.\nwant=%s\ngot= %s", tt.src, tt.expected, describe(evaluated))
+		}
+	}
+
+	errObj, ok := testEval(`json.parse(1)`).(*object.Error)
+	if !ok || errObj.Message != "argument to `json.parse` must be STRING, got INTEGER" {
+		t.Errorf("wrong error for a non-string argument. got=%+v", errObj)
+	}
+}
+
+func TestJSONStringify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let nothing = if (false) { 1 }; json.stringify({"b": [1, 2.5, 3.0], "a": nothing, "c": {"d": true}})`, `{"a":null,"b":[1,2.5,3.0],"c":{"d":true}}`},
+		{`json.stringify("<a & \"b\">\n")`, `"<a & \"b\">\n"`},
+		{`json.stringify([])`, `[]`},
+		{`json.stringify({})`, `{}`},
+		{`json.stringify(-7)`, `-7`},
+		{`json.stringify({"a": [1, {}], "b": 2}, 2)`, "{\n  \"a\": [\n    1,\n    {}\n  ],\n  \"b\": 2\n}"},
+		{`json.stringify([1], "\t")`, "[\n\t1\n]"},
+		{`json.stringify([1, 2], 0)`, `[1,2]`},
+		{`json.stringify([1], 9223372036854775807)`, "[\n          1\n]"},
+		{`json.stringify([1], "--------------------")`, "[\n----------1\n]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok || str.Value != tt.expected {
+			t.Errorf("wrong result for %q.\nwant=%s\ngot= %s", tt.input, tt.expected, describe(evaluated))
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	src := `{"items":[{"id":1,"price":9.5,"tags":[]},{"id":2,"price":10.0,"tags":["x"]}],"total":19.5}`
+	evaluated := testEvalJSON(`json.stringify(json.parse(src))`, src)
+	if str, ok := evaluated.(*object.String); !ok || str.Value != src {
+		t.Errorf("round trip changed the document.\nwant=%s\ngot= %s", src, describe(evaluated))
+	}
+}
+
+func TestJSONStringifyErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json.stringify(fn(x) { x })`, "json.stringify: cannot serialize FUNCTION at value"},
+		{`json.stringify({"a": [1, len]})`, `json.stringify: cannot serialize BUILTIN at value["a"][1]`},
+		{`json.stringify([channel(1)])`, "json.stringify: cannot serialize CHANNEL at value[0]"},
+		{`json.stringify({1: "a"})`, "json.stringify: object keys must be STRING, got INTEGER key 1 in value"},
+		{infinity + `json.stringify([inf - inf])`, "json.stringify: cannot serialize NaN at value[0]"},
+		{`json.stringify(1, -1)`, "indent passed to `json.stringify` must not be negative, got -1"},
+		{`json.stringify(1, true)`, "second argument to `json.stringify` must be INTEGER or STRING, got BOOLEAN"},
+		{`json.stringify()`, "wrong number of arguments to `json.stringify`: want=1 to 2, got=0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("wrong result for %q.\nwant=%s\ngot= %s", tt.input, tt.expected, describe(evaluated))
+		}
+	}
+}
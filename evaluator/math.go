@@ -0,0 +1,200 @@
+package evaluator
+
+import (
+	"math"
+	"monkey/object"
+	"strings"
+)
+
+// 整数か小数かは演算子と同じ規則にする(evalIntegerInfixExpression, evalFloatInfixExpression)
+// 整数だけなら整数、一つでも小数があれば小数。sqrtや三角関数はいつも小数
+// 定義域の外の引数(sqrt(-1)やasin(2)やlog(0))はNaNや±Infを返さずにerrorにする
+// NaNや±Infを渡したときだけ、結果もNaNや±Infになりうる
+// 整数の結果がINTEGERの範囲に収まらないときもerrorにする(折り返さない)
+var mathModule = &object.Module{Name: "math", Members: map[string]object.Object{
+	"pi": &object.Float{Value: math.Pi},
+	"e":  &object.Float{Value: math.E},
+
+	"sqrt": floatBuiltin("sqrt", math.Sqrt),
+	"pow": numericBuiltin("pow", 2, func(args []object.Object) object.Object {
+		base, baseOk := args[0].(*object.Integer)
+		exp, expOk := args[1].(*object.Integer)
+		if baseOk && expOk && exp.Value >= 0 {
+			result, ok := intPow(base.Value, exp.Value)
+			if !ok {
+				return newError(object.ARGUMENT_ERROR, "result of `math.pow` out of the INTEGER range, got %d and %d", base.Value, exp.Value)
+			}
+			return object.NewInteger(result)
+		}
+		return checkDomain("pow", args, math.Pow(toFloat(args[0]), toFloat(args[1])))
+	}),
+	"abs": numericBuiltin("abs", 1, func(args []object.Object) object.Object {
+		if i, ok := args[0].(*object.Integer); ok {
+			if i.Value == math.MinInt64 {
+				return newError(object.ARGUMENT_ERROR, "result of `math.abs` out of the INTEGER range, got %d", i.Value)
+			}
+			if i.Value < 0 {
+				return object.NewInteger(-i.Value)
+			}
+			return i
+		}
+		return &object.Float{Value: math.Abs(toFloat(args[0]))}
+	}),
+	"floor": roundingBuiltin("floor", math.Floor),
+	"ceil":  roundingBuiltin("ceil", math.Ceil),
+	"round": roundingBuiltin("round", math.Round),
+	"min": numericBuiltin("min", -1, func(args []object.Object) object.Object {
+		return pick(args, func(a, b float64) bool { return a < b })
+	}),
+	"max": numericBuiltin("max", -1, func(args []object.Object) object.Object {
+		return pick(args, func(a, b float64) bool { return a > b })
+	}),
+	"gcd": numericBuiltin("gcd", 2, func(args []object.Object) object.Object {
+		a, aOk := args[0].(*object.Integer)
+		b, bOk := args[1].(*object.Integer)
+		if !aOk || !bOk {
+			return newError(object.ARGUMENT_ERROR, "arguments to `math.gcd` must be INTEGER, got %s and %s", typeName(args[0]), typeName(args[1]))
+		}
+		// -MinInt64はあふれてMinInt64のまま
+		g := gcd(a.Value, b.Value)
+		if g < 0 {
+			return newError(object.ARGUMENT_ERROR, "result of `math.gcd` out of the INTEGER range, got %d and %d", a.Value, b.Value)
+		}
+		return object.NewInteger(g)
+	}),
+
+	"sin":  floatBuiltin("sin", math.Sin),
+	"cos":  floatBuiltin("cos", math.Cos),
+	"tan":  floatBuiltin("tan", math.Tan),
+	"asin": floatBuiltin("asin", math.Asin),
+	"acos": floatBuiltin("acos", math.Acos),
+	// atan(y, x)はGoのmath.Atan2(識別子に数字は使えない)
+	"atan": numericBuiltin("atan", -1, func(args []object.Object) object.Object {
+		switch len(args) {
+		case 1:
+			return &object.Float{Value: math.Atan(toFloat(args[0]))}
+		case 2:
+			return &object.Float{Value: math.Atan2(toFloat(args[0]), toFloat(args[1]))}
+		}
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `math.atan`: want=1 or 2, got=%d", len(args))
+	}),
+	"exp": floatBuiltin("exp", math.Exp),
+	"log": floatBuiltin("log", math.Log),
+}}
+
+// 引数の数と、すべてが数であることを確かめてからfnを呼ぶ。arityが-1なら1つ以上いくつでも
+func numericBuiltin(name string, arity int, fn func(args []object.Object) object.Object) *object.Builtin {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		switch {
+		case arity < 0 && len(args) == 0:
+			return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `math.%s`: want at least 1, got=0", name)
+		case arity >= 0 && len(args) != arity:
+			return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `math.%s`: want=%d, got=%d", name, arity, len(args))
+		}
+		for _, arg := range args {
+			if !isNumber(arg) {
+				return newError(object.ARGUMENT_ERROR, "argument to `math.%s` must be INTEGER or FLOAT, got %s", name, typeName(arg))
+			}
+		}
+		return fn(args)
+	}}
+}
+
+// 小数を受け取って小数を返す関数
+func floatBuiltin(name string, fn func(float64) float64) *object.Builtin {
+	return numericBuiltin(name, 1, func(args []object.Object) object.Object {
+		return checkDomain(name, args, fn(toFloat(args[0])))
+	})
+}
+
+// NaNを渡していないのにNaNになったら、引数が定義域の外
+// 有限の引数から±Infになったら、log(0)のような極なのでこれもerrorにする
+func checkDomain(name string, args []object.Object, result float64) object.Object {
+	nan, finite := false, true
+	for _, arg := range args {
+		x := toFloat(arg)
+		nan = nan || math.IsNaN(x)
+		finite = finite && !math.IsNaN(x) && !math.IsInf(x, 0)
+	}
+	if math.IsNaN(result) && !nan || math.IsInf(result, 0) && finite {
+		inspected := make([]string, len(args))
+		for i, arg := range args {
+			inspected[i] = arg.Inspect()
+		}
+		return newError(object.ARGUMENT_ERROR, "argument to `math.%s` out of domain, got %s", name, strings.Join(inspected, ", "))
+	}
+	return &object.Float{Value: result}
+}
+
+// 小数を整数にする関数。整数はそのまま
+func roundingBuiltin(name string, fn func(float64) float64) *object.Builtin {
+	return numericBuiltin(name, 1, func(args []object.Object) object.Object {
+		if i, ok := args[0].(*object.Integer); ok {
+			return i
+		}
+		x := fn(toFloat(args[0]))
+		if math.IsNaN(x) || x < math.MinInt64 || x >= math.MaxInt64 {
+			return newError(object.ARGUMENT_ERROR, "argument to `math.%s` out of the INTEGER range, got %s", name, args[0].Inspect())
+		}
+		return object.NewInteger(int64(x))
+	})
+}
+
+// min, max 小数が混ざっていれば結果も小数
+func pick(args []object.Object, better func(a, b float64) bool) object.Object {
+	best := args[0]
+	float := false
+	for _, arg := range args {
+		if _, ok := arg.(*object.Float); ok {
+			float = true
+		}
+		if better(toFloat(arg), toFloat(best)) {
+			best = arg
+		}
+	}
+	if float {
+		return &object.Float{Value: toFloat(best)}
+	}
+	return best
+}
+
+// INTEGERの範囲に収まらなければok=false
+func intPow(base, exp int64) (result int64, ok bool) {
+	result = 1
+	for exp > 0 {
+		if exp&1 == 1 {
+			if result, ok = mulInt64(result, base); !ok {
+				return 0, false
+			}
+		}
+		exp >>= 1
+		if exp > 0 {
+			if base, ok = mulInt64(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// a*bがあふれなければok=true
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if c/b != a || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return c, true
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -0,0 +1,125 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"testing"
+)
+
+// 0.0で割るとerrorなので、あふれさせて+Infを作る
+const infinity = `let square = fn(x, n) { if (n == 0) { x } else { square(x * x, n - 1) } }; let inf = square(10.0, 10); `
+
+func TestFloatArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1.5 + 2`, "FLOAT 3.5"},
+		{`2 * 1.5`, "FLOAT 3.0"},
+		{`7 / 2`, "INTEGER 3"},
+		{`7 / 2.0`, "FLOAT 3.5"},
+		{`-0.5 - 1`, "FLOAT -1.5"},
+		{`1.0 == 1`, "BOOLEAN true"},
+		{`0.1 + 0.2 > 0.3`, "BOOLEAN true"},
+		{`1 / 0.0`, "DivisionByZero 1:3 ERROR: division by zero: 1 / 0.0"},
+		{`0.0 / 0`, "DivisionByZero 1:5 ERROR: division by zero: 0.0 / 0"},
+		{`try { 1.5 / 0 } catch (e) { e.kind }`, "STRING DivisionByZero"},
+		{infinity + `[inf, -inf, inf - inf]`, "[FLOAT +Inf, FLOAT -Inf, FLOAT NaN]"},
+		{`[1.25, 100.0]`, "[FLOAT 1.25, FLOAT 100.0]"},
+		{`1.5 + "a"`, "TypeMismatch 1:5 ERROR: type mismatch: FLOAT + STRING"},
+	}
+
+	for _, tt := range tests {
+		if got := describe(testEval(tt.input)); got != tt.expected {
+			t.Errorf("wrong result for %q. want=%s, got=%s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestMathModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`math.sqrt(16)`, "FLOAT 4.0"},
+		{`math.pow(2, 10)`, "INTEGER 1024"},
+		{`math.pow(2, -1)`, "FLOAT 0.5"},
+		{`math.pow(2.0, 3)`, "FLOAT 8.0"},
+		{`math.abs(-3)`, "INTEGER 3"},
+		{`math.abs(-2.5)`, "FLOAT 2.5"},
+		{`math.floor(2.7)`, "INTEGER 2"},
+		{`math.floor(-2.5)`, "INTEGER -3"},
+		{`math.ceil(2.1)`, "INTEGER 3"},
+		{`math.round(2.5)`, "INTEGER 3"},
+		{`math.floor(4)`, "INTEGER 4"},
+		{`math.min(3, 1, 2)`, "INTEGER 1"},
+		{`math.max(1, 2.5)`, "FLOAT 2.5"},
+		{`math.min(1, 2.0)`, "FLOAT 1.0"},
+		{`math.gcd(12, -18)`, "INTEGER 6"},
+		{`math.gcd(0, 0)`, "INTEGER 0"},
+		{`math.gcd(-9223372036854775807 - 1, 6)`, "INTEGER 2"},
+		{`math.abs(-9223372036854775807)`, "INTEGER 9223372036854775807"},
+		{`math.pow(-2, 63)`, "INTEGER -9223372036854775808"},
+		{`math.pow(3, 39)`, "INTEGER 4052555153018976267"},
+		{`math.pow(0, 1000)`, "INTEGER 0"},
+		{`math.pow(-1, 1001)`, "INTEGER -1"},
+		// NaNや±Infを渡したときは、そのまま計算する
+		{infinity + `math.sqrt(inf - inf)`, "FLOAT NaN"},
+		{infinity + `math.exp(inf)`, "FLOAT +Inf"},
+		{infinity + `math.atan(inf) == math.pi / 2`, "BOOLEAN true"},
+		{`math.pi > 3.14 == (math.pi < 3.15)`, "BOOLEAN true"},
+		{`math.sin(0)`, "FLOAT 0.0"},
+		{`math.cos(0)`, "FLOAT 1.0"},
+		{`math.atan(0)`, "FLOAT 0.0"},
+		{`math.atan(1, 0) == math.pi / 2`, "BOOLEAN true"},
+		{`math.log(math.exp(2)) == 2.0`, "BOOLEAN true"},
+		{`let sqrt = math.sqrt; sqrt(9)`, "FLOAT 3.0"},
+		{`let math = 1; math`, "INTEGER 1"},
+	}
+
+	for _, tt := range tests {
+		if got := describe(testEval(tt.input)); got != tt.expected {
+			t.Errorf("wrong result for %q. want=%s, got=%s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestMathErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`math.sqrt("x")`, "argument to `math.sqrt` must be INTEGER or FLOAT, got STRING"},
+		{`math.sqrt(-1)`, "argument to `math.sqrt` out of domain, got -1"},
+		{`math.log(-1)`, "argument to `math.log` out of domain, got -1"},
+		{`math.asin(2)`, "argument to `math.asin` out of domain, got 2"},
+		{`math.acos(-1.5)`, "argument to `math.acos` out of domain, got -1.5"},
+		{infinity + `math.sin(inf)`, "argument to `math.sin` out of domain, got +Inf"},
+		{`math.log(0)`, "argument to `math.log` out of domain, got 0"},
+		{`math.pow(0, -1)`, "argument to `math.pow` out of domain, got 0, -1"},
+		{`math.exp(1000)`, "argument to `math.exp` out of domain, got 1000"},
+		{`math.pow(2, 64)`, "result of `math.pow` out of the INTEGER range, got 2 and 64"},
+		{`math.pow(10, 1000)`, "result of `math.pow` out of the INTEGER range, got 10 and 1000"},
+		{`math.pow(3, 40)`, "result of `math.pow` out of the INTEGER range, got 3 and 40"},
+		{`math.pow(-8, 0.5)`, "argument to `math.pow` out of domain, got -8, 0.5"},
+		{`math.abs(-9223372036854775807 - 1)`, "result of `math.abs` out of the INTEGER range, got -9223372036854775808"},
+		{`math.gcd(-9223372036854775807 - 1, 0)`, "result of `math.gcd` out of the INTEGER range, got -9223372036854775808 and 0"},
+		{`math.pow(2)`, "wrong number of arguments to `math.pow`: want=2, got=1"},
+		{`math.max()`, "wrong number of arguments to `math.max`: want at least 1, got=0"},
+		{`math.gcd(1.5, 2)`, "arguments to `math.gcd` must be INTEGER, got FLOAT and INTEGER"},
+		{infinity + `math.floor(inf)`, "argument to `math.floor` out of the INTEGER range, got +Inf"},
+		{`math.atan(1, 2, 3)`, "wrong number of arguments to `math.atan`: want=1 or 2, got=3"},
+		{`math.tau`, "unknown field tau on MODULE"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%s", tt.input, describe(evaluated))
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q. got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
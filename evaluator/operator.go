@@ -0,0 +1,99 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+// 演算子に対応するmethod名(implで定義する)
+// != は eq の結果を反転したもの
+var infixOperatorMethods = map[string]string{
+	"+":  "add",
+	"-":  "sub",
+	"*":  "mul",
+	"/":  "div",
+	"==": "eq",
+	"!=": "eq",
+	"<":  "lt",
+	">":  "gt",
+}
+
+// 左辺が演算子を定義していないときに右辺で探すmethod(3 * vec は vec.rmul(3))
+// 比較は向きを入れ替える(1 < m は m.gt(1))
+var reflectedOperatorMethods = map[string]string{
+	"+":  "radd",
+	"-":  "rsub",
+	"*":  "rmul",
+	"/":  "rdiv",
+	"==": "eq",
+	"!=": "eq",
+	"<":  "gt",
+	">":  "lt",
+}
+
+var prefixOperatorMethods = map[string]string{
+	"-": "neg",
+	"!": "not",
+}
+
+// 組み込みの規則より先に、左辺の型が定義した演算子を探し、なければ右辺の型のreflectedOperatorMethodsを探す
+// (Goの値のInfixOperatorは左辺のときだけ使う)
+// 見つからなければfalseを返す(組み込みの規則で評価する)
+func evalOverloadedInfixExpression(node *ast.InfixExpression, left, right object.Object) (object.Object, bool) {
+	if handler, ok := left.(object.InfixOperator); ok {
+		if result, ok := handler.InfixOperator(node.Operator, right); ok {
+			return setErrorPosition(result, node.Token), true
+		}
+	}
+
+	receiver, arg := left, right
+	method, ok := lookupOperatorMethod(left, infixOperatorMethods[node.Operator])
+	if !ok {
+		receiver, arg = right, left
+		method, ok = lookupOperatorMethod(right, reflectedOperatorMethods[node.Operator])
+	}
+	if !ok {
+		return nil, false
+	}
+
+	result := callFunction(&object.BoundMethod{Receiver: receiver, Method: method}, []object.Object{arg}, node.Token)
+	if node.Operator == "!=" && !isAbrupt(result) {
+		return nativeBoolToBooleanObject(!isTruthy(result)), true
+	}
+	return result, true
+}
+
+func evalOverloadedPrefixExpression(node *ast.PrefixExpression, right object.Object) (object.Object, bool) {
+	if handler, ok := right.(object.PrefixOperator); ok {
+		if result, ok := handler.PrefixOperator(node.Operator); ok {
+			return setErrorPosition(result, node.Token), true
+		}
+	}
+
+	method, ok := lookupOperatorMethod(right, prefixOperatorMethods[node.Operator])
+	if !ok {
+		return nil, false
+	}
+
+	return callFunction(&object.BoundMethod{Receiver: right, Method: method}, []object.Object{}, node.Token), true
+}
+
+// structやenumの値がimplで定義したmethod
+func lookupOperatorMethod(obj object.Object, name string) (*object.Function, bool) {
+	if name == "" {
+		return nil, false
+	}
+
+	var methods map[string]*object.Function
+	switch obj := obj.(type) {
+	case *object.Instance:
+		methods = obj.Struct.Methods
+	case *object.EnumValue:
+		methods = obj.Variant.Enum.Methods
+	default:
+		return nil, false
+	}
+
+	method, ok := methods[name]
+	return method, ok
+}
@@ -0,0 +1,300 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+// 上から順にpatternを試し、最初にマッチしたarmのbodyを評価する
+// どのarmにもマッチしなければNULL(evalIfExpressionと同じ)
+func evalMatchExpression(me *ast.MatchExpression, env *object.Environment, eval evalFunc) object.Object {
+	subject := eval(me.Subject, env)
+	if isAbrupt(subject) {
+		return subject
+	}
+
+	for _, arm := range me.Arms {
+		bindings := map[string]object.Object{}
+		if err := matchPattern(arm.Pattern, subject, env, bindings); err != nil {
+			if err.Kind == object.PATTERN_ERROR {
+				continue
+			}
+			return err
+		}
+
+		armEnv := object.NewEnclosedEnvironment(env)
+		bindPattern(arm.Pattern, armEnv, bindings)
+
+		if arm.Guard != nil {
+			guard := eval(arm.Guard, armEnv)
+			if isAbrupt(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+
+		return eval(arm.Body, armEnv)
+	}
+
+	return NULL
+}
+
+// let [a, b] = xs; や let {name} = person;
+// 全体がマッチしてから束縛するので、途中で失敗しても一部の変数だけ定義されることはない
+func evalDestructuringLet(ls *ast.LetStatement, val object.Object, env *object.Environment) object.Object {
+	bindings := map[string]object.Object{}
+	if err := matchPattern(ls.Pattern, val, env, bindings); err != nil {
+		setErrorPosition(err, ls.Token)
+		return err
+	}
+
+	bindPattern(ls.Pattern, env, bindings)
+
+	return nil
+}
+
+// matchPatternで集めた変数を環境に束縛する
+// resolverがslotを割り当てていればslotにも入れる
+func bindPattern(pattern ast.Pattern, env *object.Environment, bindings map[string]object.Object) {
+	for name, v := range bindings {
+		env.Set(name, v)
+	}
+
+	for _, ident := range ast.PatternIdentifiers(pattern) {
+		if v, ok := bindings[ident.Value]; ok && ident.Resolved {
+			env.SetAt(ident.Slot, ident.Value, v)
+		}
+	}
+}
+
+// valがpatternにマッチすればnilを返し、束縛する変数をbindingsに入れる
+// 形が合わなければPATTERN_ERRORのerrorを返す(matchでは次のarmを試す)
+// それ以外のerrorはdefaultの評価などで起きたもの
+func matchPattern(pattern ast.Pattern, val object.Object, env *object.Environment, bindings map[string]object.Object) *object.Error {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return nil
+	case *ast.BindingPattern:
+		// Emptyのような値だけのvariantは束縛ではなく比較
+		if unit, ok := lookupUnitVariant(pattern.Name.Value, env); ok {
+			if !objectsEqual(unit, val) {
+				return newError(object.PATTERN_ERROR, "pattern mismatch: want %s, got %s", pattern.String(), val.Inspect())
+			}
+			return nil
+		}
+		bindings[pattern.Name.Value] = val
+		return nil
+	case *ast.LiteralPattern:
+		if !matchLiteralPattern(pattern, val) {
+			return newError(object.PATTERN_ERROR, "pattern mismatch: want %s, got %s", pattern.String(), val.Inspect())
+		}
+		return nil
+	case *ast.AlternativePattern:
+		for _, alternative := range pattern.Alternatives {
+			// マッチしなかった選択肢の束縛は捨てる
+			candidate := map[string]object.Object{}
+			err := matchPattern(alternative, val, env, candidate)
+			if err != nil && err.Kind == object.PATTERN_ERROR {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			for name, v := range candidate {
+				bindings[name] = v
+			}
+			return nil
+		}
+		return newError(object.PATTERN_ERROR, "pattern mismatch: want %s, got %s", pattern.String(), val.Inspect())
+	case *ast.ConstructorPattern:
+		return matchConstructorPattern(pattern, val, env, bindings)
+	case *ast.ArrayPattern:
+		return matchArrayPattern(pattern, val, env, bindings)
+	case *ast.HashPattern:
+		return matchHashPattern(pattern, val, env, bindings)
+	case *ast.DefaultPattern:
+		return matchPattern(pattern.Pattern, val, env, bindings)
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "unknown pattern: %s", pattern.String())
+	}
+}
+
+func matchLiteralPattern(pattern *ast.LiteralPattern, val object.Object) bool {
+	switch lit := pattern.Value.(type) {
+	case *ast.IntegerLiteral:
+		integer, ok := val.(*object.Integer)
+		return ok && integer.Value == lit.Value
+	case *ast.StringLiteral:
+		str, ok := val.(*object.String)
+		return ok && str.Value == lit.Value
+	case *ast.Boolean:
+		return val == nativeBoolToBooleanObject(lit.Value)
+	}
+	return false
+}
+
+// ok(pattern), err(pattern), Circle(pattern)
+func matchConstructorPattern(pattern *ast.ConstructorPattern, val object.Object, env *object.Environment, bindings map[string]object.Object) *object.Error {
+	name := pattern.Name.Value
+	if name != "ok" && name != "err" {
+		return matchVariantPattern(pattern, val, env, bindings)
+	}
+	if len(pattern.Arguments) != 1 {
+		err := newError(object.ARGUMENT_ERROR, "wrong number of arguments in pattern %s: want=1, got=%d", name, len(pattern.Arguments))
+		setErrorPosition(err, pattern.Token)
+		return err
+	}
+
+	result, ok := val.(*object.Result)
+	if !ok || result.Ok != (name == "ok") {
+		return newError(object.PATTERN_ERROR, "pattern mismatch: want %s, got %s", pattern.String(), val.Inspect())
+	}
+	return matchPattern(pattern.Arguments[0], result.Value, env, bindings)
+}
+
+// Circle(r), Rect(w, _), Empty()
+func matchVariantPattern(pattern *ast.ConstructorPattern, val object.Object, env *object.Environment, bindings map[string]object.Object) *object.Error {
+	name := pattern.Name.Value
+
+	var variant *object.Variant
+	switch obj, _ := env.Get(name); obj := obj.(type) {
+	case *object.Variant:
+		variant = obj
+	case *object.EnumValue:
+		if obj.Variant.Unit == obj {
+			variant = obj.Variant
+		}
+	}
+	if variant == nil {
+		err := newError(object.UNKNOWN_IDENTIFIER_ERROR, "unknown constructor in pattern: %s", name)
+		setErrorPosition(err, pattern.Token)
+		return err
+	}
+	if len(pattern.Arguments) != len(variant.Fields) {
+		err := newError(object.ARGUMENT_ERROR, "wrong number of arguments in pattern %s: want=%d, got=%d", name, len(variant.Fields), len(pattern.Arguments))
+		setErrorPosition(err, pattern.Token)
+		return err
+	}
+
+	value, ok := val.(*object.EnumValue)
+	if !ok || value.Variant != variant {
+		return newError(object.PATTERN_ERROR, "pattern mismatch: want %s, got %s", pattern.String(), val.Inspect())
+	}
+
+	for i, arg := range pattern.Arguments {
+		if err := matchPattern(arg, value.Values[i], env, bindings); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 大文字で始まる名前がfieldのないvariantを指していればその値を返す
+func lookupUnitVariant(name string, env *object.Environment) (*object.EnumValue, bool) {
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		return nil, false
+	}
+
+	obj, ok := env.Get(name)
+	if !ok {
+		return nil, false
+	}
+	value, ok := obj.(*object.EnumValue)
+	if !ok || value.Variant.Unit != value {
+		return nil, false
+	}
+	return value, true
+}
+
+// [a, b = 0, ...rest]
+func matchArrayPattern(pattern *ast.ArrayPattern, val object.Object, env *object.Environment, bindings map[string]object.Object) *object.Error {
+	array, ok := val.(*object.Array)
+	if !ok {
+		return newError(object.PATTERN_ERROR, "cannot destructure %s as ARRAY", typeName(val))
+	}
+
+	elements := array.Elements
+	if pattern.Rest == nil && len(elements) > len(pattern.Elements) {
+		return newError(object.PATTERN_ERROR, "too many elements: want %d, got %d", len(pattern.Elements), len(elements))
+	}
+
+	for i, element := range pattern.Elements {
+		if i < len(elements) {
+			if err := matchPattern(element, elements[i], env, bindings); err != nil {
+				return err
+			}
+			continue
+		}
+
+		dp, ok := element.(*ast.DefaultPattern)
+		if !ok {
+			return newError(object.PATTERN_ERROR, "not enough elements: want at least %d, got %d", i+1, len(elements))
+		}
+		if err := matchDefault(dp, env, bindings); err != nil {
+			return err
+		}
+	}
+
+	if pattern.Rest != nil {
+		rest := []object.Object{}
+		if len(elements) > len(pattern.Elements) {
+			rest = append(rest, elements[len(pattern.Elements):]...)
+		}
+		bindings[pattern.Rest.Value] = &object.Array{Elements: rest}
+	}
+
+	return nil
+}
+
+// {name, age: years = 0}
+// patternにないkeyは無視する
+func matchHashPattern(pattern *ast.HashPattern, val object.Object, env *object.Environment, bindings map[string]object.Object) *object.Error {
+	hash, ok := val.(*object.Hash)
+	if !ok {
+		return newError(object.PATTERN_ERROR, "cannot destructure %s as HASH", typeName(val))
+	}
+
+	for i, key := range pattern.Keys {
+		value := pattern.Values[i]
+
+		pair, ok := hash.Pairs[(&object.String{Value: key}).HashKey()]
+		if ok {
+			if err := matchPattern(value, pair.Value, env, bindings); err != nil {
+				return err
+			}
+			continue
+		}
+
+		dp, ok := value.(*ast.DefaultPattern)
+		if !ok {
+			return newError(object.PATTERN_ERROR, "missing key %q in HASH", key)
+		}
+		if err := matchDefault(dp, env, bindings); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 要素やkeyがない場合はdefaultの値をpatternに当てはめる
+// defaultの中ではそれまでに束縛した変数を参照できる (let [a, b = a] = xs;)
+func matchDefault(dp *ast.DefaultPattern, env *object.Environment, bindings map[string]object.Object) *object.Error {
+	defaultEnv := object.NewEnclosedEnvironment(env)
+	for name, v := range bindings {
+		defaultEnv.Set(name, v)
+	}
+
+	val := Eval(dp.Default, defaultEnv)
+	if err, ok := val.(*object.Error); ok {
+		return err
+	}
+	if val == nil || val.Type() == object.RETURN_VALUE_OBJ {
+		return newError(object.PATTERN_ERROR, "invalid default value: %s", dp.Default.String())
+	}
+
+	return matchPattern(dp.Pattern, val, env, bindings)
+}
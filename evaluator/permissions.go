@@ -0,0 +1,188 @@
+package evaluator
+
+import (
+	"errors"
+	"io/fs"
+	"monkey/object"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// scriptに許すこと。既定では何も許さない
+// ファイルはAllowRead, AllowWriteで許したdirectoryの中だけを読み書きできる
+// (書けるdirectoryでも、読むにはAllowReadが要る)
+// execはAllowExec、envはAllowEnvで許したときだけ使える
+type Permissions struct {
+	mu    sync.RWMutex
+	read  []string // canonicalにしたdirectory
+	write []string
+	exec  bool
+	env   bool
+}
+
+func NewPermissions() *Permissions {
+	return &Permissions{}
+}
+
+func (p *Permissions) AllowRead(dir string) error {
+	return p.allow(&p.read, dir)
+}
+
+func (p *Permissions) AllowWrite(dir string) error {
+	return p.allow(&p.write, dir)
+}
+
+func (p *Permissions) AllowExec() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.exec = true
+}
+
+func (p *Permissions) AllowEnv() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.env = true
+}
+
+// execやenvを許しているか。許していなければPermissionDeniedのerror
+func (p *Permissions) checkCapability(name string) *object.Error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	switch {
+	case name == "exec" && p.exec, name == "env" && p.env:
+		return nil
+	case name == "exec":
+		return newError(object.PERMISSION_ERROR, "exec: running commands has not been allowed")
+	default:
+		return newError(object.PERMISSION_ERROR, "env: reading environment variables has not been allowed")
+	}
+}
+
+func (p *Permissions) allow(grants *[]string, dir string) error {
+	canonical, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if canonical, err = filepath.EvalSymlinks(canonical); err != nil {
+		return err
+	}
+	info, err := os.Stat(canonical)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return errors.New(dir + " is not a directory")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	*grants = append(*grants, canonical)
+	return nil
+}
+
+// pathを読めるならcanonicalにしたpath、読めなければPermissionDeniedのerror
+// 読み書きは元のpathではなくcanonicalにしたpathで行う(確かめたものと違うファイルを開かないように)
+func (p *Permissions) checkRead(name, path string) (string, *object.Error) {
+	return p.check(name, path, "read")
+}
+
+func (p *Permissions) checkWrite(name, path string) (string, *object.Error) {
+	return p.check(name, path, "write")
+}
+
+func (p *Permissions) check(name, path, access string) (string, *object.Error) {
+	canonical, err := canonicalPath(path)
+	if err != nil {
+		return "", newError(object.IO_ERROR, "%s: %s", name, err)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	grants, purpose := p.read, "reading"
+	if access == "write" {
+		grants, purpose = p.write, "writing"
+	}
+	for _, dir := range grants {
+		if withinDir(dir, canonical) {
+			return canonical, nil
+		}
+	}
+	return "", newError(object.PERMISSION_ERROR, "%s: %s access to %s denied: it is outside the directories allowed for %s", name, access, canonical, purpose)
+}
+
+// 絶対pathにしてsymlinkをたどる。まだないファイルは、あるところまでの親をたどって残りをつなげる
+// (dir/../../etcやdirの中から外へのsymlinkで、許したdirectoryの外に出られないように)
+// 行き先のまだないsymlinkは、行き先に置き換えてからたどり直す(書いたときにできるのはそちらなので)
+func canonicalPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	for links := 0; links <= maxSymlinks; links++ {
+		resolved, dangling, err := resolveExisting(abs)
+		if err != nil || dangling == "" {
+			return resolved, err
+		}
+		abs = dangling
+	}
+	return "", errors.New(path + ": too many levels of symbolic links")
+}
+
+// EvalSymlinksと同じだが、まだないファイルも受け付ける
+// 途中に行き先のないsymlinkがあれば、それをたどったpathをdanglingに返す
+func resolveExisting(abs string) (string, string, error) {
+	rest := ""
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(resolved, rest), "", nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) || dir == filepath.Dir(dir) {
+			return "", "", err
+		}
+		if target, err := os.Readlink(dir); err == nil {
+			if !filepath.IsAbs(target) {
+				parent, err := filepath.EvalSymlinks(filepath.Dir(dir))
+				if err != nil {
+					return "", "", err
+				}
+				target = filepath.Join(parent, target)
+			}
+			return "", filepath.Join(target, rest), nil
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
+// Linuxの上限と同じ
+const maxSymlinks = 40
+
+// pathがdirかその中か
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// runtimeと同じくenvに束縛する。なければ何も許さない
+const permissionsKey = "*permissions*"
+
+func (p *Permissions) Type() object.ObjectType { return "PERMISSIONS" }
+func (p *Permissions) Inspect() string         { return "permissions" }
+
+// envとその内側で評価するコードにpで許したことだけを許す
+func InstallPermissions(env *object.Environment, p *Permissions) {
+	env.Set(permissionsKey, p)
+}
+
+func permissionsOf(env *object.Environment) *Permissions {
+	if env != nil {
+		if obj, ok := env.Get(permissionsKey); ok {
+			return obj.(*Permissions)
+		}
+	}
+	return NewPermissions()
+}
@@ -0,0 +1,109 @@
+package evaluator
+
+import (
+	"bytes"
+	"errors"
+	"monkey/object"
+	"os/exec"
+)
+
+func init() {
+	builtins["exit"] = &object.Builtin{Fn: builtinExit}
+	envBuiltins["exec"] = builtinExec
+	envBuiltins["env"] = builtinEnv
+}
+
+// exit(code) errorと同じく呼び出しを抜けていき、evalProgramがそのまま返す
+// try/catchでは止まらない(finallyは実行される)。終了するかどうかはhost(REPLなど)が決める
+func builtinExit(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `exit`: want=1, got=%d", len(args))
+	}
+	code, ok := args[0].(*object.Integer)
+	if !ok {
+		return newError(object.ARGUMENT_ERROR, "argument to `exit` must be INTEGER, got %s", typeName(args[0]))
+	}
+	err := newError(object.EXIT_ERROR, "exit(%d)", code.Value)
+	err.Value = code
+	return err
+}
+
+// exec(cmd, args) shellを通さずにcmdを実行して終わるのを待つ
+// {"stdout": ..., "stderr": ..., "code": 終了コード}を返す。0以外のコードでもerrorにはしない
+func builtinExec(env *object.Environment) object.BuiltinFunction {
+	perms := permissionsOf(env)
+	return func(args ...object.Object) object.Object {
+		if len(args) != 2 {
+			return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `exec`: want=2, got=%d", len(args))
+		}
+		name, ok := args[0].(*object.String)
+		if !ok {
+			return newError(object.ARGUMENT_ERROR, "first argument to `exec` must be STRING, got %s", typeName(args[0]))
+		}
+		array, ok := args[1].(*object.Array)
+		if !ok {
+			return newError(object.ARGUMENT_ERROR, "second argument to `exec` must be ARRAY, got %s", typeName(args[1]))
+		}
+		cmdArgs := make([]string, len(array.Elements))
+		for i, el := range array.Elements {
+			s, ok := el.(*object.String)
+			if !ok {
+				return newError(object.ARGUMENT_ERROR, "arguments passed to `exec` must be STRING, got %s at %d", typeName(el), i)
+			}
+			cmdArgs[i] = s.Value
+		}
+		if denied := perms.checkCapability("exec"); denied != nil {
+			return denied
+		}
+
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command(name.Value, cmdArgs...)
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+		code := 0
+		if err := cmd.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				return ioError("exec", err)
+			}
+			code = exitErr.ExitCode()
+		}
+		return processResult(stdout.String(), stderr.String(), code)
+	}
+}
+
+func processResult(stdout, stderr string, code int) *object.Hash {
+	pairs := map[object.HashKey]object.HashPair{}
+	for _, pair := range []object.HashPair{
+		{Key: &object.String{Value: "stdout"}, Value: &object.String{Value: stdout}},
+		{Key: &object.String{Value: "stderr"}, Value: &object.String{Value: stderr}},
+		{Key: &object.String{Value: "code"}, Value: object.NewInteger(int64(code))},
+	} {
+		pairs[pair.Key.(object.Hashable).HashKey()] = pair
+	}
+	return &object.Hash{Pairs: pairs}
+}
+
+// env(name) 環境変数の値。なければnull
+// Runtimeから読むので、決まった結果にするRuntimeでは何も見えない
+func builtinEnv(env *object.Environment) object.BuiltinFunction {
+	perms, rt := permissionsOf(env), runtimeOf(env)
+	return func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `env`: want=1, got=%d", len(args))
+		}
+		name, ok := args[0].(*object.String)
+		if !ok {
+			return newError(object.ARGUMENT_ERROR, "argument to `env` must be STRING, got %s", typeName(args[0]))
+		}
+		if denied := perms.checkCapability("env"); denied != nil {
+			return denied
+		}
+
+		value, ok := rt.LookupEnv(name.Value)
+		if !ok {
+			return NULL
+		}
+		return &object.String{Value: value}
+	}
+}
@@ -0,0 +1,149 @@
+package evaluator
+
+import (
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+func allowAll(env *object.Environment) {
+	perms := NewPermissions()
+	perms.AllowExec()
+	perms.AllowEnv()
+	InstallPermissions(env, perms)
+}
+
+func TestExec(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`exec("echo", ["hello", "world"])`, `{STRING code: INTEGER 0, STRING stderr: STRING , STRING stdout: STRING hello world` + "\n}"},
+		// 0以外の終了コードもerrorにはしない
+		{`let r = exec("sh", ["-c", "echo oops >&2; exit 3"]); [r["code"], r["stderr"]]`, "[INTEGER 3, STRING oops\n]"},
+		// shellを通さない
+		{`exec("echo", ["$HOME", "a;b"])["stdout"]`, "STRING $HOME a;b\n"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWith(tt.input, allowAll)
+		if got := describe(evaluated); got != tt.expected {
+			t.Errorf("wrong result for %q.\nwant=%s\ngot= %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestEnv(t *testing.T) {
+	t.Setenv("MONKEY_TEST_VALUE", "banana")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`env("MONKEY_TEST_VALUE")`, "STRING banana"},
+		{`env("MONKEY_TEST_UNSET")`, "NULL null"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWith(tt.input, allowAll)
+		if got := describe(evaluated); got != tt.expected {
+			t.Errorf("wrong result for %q.\nwant=%s\ngot= %s", tt.input, tt.expected, got)
+		}
+	}
+
+	// 決まった結果にするRuntimeからは環境変数が見えない
+	evaluated := testEvalWith(`env("MONKEY_TEST_VALUE")`, func(env *object.Environment) {
+		allowAll(env)
+		InstallRuntime(env, NewDeterministicRuntime(1))
+	})
+	if evaluated != NULL {
+		t.Errorf("env should be empty in a deterministic runtime. got=%s", describe(evaluated))
+	}
+}
+
+func TestProcessNeedsGrants(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`exec("echo", ["hi"])`, "exec: running commands has not been allowed"},
+		{`env("HOME")`, "env: reading environment variables has not been allowed"},
+		// catchできるerror
+		{`try { exec("echo", []) } catch (e) { throw e.kind }`, "PermissionDenied"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("wrong result for %q.\nwant=%s\ngot= %s", tt.input, tt.expected, describe(evaluated))
+		}
+	}
+
+	// execだけ許してもenvは使えない
+	evaluated := testEvalWith(`env("HOME")`, func(env *object.Environment) {
+		perms := NewPermissions()
+		perms.AllowExec()
+		InstallPermissions(env, perms)
+	})
+	if errObj, ok := evaluated.(*object.Error); !ok || errObj.Kind != object.PERMISSION_ERROR {
+		t.Errorf("expected a PermissionDenied error. got=%s", describe(evaluated))
+	}
+}
+
+func TestProcessErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`exec("monkey-no-such-command", [])`, `exec: exec: "monkey-no-such-command": executable file not found in $PATH`},
+		{`exec("echo")`, "wrong number of arguments to `exec`: want=2, got=1"},
+		{`exec(1, [])`, "first argument to `exec` must be STRING, got INTEGER"},
+		{`exec("echo", "hi")`, "second argument to `exec` must be ARRAY, got STRING"},
+		{`exec("echo", ["a", 1])`, "arguments passed to `exec` must be STRING, got INTEGER at 1"},
+		{`env(1)`, "argument to `env` must be STRING, got INTEGER"},
+		{`exit("1")`, "argument to `exit` must be INTEGER, got STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWith(tt.input, allowAll)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("wrong result for %q.\nwant=%s\ngot= %s", tt.input, tt.expected, describe(evaluated))
+		}
+	}
+}
+
+func TestExit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`exit(0); 1`, 0},
+		{`let f = fn() { exit(3); 1 }; let g = fn() { f() + 1 }; g(); 5`, 3},
+		// try/catchでは止まらない。finallyのreturnにも上書きされない
+		{`try { exit(4) } catch (e) { 0 }`, 4},
+		{`let f = fn() { try { exit(5) } finally { return 1 } }; f()`, 5},
+		{`let g = fn*() { exit(6) }; g().next()`, 6},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("exit did not unwind for %q. got=%s", tt.input, describe(evaluated))
+			continue
+		}
+		if code, ok := errObj.ExitCode(); !ok || code != tt.expected {
+			t.Errorf("wrong exit code for %q. want=%d, got=%d (%s)", tt.input, tt.expected, code, describe(evaluated))
+		}
+	}
+
+	// finallyは実行される
+	var env *object.Environment
+	testEvalWith(`let c = channel(1); try { exit(1) } finally { send(c, "cleaned up") }`, func(e *object.Environment) { env = e })
+	if got := Eval(parser.New(lexer.New(`recv(c)`)).ParseProgram(), env); got.Inspect() != "cleaned up" {
+		t.Errorf("finally did not run before exit. got=%s", describe(got))
+	}
+}
@@ -0,0 +1,132 @@
+package evaluator
+
+import (
+	"math/rand"
+	"monkey/object"
+	"os"
+	"sync"
+	"time"
+)
+
+// builtinが触る外の世界(時刻、乱数、環境変数)
+// 同じ入力から同じ結果が欲しいときはNewDeterministicRuntimeを使う
+type Runtime interface {
+	Clock
+	Int63n(n int64) int64
+	LookupEnv(name string) (string, bool)
+}
+
+type systemRuntime struct {
+	systemClock
+}
+
+func (systemRuntime) Int63n(n int64) int64                 { return rand.Int63n(n) }
+func (systemRuntime) LookupEnv(name string) (string, bool) { return os.LookupEnv(name) }
+
+// 実際の時刻、seedのない乱数、プロセスの環境変数
+var SystemRuntime Runtime = systemRuntime{}
+
+// 時刻はUnix epochから始まるFakeClock、乱数はseedで決まり、環境変数は一つもない
+type deterministicRuntime struct {
+	*FakeClock
+	mu   sync.Mutex // spawnした関数からも呼ばれる
+	rand *rand.Rand
+}
+
+func NewDeterministicRuntime(seed int64) Runtime {
+	return &deterministicRuntime{
+		FakeClock: NewFakeClock(time.Unix(0, 0).UTC()),
+		rand:      rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (r *deterministicRuntime) Int63n(n int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Int63n(n)
+}
+
+func (r *deterministicRuntime) LookupEnv(name string) (string, bool) { return "", false }
+
+// loopと同じくenvに束縛する。なければSystemRuntime
+const runtimeKey = "*runtime*"
+
+// rtと、rtを使う組み込み関数(InstallRuntimeで一度だけ作る)
+type runtimeObject struct {
+	Runtime
+	builtins map[string]*object.Builtin
+}
+
+func (r *runtimeObject) Type() object.ObjectType { return "RUNTIME" }
+func (r *runtimeObject) Inspect() string         { return "runtime" }
+
+func newRuntimeObject(rt Runtime) *runtimeObject {
+	r := &runtimeObject{Runtime: rt, builtins: make(map[string]*object.Builtin, len(runtimeBuiltins))}
+	for name, makeBuiltin := range runtimeBuiltins {
+		r.builtins[name] = &object.Builtin{Fn: makeBuiltin(rt)}
+	}
+	return r
+}
+
+// InstallRuntimeしていない環境で使う
+var systemRuntimeObject = newRuntimeObject(SystemRuntime)
+
+// envとその内側で評価するコードのbuiltinがrtを使うようにする
+func InstallRuntime(env *object.Environment, rt Runtime) {
+	env.Set(runtimeKey, newRuntimeObject(rt))
+}
+
+func runtimeObjectOf(env *object.Environment) *runtimeObject {
+	if env != nil {
+		if obj, ok := env.Get(runtimeKey); ok {
+			return obj.(*runtimeObject)
+		}
+	}
+	return systemRuntimeObject
+}
+
+func runtimeOf(env *object.Environment) Runtime {
+	return runtimeObjectOf(env).Runtime
+}
+
+// Runtimeを使う組み込み関数。evalIdentifierで参照されたときに、その環境のRuntimeで作ったものを返す
+var runtimeBuiltins = map[string]func(rt Runtime) object.BuiltinFunction{
+	// now() Unix epochからのミリ秒
+	"now": func(rt Runtime) object.BuiltinFunction {
+		return func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `now`: want=0, got=%d", len(args))
+			}
+			return object.NewInteger(rt.Now().UnixMilli())
+		}
+	},
+	// random(n) 0以上n未満の整数
+	"random": func(rt Runtime) object.BuiltinFunction {
+		return func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `random`: want=1, got=%d", len(args))
+			}
+			n, ok := args[0].(*object.Integer)
+			if !ok || n.Value <= 0 {
+				return newError(object.ARGUMENT_ERROR, "argument to `random` must be a positive INTEGER, got %s", args[0].Inspect())
+			}
+			return object.NewInteger(rt.Int63n(n.Value))
+		}
+	},
+}
+
+// 環境に入れたもの(Permissions)を使う組み込み関数。evalIdentifierで参照されたときに、その環境で作る
+var envBuiltins = map[string]func(env *object.Environment) object.BuiltinFunction{}
+
+// 0からn-1までをrtの乱数で並べ替えたもの
+func shuffledIndices(n int, rt Runtime) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		j := int(rt.Int63n(int64(i + 1)))
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
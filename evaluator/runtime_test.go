@@ -0,0 +1,124 @@
+package evaluator
+
+import (
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+func testEvalDeterministic(input string, seed int64) object.Object {
+	return testEvalWith(input, func(env *object.Environment) {
+		rt := NewDeterministicRuntime(seed)
+		InstallRuntime(env, rt)
+		NewEventLoop(rt).Install(env)
+	})
+}
+
+func TestDeterministicRuntime(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`now()`, 0},
+		{`await setTimeout(fn() { 0 }, 1500); now()`, 1500},
+		{`let roll = fn(n, acc) { if (n == 0) { acc } else { roll(n - 1, acc * 10 + random(10)) } }; roll(6, 0)`, -1},
+		{`let c = channel(10); send(c, 1); send(c, 1); let d = channel(10); send(d, 2); send(d, 2);
+let pick = fn(n, acc) { if (n == 0) { acc } else { pick(n - 1, acc * 10 + select { x = recv(c) => x, y = recv(d) => y }) } };
+pick(4, 0)`, -1},
+	}
+
+	for _, tt := range tests {
+		// 同じseedなら何度評価しても同じ結果になる
+		first := testEvalDeterministic(tt.input, 42)
+		second := testEvalDeterministic(tt.input, 42)
+		if describe(first) != describe(second) {
+			t.Errorf("%q is not deterministic: %s, then %s", tt.input, describe(first), describe(second))
+		}
+		if tt.expected >= 0 {
+			testIntegerObject(t, first, tt.expected)
+		} else if _, ok := first.(*object.Integer); !ok {
+			t.Errorf("%q: expected an integer, got=%s", tt.input, describe(first))
+		}
+	}
+}
+
+func TestRandomDependsOnSeed(t *testing.T) {
+	input := `[random(1000000), random(1000000), random(1000000)]`
+	if describe(testEvalDeterministic(input, 1)) == describe(testEvalDeterministic(input, 2)) {
+		t.Errorf("different seeds gave the same numbers")
+	}
+}
+
+func TestRuntimeBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`random(0)`, "argument to `random` must be a positive INTEGER, got 0"},
+		{`random("a")`, "argument to `random` must be a positive INTEGER, got a"},
+		{`random()`, "wrong number of arguments to `random`: want=1, got=0"},
+		{`now(1)`, "wrong number of arguments to `now`: want=0, got=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong result for %q. want=%q, got=%s", tt.input, tt.expectedMessage, describe(evaluated))
+		}
+	}
+}
+
+func TestSystemRuntimeIsTheDefault(t *testing.T) {
+	evaluated := testEval(`[now() > 0, random(1)]`)
+	if evaluated.Inspect() != "[true, 0]" {
+		t.Errorf("wrong result. got=%s", evaluated.Inspect())
+	}
+}
+
+// now、randomはInstallRuntimeで一度だけ作り、参照するたびに同じものを返す
+func TestRuntimeBuiltinsAreBuiltOnce(t *testing.T) {
+	env := object.NewEnvironment()
+	InstallRuntime(env, NewDeterministicRuntime(1))
+	evaluated := Eval(parser.New(lexer.New(`[now, random, fn() { [now, random] }()]`)).ParseProgram(), env)
+	arr, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("expected an array, got=%s", describe(evaluated))
+	}
+	inner := arr.Elements[2].(*object.Array)
+	for i, name := range []string{"now", "random"} {
+		if arr.Elements[i] != inner.Elements[i] {
+			t.Errorf("%s was built again on lookup", name)
+		}
+	}
+	if arr.Elements[0] == testEval(`now`) {
+		t.Errorf("now in an environment with its own runtime is the default one")
+	}
+}
+
+func TestHashInspectIsSorted(t *testing.T) {
+	evaluated := testEval(`{"b": 1, 10: 2, true: 3, "a": 4, 9: 5, false: 6, -1: 7}`)
+	expected := "{false: 6, true: 3, -1: 7, 9: 5, 10: 2, a: 4, b: 1}"
+	if evaluated.Inspect() != expected {
+		t.Errorf("wrong order.\nwant=%s\ngot= %s", expected, evaluated.Inspect())
+	}
+}
+
+func TestEnvironmentNames(t *testing.T) {
+	env := object.NewEnvironment()
+	Eval(parser.New(lexer.New(`let zeta = 1; let alpha = 2; let mid = fn() { let inner = 3; };`)).ParseProgram(), env)
+	env.SetAt(0, "slot", object.NewInteger(4))
+
+	names := env.Names()
+	expected := []string{"alpha", "mid", "slot", "zeta"}
+	if len(names) != len(expected) {
+		t.Fatalf("wrong names. want=%v, got=%v", expected, names)
+	}
+	for i, name := range expected {
+		if names[i] != name {
+			t.Errorf("wrong names. want=%v, got=%v", expected, names)
+			break
+		}
+	}
+}
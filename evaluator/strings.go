@@ -0,0 +1,212 @@
+package evaluator
+
+import (
+	"math"
+	"monkey/object"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 添字はlenと同じくbyte単位、padStartとpadEndの長さは文字数
+var stringsModule = &object.Module{Name: "strings", Members: map[string]object.Object{
+	"split": stringsBuiltin("split", 2, func(args []object.Object) object.Object {
+		parts := strings.Split(stringArg(args, 0), stringArg(args, 1))
+		elements := make([]object.Object, len(parts))
+		for i, part := range parts {
+			elements[i] = &object.String{Value: part}
+		}
+		return &object.Array{Elements: elements}
+	}, object.STRING_OBJ, object.STRING_OBJ),
+	"join": stringsBuiltin("join", 2, func(args []object.Object) object.Object {
+		elements := args[0].(*object.Array).Elements
+		parts := make([]string, len(elements))
+		for i, el := range elements {
+			s, ok := el.(*object.String)
+			if !ok {
+				return newError(object.ARGUMENT_ERROR, "elements of the array passed to `strings.join` must be STRING, got %s at %d", typeName(el), i)
+			}
+			parts[i] = s.Value
+		}
+		return &object.String{Value: strings.Join(parts, stringArg(args, 1))}
+	}, object.ARRAY_OBJ, object.STRING_OBJ),
+	// trim(s) 前後の空白、trim(s, chars) 前後のcharsに含まれる文字を取り除く
+	"trim": stringsBuiltin("trim", 1, func(args []object.Object) object.Object {
+		if len(args) == 2 {
+			return &object.String{Value: strings.Trim(stringArg(args, 0), stringArg(args, 1))}
+		}
+		return &object.String{Value: strings.TrimSpace(stringArg(args, 0))}
+	}, object.STRING_OBJ, object.STRING_OBJ),
+	"replace": stringsBuiltin("replace", 3, func(args []object.Object) object.Object {
+		return &object.String{Value: strings.ReplaceAll(stringArg(args, 0), stringArg(args, 1), stringArg(args, 2))}
+	}, object.STRING_OBJ, object.STRING_OBJ, object.STRING_OBJ),
+	"contains": stringsBuiltin("contains", 2, func(args []object.Object) object.Object {
+		return nativeBoolToBooleanObject(strings.Contains(stringArg(args, 0), stringArg(args, 1)))
+	}, object.STRING_OBJ, object.STRING_OBJ),
+	// 見つからなければ-1
+	"index": stringsBuiltin("index", 2, func(args []object.Object) object.Object {
+		return object.NewInteger(int64(strings.Index(stringArg(args, 0), stringArg(args, 1))))
+	}, object.STRING_OBJ, object.STRING_OBJ),
+	"upper": stringsBuiltin("upper", 1, func(args []object.Object) object.Object {
+		return &object.String{Value: strings.ToUpper(stringArg(args, 0))}
+	}, object.STRING_OBJ),
+	"lower": stringsBuiltin("lower", 1, func(args []object.Object) object.Object {
+		return &object.String{Value: strings.ToLower(stringArg(args, 0))}
+	}, object.STRING_OBJ),
+	"repeat": stringsBuiltin("repeat", 2, func(args []object.Object) object.Object {
+		n := args[1].(*object.Integer).Value
+		if n < 0 {
+			return newError(object.ARGUMENT_ERROR, "count passed to `strings.repeat` must not be negative, got %d", n)
+		}
+		s := stringArg(args, 0)
+		if len(s) > 0 && n > int64(maxStringLen/len(s)) {
+			return newError(object.ARGUMENT_ERROR, "result of `strings.repeat` is too long: %d bytes * %d exceeds %d bytes", len(s), n, maxStringLen)
+		}
+		return &object.String{Value: strings.Repeat(s, int(n))}
+	}, object.STRING_OBJ, object.INTEGER_OBJ),
+	// padStart(s, width) / padStart(s, width, pad) widthの文字数になるまでpad(既定は空白)を前に足す
+	"padStart": stringsBuiltin("padStart", 2, func(args []object.Object) object.Object {
+		return pad("padStart", args, func(s, padding string) string { return padding + s })
+	}, object.STRING_OBJ, object.INTEGER_OBJ, object.STRING_OBJ),
+	"padEnd": stringsBuiltin("padEnd", 2, func(args []object.Object) object.Object {
+		return pad("padEnd", args, func(s, padding string) string { return s + padding })
+	}, object.STRING_OBJ, object.INTEGER_OBJ, object.STRING_OBJ),
+	"format": &object.Builtin{Fn: builtinFormat},
+}}
+
+// repeatやpadで作れる文字列の長さの上限(byte数)
+// 大きすぎる数を渡されたときにpanicしたりmemoryを使い尽くしたりしないように
+const maxStringLen = 1 << 28
+
+// 引数の型を確かめてからfnを呼ぶ。typesのうち最初のrequired個は必須で、残りは省略できる
+func stringsBuiltin(name string, required int, fn func(args []object.Object) object.Object, types ...object.ObjectType) *object.Builtin {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		if len(args) < required || len(args) > len(types) {
+			want := strconv.Itoa(required)
+			if required != len(types) {
+				want += " to " + strconv.Itoa(len(types))
+			}
+			return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `strings.%s`: want=%s, got=%d", name, want, len(args))
+		}
+		for i, arg := range args {
+			if arg.Type() != types[i] {
+				return newError(object.ARGUMENT_ERROR, "argument %d to `strings.%s` must be %s, got %s", i+1, name, types[i], typeName(arg))
+			}
+		}
+		return fn(args)
+	}}
+}
+
+// stringsBuiltinで型を確かめたあとに使う
+func stringArg(args []object.Object, i int) string {
+	return args[i].(*object.String).Value
+}
+
+func pad(name string, args []object.Object, join func(s, padding string) string) object.Object {
+	s := stringArg(args, 0)
+	width := args[1].(*object.Integer).Value
+	if width < 0 {
+		return newError(object.ARGUMENT_ERROR, "width passed to `strings.%s` must not be negative, got %d", name, width)
+	}
+	if width > maxStringLen {
+		return newError(object.ARGUMENT_ERROR, "width passed to `strings.%s` is too large: %d exceeds %d", name, width, maxStringLen)
+	}
+	fill := " "
+	if len(args) == 3 {
+		fill = stringArg(args, 2)
+	}
+	if fill == "" {
+		return newError(object.ARGUMENT_ERROR, "padding passed to `strings.%s` must not be empty", name)
+	}
+
+	missing := int(width) - utf8.RuneCountInString(s)
+	if missing <= 0 {
+		return args[0]
+	}
+	padding := []rune(strings.Repeat(fill, missing/utf8.RuneCountInString(fill)+1))[:missing]
+	return &object.String{Value: join(s, string(padding))}
+}
+
+// strings.format("%s is %d", name, age)
+// %dはINTEGER、%sは何でも(Inspectした文字列)、%%は%そのもの
+func builtinFormat(args ...object.Object) object.Object {
+	if len(args) == 0 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `strings.format`: want at least 1, got=0")
+	}
+	format, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.ARGUMENT_ERROR, "argument 1 to `strings.format` must be STRING, got %s", typeName(args[0]))
+	}
+
+	var out strings.Builder
+	values := args[1:]
+	used := 0
+	for i := 0; i < len(format.Value); i++ {
+		ch := format.Value[i]
+		if ch != '%' {
+			out.WriteByte(ch)
+			continue
+		}
+		if i+1 == len(format.Value) {
+			return newError(object.ARGUMENT_ERROR, "`strings.format` verb missing at the end of %q", format.Value)
+		}
+		i++
+		verb := format.Value[i]
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+		if verb != 'd' && verb != 's' {
+			return newError(object.ARGUMENT_ERROR, "unknown verb %%%c in `strings.format`", verb)
+		}
+		if used == len(values) {
+			return newError(object.ARGUMENT_ERROR, "not enough arguments to `strings.format`: %q needs more than %d", format.Value, len(values))
+		}
+		value := values[used]
+		used++
+		if verb == 'd' && value.Type() != object.INTEGER_OBJ {
+			return newError(object.ARGUMENT_ERROR, "%%d in `strings.format` needs an INTEGER, got %s", typeName(value))
+		}
+		out.WriteString(value.Inspect())
+	}
+	if used != len(values) {
+		return newError(object.ARGUMENT_ERROR, "too many arguments to `strings.format`: %q uses %d, got %d", format.Value, used, len(values))
+	}
+	return &object.String{Value: out.String()}
+}
+
+// str(x) xのInspect
+func builtinStr(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `str`: want=1, got=%d", len(args))
+	}
+	if s, ok := args[0].(*object.String); ok {
+		return s
+	}
+	return &object.String{Value: args[0].Inspect()}
+}
+
+// int(x) 文字列は10進数として読み、小数は0の方向に切り捨てる
+// 読めない文字列はエラーで止めずにerr(e)を返すので、?やmatchで扱える。読めればok(n)
+func builtinInt(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError(object.ARGUMENT_ERROR, "wrong number of arguments to `int`: want=1, got=%d", len(args))
+	}
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		return &object.Result{Ok: true, Value: arg}
+	case *object.Float:
+		if math.IsNaN(arg.Value) || arg.Value <= math.MinInt64 || arg.Value >= math.MaxInt64 {
+			return object.ResultFromError(newError(object.VALUE_ERROR, "cannot convert %s to an INTEGER", arg.Inspect()))
+		}
+		return &object.Result{Ok: true, Value: object.NewInteger(int64(arg.Value))}
+	case *object.String:
+		n, err := strconv.ParseInt(strings.TrimSpace(arg.Value), 10, 64)
+		if err != nil {
+			return object.ResultFromError(newError(object.VALUE_ERROR, "cannot convert %q to an INTEGER", arg.Value))
+		}
+		return &object.Result{Ok: true, Value: object.NewInteger(n)}
+	default:
+		return newError(object.ARGUMENT_ERROR, "argument to `int` must be STRING, INTEGER or FLOAT, got %s", typeName(args[0]))
+	}
+}
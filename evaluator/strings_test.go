@@ -0,0 +1,106 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"testing"
+)
+
+func TestStringsModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`strings.split("a,b,c", ",")`, `[STRING a, STRING b, STRING c]`},
+		{`strings.split("ab", "")`, `[STRING a, STRING b]`},
+		{`strings.join(["a", "b"], "-")`, `STRING a-b`},
+		{`strings.join([], "-")`, `STRING `},
+		{`strings.trim("  hi \n")`, `STRING hi`},
+		{`strings.trim("xxhixx", "x")`, `STRING hi`},
+		{`strings.replace("a-a-a", "-", "+")`, `STRING a+a+a`},
+		{`strings.contains("hello", "ell")`, `BOOLEAN true`},
+		{`strings.index("hello", "l")`, `INTEGER 2`},
+		{`strings.index("hello", "z")`, `INTEGER -1`},
+		{`strings.upper("abc") + strings.lower("DEF")`, `STRING ABCdef`},
+		{`strings.repeat("ab", 3)`, `STRING ababab`},
+		{`strings.repeat("", 9223372036854775807)`, `STRING `},
+		{`strings.padStart("7", 3, "0")`, `STRING 007`},
+		{`strings.padStart("x", 6, "ab")`, `STRING ababax`},
+		{`strings.padEnd("ab", 4) + "|"`, `STRING ab  |`},
+		{`strings.padEnd("é", 3, ".")`, `STRING é..`},
+		{`strings.padStart("abcdef", 3)`, `STRING abcdef`},
+		{`strings.format("%s is %d (100%%)", "bob", 42)`, `STRING bob is 42 (100%)`},
+		{`strings.format("%s", [1, "a"])`, `STRING [1, a]`},
+		{`strings.format("plain")`, `STRING plain`},
+	}
+
+	for _, tt := range tests {
+		if got := describe(testEval(tt.input)); got != tt.expected {
+			t.Errorf("wrong result for %q. want=%s, got=%s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestStrAndInt(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`str(42)`, `STRING 42`},
+		{`str("a")`, `STRING a`},
+		{`str([1, "a", true])`, `STRING [1, a, true]`},
+		{`str(1.5) + str(2.0)`, `STRING 1.52.0`},
+		{`int("42")`, `RESULT ok(42)`},
+		{`int(" -7 ")`, `RESULT ok(-7)`},
+		{`int(2.9)`, `RESULT ok(2)`},
+		{`int(-2.9)`, `RESULT ok(-2)`},
+		{`int(5)`, `RESULT ok(5)`},
+		{`int("4x")`, `RESULT err(ValueError: cannot convert "4x" to an INTEGER)`},
+		{infinity + `int(inf)`, `RESULT err(ValueError: cannot convert +Inf to an INTEGER)`},
+		{`let parse = fn(s) { int(s)? + 1 }; parse("41")`, `INTEGER 42`},
+		{`let parse = fn(s) { int(s)? + 1 }; parse("x")`, `RESULT err(ValueError: cannot convert "x" to an INTEGER)`},
+		{`match (int("x")) { ok(n) => n, err(e) => e.kind }`, `STRING ValueError`},
+	}
+
+	for _, tt := range tests {
+		if got := describe(testEval(tt.input)); got != tt.expected {
+			t.Errorf("wrong result for %q. want=%s, got=%s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestStringsErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`strings.split(1, ",")`, "argument 1 to `strings.split` must be STRING, got INTEGER"},
+		{`strings.repeat("a")`, "wrong number of arguments to `strings.repeat`: want=2, got=1"},
+		{`strings.trim()`, "wrong number of arguments to `strings.trim`: want=1 to 2, got=0"},
+		{`strings.repeat("a", -1)`, "count passed to `strings.repeat` must not be negative, got -1"},
+		{`strings.repeat("ab", 9223372036854775807)`, "result of `strings.repeat` is too long: 2 bytes * 9223372036854775807 exceeds 268435456 bytes"},
+		{`strings.repeat("ab", 134217729)`, "result of `strings.repeat` is too long: 2 bytes * 134217729 exceeds 268435456 bytes"},
+		{`strings.padStart("a", -1)`, "width passed to `strings.padStart` must not be negative, got -1"},
+		{`strings.padEnd("a", 9223372036854775807)`, "width passed to `strings.padEnd` is too large: 9223372036854775807 exceeds 268435456"},
+		{`strings.join(["a", 1], ",")`, "elements of the array passed to `strings.join` must be STRING, got INTEGER at 1"},
+		{`strings.padStart("a", 3, "")`, "padding passed to `strings.padStart` must not be empty"},
+		{`strings.format("%d", "x")`, "%d in `strings.format` needs an INTEGER, got STRING"},
+		{`strings.format("%s %s", 1)`, `not enough arguments to ` + "`strings.format`" + `: "%s %s" needs more than 1`},
+		{`strings.format("%s", 1, 2)`, `too many arguments to ` + "`strings.format`" + `: "%s" uses 1, got 2`},
+		{`strings.format("%x", 1)`, "unknown verb %x in `strings.format`"},
+		{`strings.format("50%")`, "`strings.format` verb missing at the end of \"50%\""},
+		{`int([1])`, "argument to `int` must be STRING, INTEGER or FLOAT, got ARRAY"},
+		{`str()`, "wrong number of arguments to `str`: want=1, got=0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%s", tt.input, describe(evaluated))
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q. got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
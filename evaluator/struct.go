@@ -0,0 +1,161 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+// struct Point { x, y } でPointを定義する
+func evalStructStatement(ss *ast.StructStatement, env *object.Environment) object.Object {
+	fields := []string{}
+	for _, f := range ss.Fields {
+		fields = append(fields, f.Value)
+	}
+
+	st := &object.StructType{Name: ss.Name.Value, Fields: fields, Methods: map[string]*object.Function{}}
+	bind(env, ss.Name, st)
+
+	return nil
+}
+
+// impl Point { fn len(self) { ... } }
+// structにもenumにもmethodを定義できる
+// methodはimplを書いた場所の環境を閉じ込める
+// codesはcompileしたmethodの本体(tree-walkerではnil)
+func evalImplStatement(is *ast.ImplStatement, env *object.Environment, codes []func(*object.Frame) object.Object) object.Object {
+	val, ok := env.Get(is.Name.Value)
+	if !ok {
+		return newError(object.UNKNOWN_IDENTIFIER_ERROR, "identifier not found: "+is.Name.Value)
+	}
+
+	var typeName string
+	var methods map[string]*object.Function
+	var hasField func(string) bool
+
+	switch t := val.(type) {
+	case *object.StructType:
+		typeName, methods, hasField = t.Name, t.Methods, t.HasField
+	case *object.EnumType:
+		typeName, methods = t.Name, t.Methods
+		hasField = func(name string) bool {
+			for _, v := range t.Variants {
+				for _, f := range v.Fields {
+					if f == name {
+						return true
+					}
+				}
+			}
+			return false
+		}
+	default:
+		return newError(object.TYPE_MISMATCH_ERROR, "cannot impl %s", val.Type())
+	}
+
+	for i, m := range is.Methods {
+		if hasField(m.Name) {
+			return newError(object.FIELD_ERROR, "%s already has a field named %s", typeName, m.Name)
+		}
+		methods[m.Name] = &object.Function{
+			Parameters: m.Parameters,
+			Body:       m.Body,
+			Env:        env,
+			Name:       typeName + "." + m.Name,
+		}
+		if codes != nil {
+			methods[m.Name].Code = codes[i]
+		}
+	}
+
+	return nil
+}
+
+// Point { x: 1, y: 2 }
+// 全てのfieldに値を与える必要がある
+func evalStructLiteral(sl *ast.StructLiteral, env *object.Environment, eval evalFunc) object.Object {
+	val := evalIdentifier(sl.Name, env)
+	if isAbrupt(val) {
+		return val
+	}
+	st, ok := val.(*object.StructType)
+	if !ok {
+		return newError(object.TYPE_MISMATCH_ERROR, "not a struct: %s", typeName(val))
+	}
+
+	fields := make(map[string]object.Object, len(st.Fields))
+	for i, f := range sl.Fields {
+		if !st.HasField(f.Value) {
+			return newError(object.FIELD_ERROR, "unknown field %s on %s", f.Value, st.Name)
+		}
+
+		value := eval(sl.Values[i], env)
+		if isAbrupt(value) {
+			return value
+		}
+		fields[f.Value] = value
+	}
+
+	for _, name := range st.Fields {
+		if _, ok := fields[name]; !ok {
+			return newError(object.FIELD_ERROR, "missing field %s in %s", name, st.Name)
+		}
+	}
+
+	return &object.Instance{Struct: st, Fields: fields}
+}
+
+// left.name
+// instanceのfieldかmethod、structのmethod、catchしたerrorの情報を参照する
+func evalFieldExpression(left object.Object, name string) object.Object {
+	switch left := left.(type) {
+	case *object.Instance:
+		if val, ok := left.Fields[name]; ok {
+			return val
+		}
+		if method, ok := left.Struct.Methods[name]; ok {
+			return &object.BoundMethod{Receiver: left, Method: method}
+		}
+	case *object.StructType:
+		if method, ok := left.Methods[name]; ok {
+			return method
+		}
+	case *object.Exception:
+		if val, ok := exceptionField(left, name); ok {
+			return val
+		}
+	case *object.EnumType, *object.EnumValue:
+		if val, ok := evalEnumFieldExpression(left, name); ok {
+			return val
+		}
+	case object.FieldGetter:
+		if val, ok := left.GetField(name); ok {
+			return val
+		}
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERROR, "field access not supported: %s", typeName(left))
+	}
+
+	return newError(object.FIELD_ERROR, "unknown field %s on %s", name, typeName(left))
+}
+
+// obj.field = value
+// Monkeyのstructのinstanceは書き換えられないので、代入できるのはGoの値(object.FieldSetter)だけ
+func evalAssignStatement(as *ast.AssignStatement, env *object.Environment, eval evalFunc) object.Object {
+	left := eval(as.Target.Left, env)
+	if isAbrupt(left) {
+		return left
+	}
+	val := eval(as.Value, env)
+	if isAbrupt(val) {
+		return val
+	}
+
+	name := as.Target.Field.Value
+	setter, ok := left.(object.FieldSetter)
+	if !ok {
+		return setErrorPosition(newError(object.FIELD_ERROR, "cannot assign to field %s of %s", name, typeName(left)), as.Token)
+	}
+	if err := setter.SetField(name, val); err != nil {
+		return setErrorPosition(newError(object.FIELD_ERROR, "%s", err), as.Token)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package evaluator
+
+import "monkey/object"
+
+type valueKind byte
+
+const (
+	objectValue valueKind = iota
+	intValue
+	boolValue
+)
+
+// compileしたコードの中だけで使う値
+// 整数と真偽値はobject.Objectにboxせずに持ち、式の外に出すときにObjectにする
+// (a * b + c の a * b のような途中の結果をallocateしなくて済む)
+type value struct {
+	kind valueKind
+	num  int64 // intValueの値。boolValueは1か0
+	obj  object.Object
+}
+
+func intVal(n int64) value { return value{kind: intValue, num: n} }
+
+func boolVal(b bool) value {
+	if b {
+		return value{kind: boolValue, num: 1}
+	}
+	return value{kind: boolValue}
+}
+
+func valueOf(obj object.Object) value {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return intVal(obj.Value)
+	case *object.Boolean:
+		return boolVal(obj.Value)
+	}
+	return value{obj: obj}
+}
+
+func (v value) object() object.Object {
+	switch v.kind {
+	case intValue:
+		return object.NewInteger(v.num)
+	case boolValue:
+		return nativeBoolToBooleanObject(v.num != 0)
+	}
+	return v.obj
+}
+
+func (v value) abrupt() bool {
+	return v.kind == objectValue && isAbrupt(v.obj)
+}
+
+// isTruthyと同じ
+func (v value) truthy() bool {
+	switch v.kind {
+	case intValue:
+		return true
+	case boolValue:
+		return v.num != 0
+	}
+	return isTruthy(v.obj)
+}
@@ -1,19 +1,24 @@
 package lexer
 
-import "monkey/token"
+import (
+	"monkey/token"
+	"strings"
+)
 
 type Lexer struct {
 	input        string
 	position     int  // 入力における現在の位置
 	readPosition int  // これから読み込む位置(this will be used like l.input[l.readPosition])
 	ch           byte // 現在調査中の文字
+	line         int  // chの行番号(1始まり)
+	column       int  // chの列番号(1始まり)
 }
 
 // initializer
 // Lexer型のポインタを返す関数
 func New(input string) *Lexer {
 	// この&は初期化した値のポインタを抽出するため(この時点で変数lはLexer構造体によって生成されたインスタンスのポインタ)
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	// EX:
 	// l = instance of Lexer {
 	// 	input: `=+(){},;`,
@@ -32,6 +37,9 @@ func (l *Lexer) NextToken() token.Token {
 
 	l.skipWhitespace()
 
+	// tokenの先頭文字の位置を記録しておく
+	line, column := l.line, l.column
+
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
@@ -42,13 +50,23 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.EQ, Literal: literal}
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.FAT_ARROW, Literal: string(ch) + string(l.ch)}
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 	case '+':
 		tok = newToken(token.PLUS, l.ch)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.ARROW, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -62,6 +80,10 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.ASTERRISK, l.ch)
 	case '/':
 		tok = newToken(token.SLASH, l.ch)
+	case '?':
+		tok = newToken(token.QUESTION, l.ch)
+	case '|':
+		tok = newToken(token.PIPE, l.ch)
 	case '<':
 		tok = newToken(token.LT, l.ch)
 	case '>':
@@ -70,6 +92,16 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.COMMA, l.ch)
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
+	case ':':
+		tok = newToken(token.COLON, l.ch)
+	case '.':
+		if strings.HasPrefix(l.input[l.position:], "...") {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else {
+			tok = newToken(token.DOT, l.ch)
+		}
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
@@ -78,6 +110,13 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
 		tok = newToken(token.RBRACE, l.ch)
+	case '[':
+		tok = newToken(token.LBRACKET, l.ch)
+	case ']':
+		tok = newToken(token.RBRACKET, l.ch)
+	case '"':
+		tok.Type = token.STRING
+		tok.Literal = l.readString()
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -85,16 +124,19 @@ func (l *Lexer) NextToken() token.Token {
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Line, tok.Column = line, column
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Type, tok.Literal = l.readNumber()
+			tok.Line, tok.Column = line, column
 			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	}
 
+	tok.Line, tok.Column = line, column
+
 	// pointerを動かす処理(文字に対するindexがインクリメントする(Lexer.position))
 	l.readChar()
 	return tok
@@ -133,6 +175,13 @@ func (l *Lexer) peekChar() byte {
 
 // this is not function but method and receiver is Lexer instances
 func (l *Lexer) readChar() {
+	// 改行を読み終えたら次の行の先頭に移る
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+	l.column += 1
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0 // means "NUL[ASCII]" <= 終端を表す
 	} else {
@@ -148,12 +197,49 @@ func isLetter(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
 }
 
-func (l *Lexer) readNumber() string {
+// 1.5のように.の後ろに数字が続けば小数(1.fooの.はfield)
+func (l *Lexer) readNumber() (token.TokenType, string) {
 	position := l.position
 	for isDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	if l.ch != '.' || !isDigit(l.peekChar()) {
+		return token.INT, l.input[position:l.position]
+	}
+
+	l.readChar()
+	for isDigit(l.ch) {
+		l.readChar()
+	}
+	return token.FLOAT, l.input[position:l.position]
+}
+
+// 閉じの「"」まで読み込む(終端に達した場合もそこまでを文字列とする)
+// \n \t \" \\ のエスケープに対応する
+func (l *Lexer) readString() string {
+	var out []byte
+	for {
+		l.readChar()
+		if l.ch == '"' || l.ch == 0 {
+			break
+		}
+		if l.ch == '\\' {
+			l.readChar()
+			switch l.ch {
+			case 'n':
+				out = append(out, '\n')
+			case 't':
+				out = append(out, '\t')
+			case 0:
+				return string(out)
+			default:
+				out = append(out, l.ch)
+			}
+			continue
+		}
+		out = append(out, l.ch)
+	}
+	return string(out)
 }
 
 func isDigit(ch byte) bool {
@@ -0,0 +1,163 @@
+// Package monkey embeds the Monkey interpreter in Go programs.
+//
+//	in := monkey.New()
+//	in.Set("greet", func(name string) string { return "hello " + name })
+//	result, err := in.Eval(`greet("monkey")`)
+package monkey
+
+import (
+	"fmt"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"strings"
+)
+
+// Interpreter evaluates Monkey source in one environment, so names bound by
+// let in one Eval (or by Set) are visible to later ones.
+type Interpreter struct {
+	env   *object.Environment
+	loop  *evaluator.EventLoop
+	perms *evaluator.Permissions
+}
+
+func New() *Interpreter {
+	return NewWithRuntime(evaluator.SystemRuntime)
+}
+
+// NewWithRuntime creates an interpreter whose builtins read the time, random
+// numbers and environment variables from rt. Pass
+// evaluator.NewDeterministicRuntime(seed) for reproducible runs.
+func NewWithRuntime(rt evaluator.Runtime) *Interpreter {
+	in := &Interpreter{env: object.NewEnvironment(), loop: evaluator.NewEventLoop(rt), perms: evaluator.NewPermissions()}
+	evaluator.InstallRuntime(in.env, rt)
+	evaluator.InstallPermissions(in.env, in.perms)
+	in.loop.Install(in.env)
+	return in
+}
+
+// AllowRead lets scripts read files and list directories under dir
+// (readFile, readLines, listDir, exists). Scripts get no file access by default.
+// dir must exist; it is resolved to an absolute path without symlinks, and
+// every path a script passes is resolved the same way before it is checked.
+func (in *Interpreter) AllowRead(dir string) error {
+	return in.perms.AllowRead(dir)
+}
+
+// AllowWrite lets scripts create and overwrite files under dir (writeFile).
+// It does not allow reading them; grant that with AllowRead.
+func (in *Interpreter) AllowWrite(dir string) error {
+	return in.perms.AllowWrite(dir)
+}
+
+// AllowExec lets scripts run commands with exec(cmd, args). Commands are
+// started directly, without a shell.
+func (in *Interpreter) AllowExec() {
+	in.perms.AllowExec()
+}
+
+// AllowEnv lets scripts read environment variables with env(name), from the
+// interpreter's Runtime.
+func (in *Interpreter) AllowEnv() {
+	in.perms.AllowEnv()
+}
+
+// Loop returns the event loop that runs the interpreter's timers and async
+// functions. Eval only runs it while a top-level await is waiting; call
+// Loop().Run() to let pending timers and promises finish.
+// Host async functions are made with Loop().AsyncBuiltin or Loop().NewPromise.
+func (in *Interpreter) Loop() *evaluator.EventLoop {
+	return in.loop
+}
+
+// ParseError holds every message the parser reported.
+type ParseError struct {
+	Messages []string
+}
+
+func (e *ParseError) Error() string {
+	return strings.Join(e.Messages, "\n")
+}
+
+// Error is an error raised by Monkey code (including values thrown with throw)
+// that nothing caught. A call to exit(code) also ends evaluation with an Error;
+// Object.ExitCode reports the code, and it is up to the host to exit.
+type Error struct {
+	Object *object.Error
+}
+
+func (e *Error) Error() string {
+	if e.Object.Line == 0 {
+		return e.Object.Message
+	}
+	return fmt.Sprintf("%d:%d: %s", e.Object.Line, e.Object.Column, e.Object.Message)
+}
+
+// Eval parses and evaluates src and returns the value of its last statement
+// (NULL for statements without a value, like let).
+func (in *Interpreter) Eval(src string) (object.Object, error) {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{Messages: p.Errors()}
+	}
+
+	return result(evaluator.Eval(program, in.env))
+}
+
+// Set binds name to v converted with ToObject.
+func (in *Interpreter) Set(name string, v any) error {
+	obj, err := ToObject(v)
+	if err != nil {
+		return err
+	}
+	in.env.Set(name, obj)
+	return nil
+}
+
+// Get returns the value bound to name converted with ToGo, or nil if name is not bound.
+func (in *Interpreter) Get(name string) any {
+	obj, ok := in.env.Get(name)
+	if !ok {
+		return nil
+	}
+	return ToGo(obj)
+}
+
+// Call calls the Monkey function bound to name with args converted with ToObject
+// and returns its result converted with ToGo.
+func (in *Interpreter) Call(name string, args ...any) (any, error) {
+	fn, ok := in.env.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("identifier not found: %s", name)
+	}
+	return call(fn, args)
+}
+
+func call(fn object.Object, args []any) (any, error) {
+	objs := make([]object.Object, len(args))
+	for i, arg := range args {
+		obj, err := ToObject(arg)
+		if err != nil {
+			return nil, err
+		}
+		objs[i] = obj
+	}
+
+	obj, err := result(evaluator.CallFunction(fn, objs...))
+	if err != nil {
+		return nil, err
+	}
+	return ToGo(obj), nil
+}
+
+func result(obj object.Object) (object.Object, error) {
+	switch obj := obj.(type) {
+	case nil:
+		return evaluator.NULL, nil
+	case *object.Error:
+		return nil, &Error{Object: obj}
+	}
+	return obj, nil
+}
@@ -0,0 +1,383 @@
+package monkey
+
+import (
+	"errors"
+	"fmt"
+	"monkey/evaluator"
+	"monkey/object"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterpreterEval(t *testing.T) {
+	in := New()
+
+	if _, err := in.Eval("let x = 5;"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := in.Eval("x * 2")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if result.Inspect() != "10" {
+		t.Errorf("wrong result. want=10, got=%s", result.Inspect())
+	}
+}
+
+func TestInterpreterEvalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let = 1;", "expected next token to be IDENT, got = instead"},
+		{"1 + true", "1:3: type mismatch: INTEGER + BOOLEAN"},
+		{"throw \"boom\"", "1:1: boom"},
+	}
+
+	for _, tt := range tests {
+		_, err := New().Eval(tt.input)
+		if err == nil {
+			t.Errorf("expected an error for %q", tt.input)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), tt.expected) {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, err.Error())
+		}
+	}
+
+	_, err := New().Eval("throw 1")
+	var monkeyErr *Error
+	if !errors.As(err, &monkeyErr) || monkeyErr.Object.Kind != object.THROWN_ERROR {
+		t.Errorf("expected a thrown *Error, got=%#v", err)
+	}
+}
+
+func TestSetAndGet(t *testing.T) {
+	tests := []struct {
+		value    any
+		script   string
+		expected any
+	}{
+		{int64(3), "v + 1", int64(4)},
+		{7, "v * 2", int64(14)},
+		{uint8(200), "v", int64(200)},
+		{true, "!v", false},
+		{2.5, "v * 2", 5.0},
+		{float32(0.5), "v + 1", 1.5},
+		{"go", "v + \"pher\"", "gopher"},
+		{nil, "v", nil},
+		{[]int{1, 2, 3}, "[v[0], len(v)]", []any{int64(1), int64(3)}},
+		{map[string]int{"a": 1}, "v[\"a\"]", int64(1)},
+		{map[string]any{"xs": []string{"p"}}, "v[\"xs\"]", []any{"p"}},
+		{[]any{1, "two", false}, "v", []any{int64(1), "two", false}},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		if err := in.Set("v", tt.value); err != nil {
+			t.Fatalf("Set(%#v) failed: %s", tt.value, err)
+		}
+		if _, err := in.Eval("let result = " + tt.script + ";"); err != nil {
+			t.Fatalf("Eval(%q) failed: %s", tt.script, err)
+		}
+		if got := in.Get("result"); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("wrong value for %q. want=%#v, got=%#v", tt.script, tt.expected, got)
+		}
+	}
+}
+
+func TestGetConvertsHashes(t *testing.T) {
+	in := New()
+	if _, err := in.Eval(`let h = {"a": [1], 2: true};`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[any]any{"a": []any{int64(1)}, int64(2): true}
+	if got := in.Get("h"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong value. want=%#v, got=%#v", expected, got)
+	}
+	if got := in.Get("undefined"); got != nil {
+		t.Errorf("expected nil for an unbound name, got=%#v", got)
+	}
+}
+
+func TestSetUnsupportedValue(t *testing.T) {
+	if err := New().Set("c", make(chan int)); err == nil {
+		t.Errorf("expected an error for a channel")
+	}
+	if err := New().Set("h", map[any]int{nil: 1}); err == nil {
+		t.Errorf("expected an error for an unhashable key")
+	}
+}
+
+func TestGoFunctions(t *testing.T) {
+	in := New()
+	in.Set("add", func(a, b int) int { return a + b })
+	in.Set("join", func(sep string, parts ...string) string { return strings.Join(parts, sep) })
+	in.Set("sum", func(xs []int64) int64 {
+		total := int64(0)
+		for _, x := range xs {
+			total += x
+		}
+		return total
+	})
+	in.Set("divide", func(a, b int) (int, error) {
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return a / b, nil
+	})
+	in.Set("apply", func(f func(int) int, x int) int { return f(x) })
+	in.Set("kind", func(v any) string { return reflect.TypeOf(v).String() })
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"add(1, 2)", "3"},
+		{`join("-", "a", "b", "c")`, "a-b-c"},
+		{"sum([1, 2, 3])", "6"},
+		{"divide(6, 3)", "2"},
+		{"try { divide(1, 0) } catch (e) { e.kind + \": \" + e.message }", "HostError: division by zero"},
+		{"apply(fn(n) { n * 10 }, 4)", "40"},
+		{"kind([1])", "[]interface {}"},
+		{"try { add(1) } catch (e) { e.message }", "wrong number of arguments: want=2, got=1"},
+		{"try { add(1, \"x\") } catch (e) { e.message }", "argument 2: cannot use STRING as int"},
+	}
+
+	for _, tt := range tests {
+		result, err := in.Eval(tt.input)
+		if err != nil {
+			t.Errorf("Eval(%q) failed: %s", tt.input, err)
+			continue
+		}
+		if result.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+	}
+}
+
+func TestCallMonkeyFunctions(t *testing.T) {
+	in := New()
+	if _, err := in.Eval(`let greet = fn(name, n) { if (n > 0) { "hi " + name } else { throw "no" } };`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got, err := in.Call("greet", "go", 1)
+	if err != nil || got != "hi go" {
+		t.Errorf("wrong result. got=%#v, %v", got, err)
+	}
+
+	if _, err := in.Call("greet", "go", 0); err == nil || err.Error() != "1:62: no" {
+		t.Errorf("expected the thrown error, got=%v", err)
+	}
+
+	if _, err := in.Call("missing"); err == nil {
+		t.Errorf("expected an error for an unbound name")
+	}
+
+	greet, ok := in.Get("greet").(func(args ...any) (any, error))
+	if !ok {
+		t.Fatalf("expected a func, got=%T", in.Get("greet"))
+	}
+	if got, err := greet("gopher", 2); err != nil || got != "hi gopher" {
+		t.Errorf("wrong result. got=%#v, %v", got, err)
+	}
+}
+
+type server struct {
+	Host string
+	Port int
+}
+
+type config struct {
+	Name    string
+	Debug   bool
+	Server  server
+	Tags    []string
+	retries int
+}
+
+func (c *config) Address() string {
+	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
+}
+
+func (c *config) Retry(n int) error {
+	if n < 0 {
+		return errors.New("negative retries")
+	}
+	c.retries = n
+	return nil
+}
+
+func TestBindStruct(t *testing.T) {
+	cfg := &config{Name: "api", Server: server{Host: "localhost", Port: 80}, Tags: []string{"a"}}
+	in := New()
+	if err := in.Set("config", cfg); err != nil {
+		t.Fatalf("Set failed: %s", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"config.Name", "api"},
+		{"config.Server.Port + 1", "81"},
+		{"config.Tags", "[a]"},
+		{"config.Address()", "localhost:80"},
+		{"config.Server.Port = 8080; config.Address()", "localhost:8080"},
+		{"config.Debug = !config.Debug; config.Debug", "true"},
+		{"config.Tags = [\"x\", \"y\"]; len(config.Tags)", "2"},
+		{"config.Retry(3)", "null"},
+		{"config == config", "true"},
+		{"config.Server == config.Server", "true"},
+		{"try { config.Retry(-1) } catch (e) { e.kind + \": \" + e.message }", "HostError: negative retries"},
+		{"try { config.Port } catch (e) { e.message }", "unknown field Port on *monkey.config"},
+		{"try { config.retries } catch (e) { e.message }", "unknown field retries on *monkey.config"},
+		{"try { config.Port = 1 } catch (e) { e.message }", "unknown field Port on *monkey.config"},
+		{"try { config.Name = 1 } catch (e) { e.message }", "cannot assign to Name: cannot use INTEGER as string"},
+	}
+
+	for _, tt := range tests {
+		result, err := in.Eval(tt.input)
+		if err != nil {
+			t.Errorf("Eval(%q) failed: %s", tt.input, err)
+			continue
+		}
+		if result.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, tt.expected, result.Inspect())
+		}
+	}
+
+	if cfg.Server.Port != 8080 || !cfg.Debug || len(cfg.Tags) != 2 || cfg.retries != 3 {
+		t.Errorf("writes did not reach the Go value: %+v", cfg)
+	}
+	if in.Get("config") != cfg {
+		t.Errorf("Get should return the bound pointer")
+	}
+}
+
+func TestBindStructValueIsCopied(t *testing.T) {
+	cfg := config{Name: "api"}
+	in := New()
+	in.Set("config", cfg)
+	if _, err := in.Eval(`config.Name = "changed";`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cfg.Name != "api" {
+		t.Errorf("a struct passed by value should not change. got=%q", cfg.Name)
+	}
+}
+
+func TestGoFunctionsTakeHostObjects(t *testing.T) {
+	in := New()
+	in.Set("config", &config{Server: server{Port: 1}})
+	in.Set("port", func(c *config) int { return c.Server.Port })
+	in.Set("host", func(s server) int { return s.Port })
+
+	result, err := in.Eval("[port(config), host(config.Server)]")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if result.Inspect() != "[1, 1]" {
+		t.Errorf("wrong result. got=%s", result.Inspect())
+	}
+}
+
+func TestHostAsyncFunctions(t *testing.T) {
+	in := New()
+	in.Set("fetch", in.Loop().AsyncBuiltin(func(args ...object.Object) object.Object {
+		return &object.String{Value: "body of " + args[0].Inspect()}
+	}))
+
+	result, err := in.Eval(`let get = async fn(url) { await fetch(url) }; await get("a")`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if result.Inspect() != "body of a" {
+		t.Errorf("wrong result. want=%q, got=%q", "body of a", result.Inspect())
+	}
+
+	if _, err := in.Eval(`let p = setTimeout(fn() { 1 }, 1);`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	in.Loop().Run()
+	if p := in.Get("p"); p.(*object.Promise).State != object.PROMISE_FULFILLED {
+		t.Errorf("Run did not fire the timer")
+	}
+}
+
+func TestDeterministicInterpreter(t *testing.T) {
+	run := func() string {
+		in := NewWithRuntime(evaluator.NewDeterministicRuntime(7))
+		result, err := in.Eval(`let t = await setTimeout(fn() { now() }, 20); [t, random(1000), random(1000)]`)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return result.Inspect()
+	}
+
+	first := run()
+	if first != run() {
+		t.Errorf("runs with the same seed differ")
+	}
+	if !strings.HasPrefix(first, "[20, ") {
+		t.Errorf("the fake clock did not advance to the timer. got=%s", first)
+	}
+}
+
+func TestFileAccessNeedsGrants(t *testing.T) {
+	dir := t.TempDir()
+	script := fmt.Sprintf(`writeFile(%q, "data"); readFile(%q)`, dir+"/f.txt", dir+"/f.txt")
+
+	in := New()
+	_, err := in.Eval(script)
+	var monkeyErr *Error
+	if !errors.As(err, &monkeyErr) || monkeyErr.Object.Kind != object.PERMISSION_ERROR {
+		t.Fatalf("expected a PermissionDenied error, got=%v", err)
+	}
+
+	if err := in.AllowWrite(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.AllowRead(dir); err != nil {
+		t.Fatal(err)
+	}
+	result, err := in.Eval(script)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if result.Inspect() != "data" {
+		t.Errorf("wrong result. want=data, got=%s", result.Inspect())
+	}
+}
+
+func TestProcessNeedsGrants(t *testing.T) {
+	in := New()
+	_, err := in.Eval(`exec("echo", ["hi"])`)
+	var monkeyErr *Error
+	if !errors.As(err, &monkeyErr) || monkeyErr.Object.Kind != object.PERMISSION_ERROR {
+		t.Fatalf("expected a PermissionDenied error, got=%v", err)
+	}
+
+	in.AllowExec()
+	result, err := in.Eval(`exec("echo", ["hi"])["stdout"]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if result.Inspect() != "hi\n" {
+		t.Errorf("wrong result. want=%q, got=%q", "hi\n", result.Inspect())
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	_, err := New().Eval(`let f = fn() { exit(7) }; try { f() } catch (e) { 0 }`)
+	var monkeyErr *Error
+	if !errors.As(err, &monkeyErr) {
+		t.Fatalf("expected exit to end evaluation with an error, got=%v", err)
+	}
+	if code, ok := monkeyErr.Object.ExitCode(); !ok || code != 7 {
+		t.Errorf("wrong exit code. want=7, got=%d, %t", code, ok)
+	}
+}
@@ -1,20 +1,136 @@
 package object
 
+import (
+	"sort"
+	"sync"
+)
+
 func NewEnvironment() *Environment {
-	s := make(map[string]Object)
-	return &Environment{store: s}
+	return &Environment{}
+}
+
+// 関数呼び出しごとに作られる環境(outerは関数が定義された環境)
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	return &Environment{outer: outer}
 }
 
+// resolverがslotを割り当てた名前はslotsに、それ以外はstoreに入れる
+// 同じ名前が両方に入ることはない
+// storeは関数呼び出しのたびにmapを作らないように、最初にSetしたときに作る
+// spawnした関数と同じ環境を共有することがあるので、読み書きはmuで守る
 type Environment struct {
+	mu    sync.RWMutex
 	store map[string]Object
+	slots []binding
+	outer *Environment
+}
+
+// compileしたコード(evaluator.Compile)が実行される環境
+type Frame = Environment
+
+type binding struct {
+	name  string
+	value Object
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
-	return obj, ok
+	for ; e != nil; e = e.outer {
+		if obj, ok := e.get(name); ok {
+			return obj, true
+		}
+	}
+	return nil, false
+}
+
+// この環境だけを探す
+func (e *Environment) get(name string) (Object, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if obj, ok := e.store[name]; ok {
+		return obj, true
+	}
+	for _, b := range e.slots {
+		if b.name == name && b.value != nil {
+			return b.value, true
+		}
+	}
+	return nil, false
 }
 
 func (e *Environment) Set(name string, val Object) Object {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i := range e.slots {
+		if e.slots[i].name == name {
+			e.slots[i].value = val
+			return val
+		}
+	}
+
+	if e.store == nil {
+		e.store = make(map[string]Object)
+	}
 	e.store[name] = val
 	return val
 }
+
+// depth個外側の環境のslotの値。まだ束縛されていなければfalse
+func (e *Environment) GetAt(depth, slot int) (Object, bool) {
+	for i := 0; i < depth && e != nil; i++ {
+		e = e.outer
+	}
+	if e == nil {
+		return nil, false
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if slot >= len(e.slots) || e.slots[slot].value == nil {
+		return nil, false
+	}
+	return e.slots[slot].value, true
+}
+
+func (e *Environment) SetAt(slot int, name string, val Object) Object {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if slot >= len(e.slots) {
+		// 関数の引数を一つずつ束縛するたびにallocateしないよう、少し多めに取る
+		if slot >= cap(e.slots) {
+			size := 2*cap(e.slots) + 4
+			if size <= slot {
+				size = slot + 1
+			}
+			slots := make([]binding, len(e.slots), size)
+			copy(slots, e.slots)
+			e.slots = slots
+		}
+		e.slots = e.slots[:slot+1]
+	}
+	e.slots[slot] = binding{name: name, value: val}
+	delete(e.store, name)
+	return val
+}
+
+// この環境で束縛されている名前(外側は含まない)を名前の順に返す
+// storeはmapなので、順番が実行ごとに変わらないように並べる
+func (e *Environment) Names() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	names := make([]string, 0, len(e.store)+len(e.slots))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	for _, b := range e.slots {
+		if b.value != nil {
+			names = append(names, b.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
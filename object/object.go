@@ -1,15 +1,61 @@
 package object
 
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"monkey/ast"
+	"sort"
+	"strconv"
+	"strings"
+)
 
 type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
 	ERROR_OBJ        = "ERROR"
+	FUNCTION_OBJ     = "FUNCTION"
+	STRING_OBJ       = "STRING"
+	EXCEPTION_OBJ    = "EXCEPTION"
+	BUILTIN_OBJ      = "BUILTIN"
+	RESULT_OBJ       = "RESULT"
+	ARRAY_OBJ        = "ARRAY"
+	HASH_OBJ         = "HASH"
+	STRUCT_OBJ       = "STRUCT"
+	BOUND_METHOD_OBJ = "BOUND_METHOD"
+	ENUM_OBJ         = "ENUM"
+	VARIANT_OBJ      = "VARIANT"
+	INSTANCE_OBJ     = "INSTANCE"
+	ENUM_VALUE_OBJ   = "ENUM_VALUE"
+	CHANNEL_OBJ      = "CHANNEL"
+	GENERATOR_OBJ    = "GENERATOR"
+	PROMISE_OBJ      = "PROMISE"
+	MODULE_OBJ       = "MODULE"
+)
+
+// kind of Error (exposed to monkey code as e["kind"])
+const (
+	TYPE_MISMATCH_ERROR      = "TypeMismatch"
+	UNKNOWN_OPERATOR_ERROR   = "UnknownOperator"
+	UNKNOWN_IDENTIFIER_ERROR = "UnknownIdentifier"
+	NOT_A_FUNCTION_ERROR     = "NotAFunction"
+	ARGUMENT_ERROR           = "ArgumentError"
+	INDEX_ERROR              = "IndexError"
+	DIVISION_BY_ZERO_ERROR   = "DivisionByZero"
+	PATTERN_ERROR            = "PatternError" // the value does not have the shape of the pattern
+	FIELD_ERROR              = "FieldError"
+	THROWN_ERROR             = "Thrown"           // throw with a value that is not an error
+	HOST_ERROR               = "HostError"        // a Go function returned an error
+	CANCELLED_ERROR          = "Cancelled"        // a timer was cleared before it fired
+	VALUE_ERROR              = "ValueError"       // right type, but a value the function cannot use (int("x"))
+	PERMISSION_ERROR         = "PermissionDenied" // the host has not granted access (files outside the allowed directories)
+	IO_ERROR                 = "IOError"
+	EXIT_ERROR               = "Exit" // exit(code): unwinds like an error, but try/catch does not catch it
 )
 
 type Object interface {
@@ -17,6 +63,28 @@ type Object interface {
 	Inspect() string
 }
 
+// Go values can overload operators by implementing these.
+// They are asked before the built-in rules; returning false falls back to them.
+// Only the left operand of an infix operator is asked.
+type InfixOperator interface {
+	InfixOperator(operator string, right Object) (Object, bool)
+}
+
+type PrefixOperator interface {
+	PrefixOperator(operator string) (Object, bool)
+}
+
+// Go values can expose fields (and methods) to `x.name` by implementing FieldGetter,
+// and accept `x.name = value` by implementing FieldSetter.
+// Struct instances defined in Monkey cannot be assigned to.
+type FieldGetter interface {
+	GetField(name string) (Object, bool)
+}
+
+type FieldSetter interface {
+	SetField(name string, value Object) error
+}
+
 type Integer struct {
 	Value int64
 }
@@ -24,6 +92,51 @@ type Integer struct {
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 
+// よく使う小さな整数は前もって作っておき、同じものを使い回す
+// Integerは作ったあとで書き換えないこと
+const (
+	minCachedInteger = -128
+	maxCachedInteger = 1024
+)
+
+var integerCache = func() []Integer {
+	cache := make([]Integer, maxCachedInteger-minCachedInteger+1)
+	for i := range cache {
+		cache[i].Value = int64(i + minCachedInteger)
+	}
+	return cache
+}()
+
+// 小さな整数であればallocateしない
+func NewInteger(value int64) *Integer {
+	if value >= minCachedInteger && value <= maxCachedInteger {
+		return &integerCache[value-minCachedInteger]
+	}
+	return &Integer{Value: value}
+}
+
+// Float is printed with a decimal point even when it is integral (2.0),
+// so it can be told apart from an Integer.
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
+type String struct {
+	Value string
+}
+
+func (s *String) Type() ObjectType { return STRING_OBJ }
+func (s *String) Inspect() string  { return s.Value }
+
 type Boolean struct {
 	Value bool
 }
@@ -44,9 +157,421 @@ type ReturnValue struct {
 func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
+// Error stops evaluation until it is caught by try/catch.
+// Line and Column point at where it was raised, Value is the thrown value for `throw`.
+// Stack holds the calls the error has propagated out of, innermost first
+// (like a Go panic trace). Embedders can read the frames directly.
 type Error struct {
+	Kind    string
 	Message string
+	Line    int
+	Column  int
+	Value   Object
+	Stack   []StackFrame
+}
+
+// ExitCode reports the code passed to exit() when the error was raised by it.
+func (e *Error) ExitCode() (int, bool) {
+	if e.Kind != EXIT_ERROR {
+		return 0, false
+	}
+	code, _ := e.Value.(*Integer)
+	if code == nil {
+		return 0, true
+	}
+	return int(code.Value), true
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
-func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+func (e *Error) Inspect() string {
+	if len(e.Stack) == 0 {
+		return "ERROR: " + e.Message
+	}
+
+	var out bytes.Buffer
+
+	out.WriteString("ERROR: " + e.Message + "\n")
+	out.WriteString("\n")
+	for _, f := range e.Stack {
+		out.WriteString(f.String() + "\n")
+	}
+
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// Exception is an Error bound by catch.
+// Unlike Error it is an ordinary value, so it can be passed around without propagating.
+type Exception struct {
+	Error *Error
+}
+
+func (e *Exception) Type() ObjectType { return EXCEPTION_OBJ }
+func (e *Exception) Inspect() string  { return e.Error.Kind + ": " + e.Error.Message }
+
+// one call on the stack
+// Function is "<anonymous>" when the function was not bound with let
+// Line and Column point at the call site
+type StackFrame struct {
+	Function string
+	Line     int
+	Column   int
+}
+
+// add(...)
+//
+//	at 3:12
+func (f StackFrame) String() string {
+	return fmt.Sprintf("%s(...)\n\tat %d:%d", f.Function, f.Line, f.Column)
+}
+
+type Function struct {
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+	Name       string
+	Code       func(*Frame) Object // compileした本体(nilならBodyをEvalする)
+	Generator  bool                // fn*で作った関数。呼ぶと本体を実行せずGeneratorを返す
+	Async      bool                // async fn。呼ぶとPromiseを返す
+}
+
+func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
+func (f *Function) Inspect() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range f.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("fn")
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(f.Body.String())
+	out.WriteString("\n}")
+
+	return out.String()
+}
+
+// function implemented in Go
+type BuiltinFunction func(args ...Object) Object
+
+type Builtin struct {
+	Fn BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
+func (b *Builtin) Inspect() string  { return "builtin function" }
+
+// ok(value) or err(value)
+// Builtins may return an err Result instead of an Error so that scripts can
+// handle the failure themselves (with ?) rather than having it unwind the program.
+type Result struct {
+	Ok    bool
+	Value Object
+}
+
+func (r *Result) Type() ObjectType { return RESULT_OBJ }
+func (r *Result) Inspect() string {
+	if r.Ok {
+		return "ok(" + r.Value.Inspect() + ")"
+	}
+	return "err(" + r.Value.Inspect() + ")"
+}
+
+// ResultFromError turns an Error into err(e), where e exposes the message, kind
+// and position the same way a caught exception does.
+func ResultFromError(err *Error) *Result {
+	return &Result{Ok: false, Value: &Exception{Error: err}}
+}
+
+type Array struct {
+	Elements []Object
+}
+
+func (ao *Array) Type() ObjectType { return ARRAY_OBJ }
+func (ao *Array) Inspect() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, e := range ao.Elements {
+		elements = append(elements, e.Inspect())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+// key of Hash.Pairs
+// two objects with the same type and value have the same HashKey
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+// objects that can be used as a key of Hash
+type Hashable interface {
+	HashKey() HashKey
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+
+	if b.Value {
+		value = 1
+	} else {
+		value = 0
+	}
+
+	return HashKey{Type: b.Type(), Value: value}
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+type Hash struct {
+	Pairs map[HashKey]HashPair
+}
+
+func (h *Hash) Type() ObjectType { return HASH_OBJ }
+func (h *Hash) Inspect() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range h.SortedPairs() {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// SortedPairs returns the pairs in a fixed order, so that listings do not depend
+// on Go's map iteration order: by key type, then integers by value and other
+// keys by their Inspect.
+func (h *Hash) SortedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		a, b := pairs[i].Key, pairs[j].Key
+		if a.Type() != b.Type() {
+			return a.Type() < b.Type()
+		}
+		if a, ok := a.(*Integer); ok {
+			return a.Value < b.(*Integer).Value
+		}
+		return a.Inspect() < b.Inspect()
+	})
+	return pairs
+}
+
+// struct Point { x, y }
+// Methods are added by impl
+type StructType struct {
+	Name    string
+	Fields  []string
+	Methods map[string]*Function
+}
+
+func (st *StructType) Type() ObjectType { return STRUCT_OBJ }
+func (st *StructType) Inspect() string {
+	return "struct " + st.Name + " { " + strings.Join(st.Fields, ", ") + " }"
+}
+
+func (st *StructType) HasField(name string) bool {
+	for _, f := range st.Fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// value of a user-defined struct (Point { x: 1, y: 2 })
+// Type() is always INSTANCE, so a struct named like a built-in type (INTEGER)
+// cannot be mistaken for it; TypeName() is the name of the struct
+type Instance struct {
+	Struct *StructType
+	Fields map[string]Object
+}
+
+func (i *Instance) Type() ObjectType { return INSTANCE_OBJ }
+func (i *Instance) TypeName() string { return i.Struct.Name }
+func (i *Instance) Inspect() string {
+	fields := []string{}
+	for _, name := range i.Struct.Fields {
+		fields = append(fields, name+": "+i.Fields[name].Inspect())
+	}
+	return i.Struct.Name + " { " + strings.Join(fields, ", ") + " }"
+}
+
+// point.len (the receiver is passed as the first argument when called)
+type BoundMethod struct {
+	Receiver Object
+	Method   *Function
+}
+
+func (bm *BoundMethod) Type() ObjectType { return BOUND_METHOD_OBJ }
+func (bm *BoundMethod) Inspect() string  { return "method " + bm.Method.Name }
+
+// enum Shape { Circle(r), Rect(w, h), Empty }
+type EnumType struct {
+	Name     string
+	Variants []*Variant
+	Methods  map[string]*Function
+}
+
+func (et *EnumType) Type() ObjectType { return ENUM_OBJ }
+func (et *EnumType) Inspect() string {
+	variants := []string{}
+	for _, v := range et.Variants {
+		variants = append(variants, v.Inspect())
+	}
+	return "enum " + et.Name + " { " + strings.Join(variants, ", ") + " }"
+}
+
+func (et *EnumType) Variant(name string) (*Variant, bool) {
+	for _, v := range et.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// one variant of an enum
+// A variant with fields is called like a function to build a value (Circle(2)).
+// A variant without fields has a single value, Unit (Empty).
+type Variant struct {
+	Enum   *EnumType
+	Name   string
+	Fields []string
+	Unit   *EnumValue
+}
+
+func (v *Variant) Type() ObjectType { return VARIANT_OBJ }
+func (v *Variant) Inspect() string {
+	if len(v.Fields) == 0 {
+		return v.Name
+	}
+	return v.Name + "(" + strings.Join(v.Fields, ", ") + ")"
+}
+
+// value built from a variant (Circle(2), Empty)
+// Type() is always ENUM_VALUE and TypeName() is the name of the enum.
+// Values[i] belongs to Variant.Fields[i]
+type EnumValue struct {
+	Variant *Variant
+	Values  []Object
+}
+
+func (ev *EnumValue) Type() ObjectType { return ENUM_VALUE_OBJ }
+func (ev *EnumValue) TypeName() string { return ev.Variant.Enum.Name }
+func (ev *EnumValue) Inspect() string {
+	if len(ev.Values) == 0 {
+		return ev.Variant.Name
+	}
+
+	values := []string{}
+	for _, v := range ev.Values {
+		values = append(values, v.Inspect())
+	}
+	return ev.Variant.Name + "(" + strings.Join(values, ", ") + ")"
+}
+
+// channel(n)で作るチャネル。spawnした関数の結果もこれで受け取る
+type Channel struct {
+	Ch chan Object
+}
+
+func (c *Channel) Type() ObjectType { return CHANNEL_OBJ }
+func (c *Channel) Inspect() string  { return fmt.Sprintf("channel(%d)", cap(c.Ch)) }
+
+// fn*で作った関数を呼んだときの値
+// g.next(v)は次のyieldまで進めて {"value": yieldした値, "done": false} を返す
+// 本体が終わると {"value": 戻り値, "done": true}、そのあとは {"value": null, "done": true}
+// g.close()は途中で止める(参照されなくなったgeneratorも止まる)
+type Generator struct {
+	Next  BuiltinFunction
+	Close BuiltinFunction
+}
+
+func (g *Generator) Type() ObjectType { return GENERATOR_OBJ }
+func (g *Generator) Inspect() string  { return "generator" }
+
+func (g *Generator) GetField(name string) (Object, bool) {
+	switch name {
+	case "next":
+		return &Builtin{Fn: g.Next}, true
+	case "close":
+		return &Builtin{Fn: g.Close}, true
+	}
+	return nil, false
+}
+
+type PromiseState int
+
+const (
+	PROMISE_PENDING PromiseState = iota
+	PROMISE_FULFILLED
+	PROMISE_REJECTED
+)
+
+// async fnやsetTimeoutが返す値
+// event loopを動かしているgoroutineからだけ読み書きする(Goから解決するときはEventLoop.NewPromiseを使う)
+type Promise struct {
+	State     PromiseState
+	Value     Object      // fulfilledなら結果、rejectedなら*Error
+	Callbacks []func()    // settleしたときにevent loopで呼ぶ
+	Cancel    func() bool // setTimeoutのpromiseならclearTimeoutで止める
+}
+
+func (p *Promise) Type() ObjectType { return PROMISE_OBJ }
+func (p *Promise) Inspect() string {
+	switch p.State {
+	case PROMISE_FULFILLED:
+		return "promise(" + p.Value.Inspect() + ")"
+	case PROMISE_REJECTED:
+		if err, ok := p.Value.(*Error); ok {
+			return "promise(rejected: " + err.Message + ")"
+		}
+		return "promise(rejected)"
+	default:
+		return "promise(pending)"
+	}
+}
+
+// Module groups builtins and constants under one name (math.sqrt, math.pi).
+type Module struct {
+	Name    string
+	Members map[string]Object
+}
+
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Inspect() string  { return "module " + m.Name }
+
+func (m *Module) GetField(name string) (Object, bool) {
+	member, ok := m.Members[name]
+	return member, ok
+}
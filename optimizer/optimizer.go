@@ -0,0 +1,285 @@
+package optimizer
+
+import (
+	"monkey/ast"
+	"monkey/token"
+	"strconv"
+)
+
+// 評価の前にprogramを書き換える(元のprogramをそのまま書き換えて返す)
+//   - 整数と真偽値のリテラルどうしの演算を畳み込む(0での割り算などエラーになるものはそのまま)
+//   - 条件がリテラルのifは評価される側だけを残す
+//   - returnやthrowのあとの文を取り除く
+//
+// 評価した結果は書き換える前と変わらない
+func Optimize(program *ast.Program) *ast.Program {
+	program.Statements = optimizeStatements(program.Statements)
+	return program
+}
+
+func optimizeBlock(block *ast.BlockStatement) {
+	if block != nil {
+		block.Statements = optimizeStatements(block.Statements)
+	}
+}
+
+// blockは環境を作らないので、条件が決まっているifのblockは外側の文の並びに展開できる
+func optimizeStatements(stmts []ast.Statement) []ast.Statement {
+	result := []ast.Statement{}
+
+	for i, stmt := range stmts {
+		stmt = optimizeStatement(stmt)
+		last := i == len(stmts)-1
+
+		if es, ok := stmt.(*ast.ExpressionStatement); ok {
+			if ie, ok := es.Expression.(*ast.IfExpression); ok {
+				if block, ok := chosenBranch(ie); ok {
+					// ifの値は最後の文の値になるが、空のblockの値(nilやNULL)は文の並びでは表せない
+					if block != nil && len(block.Statements) != 0 {
+						result = append(result, block.Statements...)
+						if isTerminal(result[len(result)-1]) {
+							break
+						}
+						continue
+					}
+					if !last {
+						continue
+					}
+				}
+			}
+		}
+
+		result = append(result, stmt)
+		if isTerminal(stmt) {
+			break
+		}
+	}
+
+	return result
+}
+
+// このあとの文には決して届かない
+func isTerminal(stmt ast.Statement) bool {
+	switch stmt.(type) {
+	case *ast.ReturnStatement, *ast.ThrowStatement:
+		return true
+	}
+	return false
+}
+
+func optimizeStatement(stmt ast.Statement) ast.Statement {
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatement:
+		stmt.Expression = optimizeExpression(stmt.Expression)
+	case *ast.LetStatement:
+		stmt.Value = optimizeExpression(stmt.Value)
+		optimizePattern(stmt.Pattern)
+	case *ast.ReturnStatement:
+		stmt.ReturnValue = optimizeExpression(stmt.ReturnValue)
+	case *ast.ThrowStatement:
+		stmt.Value = optimizeExpression(stmt.Value)
+	case *ast.AssignStatement:
+		stmt.Target.Left = optimizeExpression(stmt.Target.Left)
+		stmt.Value = optimizeExpression(stmt.Value)
+	case *ast.ImplStatement:
+		for _, m := range stmt.Methods {
+			optimizeBlock(m.Body)
+		}
+	case *ast.BlockStatement:
+		optimizeBlock(stmt)
+	}
+	return stmt
+}
+
+func optimizeExpression(exp ast.Expression) ast.Expression {
+	switch exp := exp.(type) {
+	case *ast.PrefixExpression:
+		exp.Right = optimizeExpression(exp.Right)
+		if folded := foldPrefix(exp); folded != nil {
+			return folded
+		}
+	case *ast.InfixExpression:
+		exp.Left = optimizeExpression(exp.Left)
+		exp.Right = optimizeExpression(exp.Right)
+		if folded := foldInfix(exp); folded != nil {
+			return folded
+		}
+	case *ast.PostfixExpression:
+		exp.Left = optimizeExpression(exp.Left)
+	case *ast.IfExpression:
+		exp.Condition = optimizeExpression(exp.Condition)
+		optimizeBlock(exp.Consequence)
+		optimizeBlock(exp.Alternative)
+		// 評価される側が式一つだけならその式に置き換える
+		if block, ok := chosenBranch(exp); ok && block != nil && len(block.Statements) == 1 {
+			if es, ok := block.Statements[0].(*ast.ExpressionStatement); ok && es.Expression != nil {
+				return es.Expression
+			}
+		}
+	case *ast.FunctionLiteral:
+		optimizeBlock(exp.Body)
+	case *ast.CallExpression:
+		exp.Function = optimizeExpression(exp.Function)
+		for i := range exp.Arguments {
+			exp.Arguments[i] = optimizeExpression(exp.Arguments[i])
+		}
+	case *ast.ArrayLiteral:
+		for i := range exp.Elements {
+			exp.Elements[i] = optimizeExpression(exp.Elements[i])
+		}
+	case *ast.HashLiteral:
+		for i := range exp.Keys {
+			exp.Keys[i] = optimizeExpression(exp.Keys[i])
+			exp.Values[i] = optimizeExpression(exp.Values[i])
+		}
+	case *ast.StructLiteral:
+		for i := range exp.Values {
+			exp.Values[i] = optimizeExpression(exp.Values[i])
+		}
+	case *ast.IndexExpression:
+		exp.Left = optimizeExpression(exp.Left)
+		exp.Index = optimizeExpression(exp.Index)
+	case *ast.FieldExpression:
+		exp.Left = optimizeExpression(exp.Left)
+	case *ast.TryExpression:
+		optimizeBlock(exp.Block)
+		optimizeBlock(exp.Catch)
+		optimizeBlock(exp.Finally)
+	case *ast.MatchExpression:
+		exp.Subject = optimizeExpression(exp.Subject)
+		for _, arm := range exp.Arms {
+			optimizePattern(arm.Pattern)
+			arm.Guard = optimizeExpression(arm.Guard)
+			optimizeBlock(arm.Body)
+		}
+	case *ast.YieldExpression:
+		exp.Value = optimizeExpression(exp.Value)
+	case *ast.AwaitExpression:
+		exp.Value = optimizeExpression(exp.Value)
+	case *ast.SelectExpression:
+		for _, sc := range exp.Cases {
+			sc.Channel = optimizeExpression(sc.Channel)
+			sc.Value = optimizeExpression(sc.Value)
+			optimizeBlock(sc.Body)
+		}
+	}
+	return exp
+}
+
+// patternのdefaultの式
+func optimizePattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.DefaultPattern:
+		pattern.Default = optimizeExpression(pattern.Default)
+		optimizePattern(pattern.Pattern)
+	case *ast.AlternativePattern:
+		for _, alt := range pattern.Alternatives {
+			optimizePattern(alt)
+		}
+	case *ast.ConstructorPattern:
+		for _, arg := range pattern.Arguments {
+			optimizePattern(arg)
+		}
+	case *ast.ArrayPattern:
+		for _, el := range pattern.Elements {
+			optimizePattern(el)
+		}
+	case *ast.HashPattern:
+		for _, v := range pattern.Values {
+			optimizePattern(v)
+		}
+	}
+}
+
+// 条件がリテラルなら評価されるblockを返す(elseがなければnil)
+// 真偽の決まり方はevaluator.isTruthyと同じ(整数や文字列は真)
+func chosenBranch(ie *ast.IfExpression) (*ast.BlockStatement, bool) {
+	switch cond := ie.Condition.(type) {
+	case *ast.Boolean:
+		if cond.Value {
+			return ie.Consequence, true
+		}
+		return ie.Alternative, true
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral:
+		return ie.Consequence, true
+	}
+	return nil, false
+}
+
+func foldPrefix(pe *ast.PrefixExpression) ast.Expression {
+	switch right := pe.Right.(type) {
+	case *ast.IntegerLiteral:
+		switch pe.Operator {
+		case "-":
+			return integerLiteral(pe.Token, -right.Value)
+		case "!":
+			return booleanLiteral(pe.Token, false)
+		}
+	case *ast.Boolean:
+		if pe.Operator == "!" {
+			return booleanLiteral(pe.Token, !right.Value)
+		}
+	}
+	return nil
+}
+
+// 畳み込めなければnil(実行時のエラーはそのまま実行時に起こす)
+func foldInfix(ie *ast.InfixExpression) ast.Expression {
+	switch left := ie.Left.(type) {
+	case *ast.IntegerLiteral:
+		right, ok := ie.Right.(*ast.IntegerLiteral)
+		if !ok {
+			return nil
+		}
+		l, r := left.Value, right.Value
+
+		switch ie.Operator {
+		case "+":
+			return integerLiteral(ie.Token, l+r)
+		case "-":
+			return integerLiteral(ie.Token, l-r)
+		case "*":
+			return integerLiteral(ie.Token, l*r)
+		case "/":
+			if r == 0 {
+				return nil
+			}
+			return integerLiteral(ie.Token, l/r)
+		case "<":
+			return booleanLiteral(ie.Token, l < r)
+		case ">":
+			return booleanLiteral(ie.Token, l > r)
+		case "==":
+			return booleanLiteral(ie.Token, l == r)
+		case "!=":
+			return booleanLiteral(ie.Token, l != r)
+		}
+	case *ast.Boolean:
+		right, ok := ie.Right.(*ast.Boolean)
+		if !ok {
+			return nil
+		}
+
+		switch ie.Operator {
+		case "==":
+			return booleanLiteral(ie.Token, left.Value == right.Value)
+		case "!=":
+			return booleanLiteral(ie.Token, left.Value != right.Value)
+		}
+	}
+	return nil
+}
+
+// 畳み込んだ結果のリテラルは元の演算子の位置に置く
+func integerLiteral(at token.Token, value int64) *ast.IntegerLiteral {
+	tok := token.Token{Type: token.INT, Literal: strconv.FormatInt(value, 10), Line: at.Line, Column: at.Column}
+	return &ast.IntegerLiteral{Token: tok, Value: value}
+}
+
+func booleanLiteral(at token.Token, value bool) *ast.Boolean {
+	tok := token.Token{Type: token.FALSE, Literal: "false", Line: at.Line, Column: at.Column}
+	if value {
+		tok.Type, tok.Literal = token.TRUE, "true"
+	}
+	return &ast.Boolean{Token: tok, Value: value}
+}
@@ -0,0 +1,112 @@
+package optimizer
+
+import (
+	"monkey/ast"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %q", input, p.Errors())
+	}
+	return program
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2 * 3", "7"},
+		{"-(2 - 5)", "3"},
+		{"!true == false", "true"},
+		{"1 < 2 != false", "true"},
+		{"!5", "false"},
+		{"x + 2 * 3", "(x + 6)"},
+		{"1 / 0", "(1 / 0)"},
+		{"true + 1", "(true + 1)"},
+		{"-true", "(-true)"},
+		{"let x = if (true) { a } else { b };", "let x = a;"},
+		{"let x = if (1 > 2) { a } else { b };", "let x = b;"},
+		{"let x = if (false) { a };", "let x = iffalse a;"},
+		{"if (true) { let a = 1; a }; 2", "let a = 1;a2"},
+		{"if (false) { a }; 2", "2"},
+		{"if (false) { a }", "iffalse a"},
+		{"if (c) { 1 + 1 } else { 2 * 2 }", "ifc 2else 4"},
+		{"let f = fn() { return 1; 2; 3 };", "let f = fn() 1;;"},
+		{"let f = fn() { if (true) { return 1; } 2 };", "let f = fn() 1;;"},
+		{"throw 1 + 1; 2", "throw 2;"},
+		{"f(1 + 1, [2 * 2], {3 - 3: true == true})", "f(2, [4], {0:true})"},
+		{"match (x) { n if 1 < 2 => n * (2 + 2) }", "match (x) { n if true => (n * 4) }"},
+	}
+
+	for _, tt := range tests {
+		program := Optimize(parse(t, tt.input))
+		if program.String() != tt.expected {
+			t.Errorf("wrong program for %q. want=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+// 最適化しても評価した結果は変わらない
+func TestOptimizePreservesResults(t *testing.T) {
+	tests := []string{
+		"1 + 2 * 3 - 4 / 2",
+		"let f = fn(x) { if (true) { x * (2 + 3) } else { 0 } }; f(2)",
+		"let f = fn() { if (true) { let y = 10; } y }; f()",
+		"let f = fn() { 1; if (true) { } }; f()",
+		"let f = fn() { 1; if (false) { 2 } }; f()",
+		"let f = fn() { return 1 + 1; 3 }; f()",
+		"let f = fn(x) { if (x > 2 * 2) { return x; } 0 }; [f(5), f(1)]",
+		"1 / 0 == 0",
+		"try { throw 2 * 3; 4 } catch (e) { e.value + 1 }",
+		"if (5) { 10 } else { 20 }",
+		"if (!!0) { 1 }",
+		"let r = fn(x) { let v = x?; if (true) { ok(v + 1) } }; [r(ok(1)), r(err(2))]",
+	}
+
+	for _, input := range tests {
+		want := evaluate(parse(t, input))
+		got := evaluate(Optimize(parse(t, input)))
+		if want != got {
+			t.Errorf("wrong result for %q. want=%q, got=%q", input, want, got)
+		}
+	}
+}
+
+// 1 / 0は畳み込まずに残し、実行したときにcatchできるerrorになる
+func TestDivisionByZeroIsNotFolded(t *testing.T) {
+	evaluated := evaluator.Eval(Optimize(parse(t, "1 / 0")), object.NewEnvironment())
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Kind != object.DIVISION_BY_ZERO_ERROR {
+		t.Fatalf("expected a DivisionByZero error. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	got := evaluate(Optimize(parse(t, `try { 1 / 0 } catch (e) { e.kind }`)))
+	if got != "DivisionByZero" {
+		t.Errorf("division by zero was not caught. got=%q", got)
+	}
+}
+
+func evaluate(program *ast.Program) string {
+	result := func() (result string) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = "panic"
+			}
+		}()
+		evaluated := evaluator.Eval(program, object.NewEnvironment())
+		if evaluated == nil {
+			return "nil"
+		}
+		return evaluated.Inspect()
+	}()
+	return result
+}
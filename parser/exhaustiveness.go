@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"fmt"
+	"monkey/ast"
+	"strings"
+)
+
+// checkExhaustiveness warns about a match expression that can fall through to NULL.
+// It only speaks up when the type of the subject is known, either from the subject
+// itself (a literal) or because all of the patterns agree on one type.
+func (p *Parser) checkExhaustiveness(me *ast.MatchExpression) {
+	for _, arm := range me.Arms {
+		if arm.Guard == nil && p.isIrrefutable(arm.Pattern) {
+			return
+		}
+	}
+
+	typ := literalType(me.Subject)
+	if typ == "" {
+		for i, arm := range me.Arms {
+			pt := p.patternType(arm.Pattern)
+			if pt == "" || (i > 0 && pt != typ) {
+				return
+			}
+			typ = pt
+		}
+	}
+
+	var missing []string
+	switch typ {
+	case "BOOLEAN":
+		missing = p.missingAlternatives(me, []string{"true", "false"})
+	case "RESULT":
+		missing = p.missingAlternatives(me, []string{"ok(_)", "err(_)"})
+	case "INTEGER", "STRING":
+		missing = []string{"_"}
+	default:
+		variants, ok := p.enums[typ]
+		if !ok {
+			return
+		}
+		missing = p.missingAlternatives(me, variants)
+	}
+
+	if len(missing) == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("%d:%d: non-exhaustive match on %s: %s not covered",
+		me.Token.Line, me.Token.Column, typ, strings.Join(missing, ", "))
+	p.warnings = append(p.warnings, msg)
+}
+
+// 何にでもマッチするpattern(_ や x)
+// enumのvariant名(Emptyなど)は変数ではなくそのvariantとの比較になる
+func (p *Parser) isIrrefutable(pattern ast.Pattern) bool {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return true
+	case *ast.BindingPattern:
+		_, isVariant := p.variantEnums[pattern.Name.Value]
+		return !isVariant
+	case *ast.AlternativePattern:
+		for _, a := range pattern.Alternatives {
+			if p.isIrrefutable(a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func literalType(exp ast.Expression) string {
+	switch exp.(type) {
+	case *ast.IntegerLiteral:
+		return "INTEGER"
+	case *ast.StringLiteral:
+		return "STRING"
+	case *ast.Boolean:
+		return "BOOLEAN"
+	}
+	return ""
+}
+
+// patternがマッチする値の型(分からなければ"")
+func (p *Parser) patternType(pattern ast.Pattern) string {
+	switch pattern := pattern.(type) {
+	case *ast.LiteralPattern:
+		return literalType(pattern.Value)
+	case *ast.BindingPattern:
+		return p.variantEnums[pattern.Name.Value]
+	case *ast.ConstructorPattern:
+		if pattern.Name.Value == "ok" || pattern.Name.Value == "err" {
+			return "RESULT"
+		}
+		return p.variantEnums[pattern.Name.Value]
+	case *ast.AlternativePattern:
+		typ := ""
+		for i, a := range pattern.Alternatives {
+			at := p.patternType(a)
+			if at == "" || (i > 0 && at != typ) {
+				return ""
+			}
+			typ = at
+		}
+		return typ
+	}
+	return ""
+}
+
+// cases(true, false や ok(_), err(_)、enumのvariant名)のうちguardのないarmで網羅されていないもの
+func (p *Parser) missingAlternatives(me *ast.MatchExpression, cases []string) []string {
+	covered := map[string]bool{}
+	for _, arm := range me.Arms {
+		if arm.Guard == nil {
+			p.coverPattern(arm.Pattern, covered)
+		}
+	}
+
+	missing := []string{}
+	for _, c := range cases {
+		if !covered[c] {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+func (p *Parser) coverPattern(pattern ast.Pattern, covered map[string]bool) {
+	switch pattern := pattern.(type) {
+	case *ast.LiteralPattern:
+		covered[pattern.String()] = true
+	case *ast.BindingPattern:
+		covered[pattern.Name.Value] = true
+	case *ast.ConstructorPattern:
+		for _, arg := range pattern.Arguments {
+			if !p.isIrrefutable(arg) {
+				return
+			}
+		}
+		if _, isVariant := p.variantEnums[pattern.Name.Value]; isVariant {
+			covered[pattern.Name.Value] = true
+		} else {
+			covered[pattern.Name.Value+"(_)"] = true
+		}
+	case *ast.AlternativePattern:
+		for _, a := range pattern.Alternatives {
+			p.coverPattern(a, covered)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"monkey/lexer"
 	"monkey/token"
 	"strconv"
+	"unicode"
 )
 
 // priority of parser
@@ -17,7 +18,9 @@ const ( // LOWER
 	SUM          // +
 	PRODUCT      // *
 	PREFIX       // -X or !X
+	POSTFIX      // X?
 	CALL         // myFunction(X)
+	INDEX        // array[index]
 ) // HIGHER
 
 // mapping of token and priority
@@ -30,16 +33,23 @@ var precedences = map[token.TokenType]int{
 	token.MINUS:     SUM,
 	token.SLASH:     PRODUCT,
 	token.ASTERRISK: PRODUCT,
+	token.QUESTION:  POSTFIX,
 	token.LPAREN:    CALL,
+	token.LBRACKET:  INDEX,
+	token.DOT:       INDEX,
 }
 
 type Parser struct {
 	l              *lexer.Lexer // pointer of Lexer instance
 	errors         []string
+	warnings       []string                          // 実行はできるが怪しいもの(網羅されていないmatchなど)
+	enums          map[string][]string               // これまでに宣言されたenumのvariant名(網羅性の確認に使う)
+	variantEnums   map[string]string                 // variant名 -> enum名
 	curToken       token.Token                       // 現在調べているtoken
 	peekToken      token.Token                       // 次のtokenを確認する用
 	prefixParseFns map[token.TokenType]prefixParseFn // tokenと関数をmappingする
 	infixParseFns  map[token.TokenType]infixParseFn
+	inGenerator    bool // fn*の本体の中(その中のfnも含む)ならyieldを書ける
 }
 
 type (
@@ -67,8 +77,11 @@ type (
 //	}
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
-		l:      l,
-		errors: []string{},
+		l:            l,
+		errors:       []string{},
+		warnings:     []string{},
+		enums:        map[string][]string{},
+		variantEnums: map[string]string{},
 	}
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
@@ -76,11 +89,21 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.TRUE, p.parserBoolean)
 	p.registerPrefix(token.FALSE, p.parserBoolean)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.TRY, p.parseTryExpression)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+	p.registerPrefix(token.SELECT, p.parseSelectExpression)
+	p.registerPrefix(token.YIELD, p.parseYieldExpression)
+	p.registerPrefix(token.ASYNC, p.parseAsyncFunctionLiteral)
+	p.registerPrefix(token.AWAIT, p.parseAwaitExpression)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -91,7 +114,10 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.QUESTION, p.parsePostfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseFieldExpression)
 
 	// 2つトークンを読み込む -> curTokenとpeekTokenの両方がセットされる
 	p.nextToken()
@@ -104,6 +130,10 @@ func (p *Parser) Errors() []string {
 	return p.errors
 }
 
+func (p *Parser) Warnings() []string {
+	return p.warnings
+}
+
 func (p *Parser) peekError(t token.TokenType) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
 	p.errors = append(p.errors, msg)
@@ -148,6 +178,14 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.THROW:
+		return p.parseThrowStatement()
+	case token.STRUCT:
+		return p.parseStructStatement()
+	case token.IMPL:
+		return p.parseImplStatement()
+	case token.ENUM:
+		return p.parseEnumStatement()
 	default:
 		// when match neither 'let' nor 'return'(like token.INT)
 		return p.parseExpressionStatement()
@@ -156,13 +194,33 @@ func (p *Parser) parseStatement() ast.Statement {
 
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.curToken}
-	// letの次のTokenが識別子(token.IDENT)でなかった場合はDobon
-	if !p.expectPeek(token.IDENT) {
-		return nil
-	}
 
-	// expectPeekではnextTokenが呼ばれているため、token sequenceのindexはインクリメントされている
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	// let [a, b] = ... や let {name} = ... は分割代入
+	if p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		stmt.Pattern = p.parsePrimaryPattern()
+		if stmt.Pattern == nil {
+			return nil
+		}
+	} else {
+		// letの次のTokenが識別子(token.IDENT)でなかった場合はDobon
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		// expectPeekではnextTokenが呼ばれているため、token sequenceのindexはインクリメントされている
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+		// let x: int = ... の型注釈
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			p.nextToken()
+			stmt.Name.Type = p.parseTypeExpression()
+			if stmt.Name.Type == nil {
+				return nil
+			}
+		}
+	}
 
 	// 識別子が確認できたら次のtokenが「=」であるか判定する
 	if !p.expectPeek(token.ASSIGN) {
@@ -173,6 +231,11 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	stmt.Value = p.parseExpression(LOWEST)
 
+	// 関数に名前をつけておく(stack traceで使う)
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok && stmt.Name != nil {
+		fl.Name = stmt.Name.Value
+	}
+
 	for p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -186,18 +249,216 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	// `fn() { return 1 }` のように;がないこともある
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// struct Point { x, y }
+func (p *Parser) parseStructStatement() *ast.StructStatement {
+	stmt := &ast.StructStatement{Token: p.curToken, Fields: []*ast.Identifier{}}
+
+	if !p.expectTypeName() {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Fields = append(stmt.Fields, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// enum Shape { Circle(r), Rect(w, h), Empty }
+func (p *Parser) parseEnumStatement() *ast.EnumStatement {
+	stmt := &ast.EnumStatement{Token: p.curToken, Variants: []*ast.EnumVariant{}}
+
+	if !p.expectTypeName() {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		if !p.expectTypeName() {
+			return nil
+		}
+		variant := &ast.EnumVariant{
+			Name:   &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+			Fields: []*ast.Identifier{},
+		}
+
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			variant.Fields = p.parseFunctionParameters()
+			if variant.Fields == nil {
+				return nil
+			}
+		}
+		stmt.Variants = append(stmt.Variants, variant)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	names := []string{}
+	for _, v := range stmt.Variants {
+		names = append(names, v.Name.Value)
+		p.variantEnums[v.Name.Value] = stmt.Name.Value
+	}
+	p.enums[stmt.Name.Value] = names
+
+	return stmt
+}
+
+// impl Point { fn len(self) { ... } fn scale(self, n) { ... } }
+func (p *Parser) parseImplStatement() *ast.ImplStatement {
+	stmt := &ast.ImplStatement{Token: p.curToken, Methods: []*ast.FunctionLiteral{}}
+
+	if !p.expectTypeName() {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		if !p.expectPeek(token.FUNCTION) {
+			return nil
+		}
+		method := &ast.FunctionLiteral{Token: p.curToken}
+
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		method.Name = p.curToken.Literal
+
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+		method.Parameters = p.parseFunctionParameters()
+		if method.Parameters == nil {
+			return nil
+		}
+
+		if !p.parseReturnType(method) {
+			return nil
+		}
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		method.Body = p.parseBlockStatement()
+
+		stmt.Methods = append(stmt.Methods, method)
+
+		// methodの区切りの「;」や「,」はあってもなくてもよい
+		if p.peekTokenIs(token.SEMICOLON) || p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
 	return stmt
 }
 
-func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+// 型の名前は大文字で始まる(Point { x: 1 } をblockと区別するため)
+func (p *Parser) expectTypeName() bool {
+	if !p.expectPeek(token.IDENT) {
+		return false
+	}
+	if !isTypeName(p.curToken.Literal) {
+		msg := fmt.Sprintf("type name must start with an uppercase letter, got %q", p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return false
+	}
+	return true
+}
+
+func isTypeName(name string) bool {
+	return unicode.IsUpper(rune(name[0]))
+}
+
+func (p *Parser) parseExpressionStatement() ast.Statement {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 
 	stmt.Expression = p.parseExpression(LOWEST)
 
+	// obj.field = value;
+	if fe, ok := stmt.Expression.(*ast.FieldExpression); ok && p.peekTokenIs(token.ASSIGN) {
+		return p.parseAssignStatement(fe)
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseAssignStatement(target *ast.FieldExpression) *ast.AssignStatement {
+	p.nextToken()
+	stmt := &ast.AssignStatement{Token: p.curToken, Target: target}
+
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -247,7 +508,47 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 }
 
 func (p *Parser) parseIdentifier() ast.Expression {
-	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	// Point { x: 1, y: 2 }
+	if isTypeName(ident.Value) && p.peekTokenIs(token.LBRACE) {
+		return p.parseStructLiteral(ident)
+	}
+
+	return ident
+}
+
+// Point { x: 1, y: 2 } ({ x, y } は { x: x, y: y } の省略形)
+func (p *Parser) parseStructLiteral(name *ast.Identifier) ast.Expression {
+	p.nextToken()
+	lit := &ast.StructLiteral{Token: p.curToken, Name: name, Fields: []*ast.Identifier{}, Values: []ast.Expression{}}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		field := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+		var value ast.Expression = field
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			p.nextToken()
+			value = p.parseExpression(LOWEST)
+		}
+
+		lit.Fields = append(lit.Fields, field)
+		lit.Values = append(lit.Values, value)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return lit
 }
 
 func (p *Parser) parserBoolean() ast.Expression {
@@ -267,6 +568,22 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	lit.Value = value
+	return lit
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+}
+
 // this function is for 「!」 or 「-」
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
@@ -294,6 +611,15 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+// 右側はないのでtokenを進めない
+func (p *Parser) parsePostfixExpression(left ast.Expression) ast.Expression {
+	return &ast.PostfixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+}
+
 // @params ast.Expression && ast.Identifier
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
@@ -302,27 +628,93 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 }
 
 func (p *Parser) parseCallArguments() []ast.Expression {
-	args := []ast.Expression{}
+	return p.parseExpressionList(token.RPAREN)
+}
 
-	if p.peekTokenIs(token.RPAREN) {
+// 「,」区切りの式をendまで読む(関数の引数や配列の要素)
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	if p.peekTokenIs(end) {
 		p.nextToken()
-		return args
+		return list
 	}
 
 	p.nextToken()
-	args = append(args, p.parseExpression(LOWEST))
+	list = append(list, p.parseExpression(LOWEST))
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		args = append(args, p.parseExpression(LOWEST))
+		list = append(list, p.parseExpression(LOWEST))
 	}
 
-	if !p.expectPeek(token.RPAREN) {
+	if !p.expectPeek(end) {
 		return nil
 	}
 
-	return args
+	return list
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+	array.Elements = p.parseExpressionList(token.RBRACKET)
+	return array
+}
+
+// {key: value, key: value, ...}
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+
+		hash.Keys = append(hash.Keys, key)
+		hash.Values = append(hash.Values, value)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return hash
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+
+	p.nextToken()
+	exp.Index = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return exp
+}
+
+// left.field
+func (p *Parser) parseFieldExpression(left ast.Expression) ast.Expression {
+	exp := &ast.FieldExpression{Token: p.curToken, Left: left}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Field = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
 }
 
 func (p *Parser) parseGroupedExpression() ast.Expression {
@@ -399,41 +791,564 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
-func (p *Parser) parseFunctionLiteral() ast.Expression {
-	lit := &ast.FunctionLiteral{Token: p.curToken}
-
-	if !p.expectPeek(token.LPAREN) {
-		return nil
-	}
-
-	lit.Parameters = p.parseFunctionParameters()
+// try { ... } catch (e) { ... } finally { ... }
+// catchとfinallyはどちらか片方だけでもよい
+func (p *Parser) parseTryExpression() ast.Expression {
+	expression := &ast.TryExpression{Token: p.curToken}
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
+	expression.Block = p.parseBlockStatement()
 
-	lit.Body = p.parseBlockStatement()
+	if p.peekTokenIs(token.CATCH) {
+		p.nextToken()
 
-	return lit
-}
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		expression.CatchParameter = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if !p.expectPeek(token.RPAREN) {
+			return nil
+		}
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		expression.Catch = p.parseBlockStatement()
+	}
 
-	if p.peekTokenIs(token.RPAREN) {
+	if p.peekTokenIs(token.FINALLY) {
 		p.nextToken()
-		return identifiers
-	}
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		expression.Finally = p.parseBlockStatement()
+	}
+
+	if expression.Catch == nil && expression.Finally == nil {
+		msg := fmt.Sprintf("expected catch or finally after try block, got %s instead", p.peekToken.Type)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	return expression
+}
+
+// match (subject) { pattern => expression, pattern if guard => { block }, ... }
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
 
 	p.nextToken()
+	expression.Subject = p.parseExpression(LOWEST)
 
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		expression.Arms = append(expression.Arms, arm)
+
+		// armの区切りの「,」(最後のarmの後ろにあってもよい)
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	p.checkExhaustiveness(expression)
+
+	return expression
+}
+
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{}
+
+	arm.Pattern = p.parsePattern()
+	if arm.Pattern == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+		p.nextToken()
+		arm.Guard = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.FAT_ARROW) {
+		return nil
+	}
+	arm.Token = p.curToken
+	arm.Body = p.parseArmBody()
+
+	return arm
+}
+
+// => { ... } はblock、それ以外は式1つだけのblockとして扱う
+// curTokenは=>
+func (p *Parser) parseArmBody() *ast.BlockStatement {
+	arrow := p.curToken
+
+	if p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		return p.parseBlockStatement()
+	}
+
+	p.nextToken()
+	stmt := &ast.ExpressionStatement{Token: p.curToken}
+	stmt.Expression = p.parseExpression(LOWEST)
+	return &ast.BlockStatement{Token: arrow, Statements: []ast.Statement{stmt}}
+}
+
+// select { v = recv(a) => ..., send(b, 1) => ..., _ => ... }
+func (p *Parser) parseSelectExpression() ast.Expression {
+	expression := &ast.SelectExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	hasDefault := false
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		c := p.parseSelectCase()
+		if c == nil {
+			return nil
+		}
+		if c.IsDefault() {
+			if hasDefault {
+				p.errors = append(p.errors, "select has more than one default case")
+				return nil
+			}
+			hasDefault = true
+		}
+		expression.Cases = append(expression.Cases, c)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return expression
+}
+
+func (p *Parser) parseSelectCase() *ast.SelectCase {
+	c := &ast.SelectCase{}
+
+	// name = recv(ch)
+	if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.ASSIGN) {
+		c.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		p.nextToken()
+		p.nextToken()
+		if !p.curTokenIs(token.IDENT) || p.curToken.Literal != "recv" {
+			msg := fmt.Sprintf("expected recv(...) after %s =, got %s instead", c.Name.Value, p.curToken.Literal)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+	}
+
+	switch {
+	case p.curTokenIs(token.IDENT) && p.curToken.Literal == "_":
+	case p.curTokenIs(token.IDENT) && p.curToken.Literal == "recv":
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+		p.nextToken()
+		c.Channel = p.parseExpression(LOWEST)
+		if !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+	case p.curTokenIs(token.IDENT) && p.curToken.Literal == "send":
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+		p.nextToken()
+		c.Channel = p.parseExpression(LOWEST)
+		if !p.expectPeek(token.COMMA) {
+			return nil
+		}
+		p.nextToken()
+		c.Value = p.parseExpression(LOWEST)
+		if !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+	default:
+		msg := fmt.Sprintf("expected recv, send or _ in select, got %s instead", p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	if !p.expectPeek(token.FAT_ARROW) {
+		return nil
+	}
+	c.Token = p.curToken
+	c.Body = p.parseArmBody()
+
+	return c
+}
+
+// [parse*Pattern]
+// pattern | pattern | ...
+func (p *Parser) parsePattern() ast.Pattern {
+	pattern := p.parsePrimaryPattern()
+	if pattern == nil || !p.peekTokenIs(token.PIPE) {
+		return pattern
+	}
+
+	alternative := &ast.AlternativePattern{Token: p.peekToken, Alternatives: []ast.Pattern{pattern}}
+	for p.peekTokenIs(token.PIPE) {
+		p.nextToken()
+		p.nextToken()
+
+		pattern := p.parsePrimaryPattern()
+		if pattern == nil {
+			return nil
+		}
+		alternative.Alternatives = append(alternative.Alternatives, pattern)
+	}
+
+	return alternative
+}
+
+func (p *Parser) parsePrimaryPattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.INT:
+		return p.parseLiteralPattern(p.parseIntegerLiteral())
+	case token.MINUS:
+		// -1 (負の整数リテラル)
+		minus := p.curToken
+		if !p.expectPeek(token.INT) {
+			return nil
+		}
+		lit, ok := p.parseIntegerLiteral().(*ast.IntegerLiteral)
+		if !ok {
+			return nil
+		}
+		lit.Value = -lit.Value
+		return &ast.LiteralPattern{Token: minus, Value: lit}
+	case token.STRING:
+		return p.parseLiteralPattern(p.parseStringLiteral())
+	case token.TRUE, token.FALSE:
+		return p.parseLiteralPattern(p.parserBoolean())
+	case token.IDENT:
+		if p.curToken.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if p.peekTokenIs(token.LPAREN) {
+			return p.parseConstructorPattern(ident)
+		}
+		return &ast.BindingPattern{Token: p.curToken, Name: ident}
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	default:
+		msg := fmt.Sprintf("unexpected %s in pattern", p.curToken.Type)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+}
+
+// [pattern, pattern = default, ...rest]
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken, Elements: []ast.Pattern{}}
+
+	for !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+
+		// ...restは最後の要素にだけ書ける
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			pattern.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			break
+		}
+
+		element := p.parsePatternWithDefault()
+		if element == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, element)
+
+		if !p.peekTokenIs(token.RBRACKET) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return pattern
+}
+
+// {name, age: years, "first-name": first = "anonymous"}
+func (p *Parser) parseHashPattern() ast.Pattern {
+	pattern := &ast.HashPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		if !p.curTokenIs(token.IDENT) && !p.curTokenIs(token.STRING) {
+			msg := fmt.Sprintf("expected key in hash pattern, got %s instead", p.curToken.Type)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		key := p.curToken
+
+		var value ast.Pattern
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			p.nextToken()
+			value = p.parsePattern()
+		} else if key.Type == token.IDENT {
+			// {name} は {name: name} の省略形
+			value = &ast.BindingPattern{Token: key, Name: &ast.Identifier{Token: key, Value: key.Literal}}
+		} else {
+			p.peekError(token.COLON)
+			return nil
+		}
+		if value == nil {
+			return nil
+		}
+
+		value = p.parseDefault(value)
+		if value == nil {
+			return nil
+		}
+
+		pattern.Keys = append(pattern.Keys, key.Literal)
+		pattern.Values = append(pattern.Values, value)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return pattern
+}
+
+func (p *Parser) parsePatternWithDefault() ast.Pattern {
+	pattern := p.parsePattern()
+	if pattern == nil {
+		return nil
+	}
+	return p.parseDefault(pattern)
+}
+
+// pattern = default
+func (p *Parser) parseDefault(pattern ast.Pattern) ast.Pattern {
+	if !p.peekTokenIs(token.ASSIGN) {
+		return pattern
+	}
+	p.nextToken()
+
+	dp := &ast.DefaultPattern{Token: p.curToken, Pattern: pattern}
+	p.nextToken()
+	dp.Default = p.parseExpression(LOWEST)
+	if dp.Default == nil {
+		return nil
+	}
+
+	return dp
+}
+
+func (p *Parser) parseLiteralPattern(value ast.Expression) ast.Pattern {
+	if value == nil {
+		return nil
+	}
+	return &ast.LiteralPattern{Token: p.curToken, Value: value}
+}
+
+// name(pattern, pattern, ...)
+func (p *Parser) parseConstructorPattern(name *ast.Identifier) ast.Pattern {
+	pattern := &ast.ConstructorPattern{Token: p.curToken, Name: name, Arguments: []ast.Pattern{}}
+	p.nextToken()
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return pattern
+	}
+
+	p.nextToken()
+	arg := p.parsePattern()
+	if arg == nil {
+		return nil
+	}
+	pattern.Arguments = append(pattern.Arguments, arg)
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		arg := p.parsePattern()
+		if arg == nil {
+			return nil
+		}
+		pattern.Arguments = append(pattern.Arguments, arg)
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return pattern
+}
+
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+
+	// fn*(...) { ... } はgenerator
+	if p.peekTokenIs(token.ASTERRISK) {
+		p.nextToken()
+		lit.Generator = true
+	}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	lit.Parameters = p.parseFunctionParameters()
+	if lit.Parameters == nil {
+		return nil
+	}
+
+	if !p.parseReturnType(lit) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	saved := p.inGenerator
+	p.inGenerator = saved || lit.Generator
+	lit.Body = p.parseBlockStatement()
+	p.inGenerator = saved
+
+	return lit
+}
+
+// async fn(...) { ... }
+func (p *Parser) parseAsyncFunctionLiteral() ast.Expression {
+	if !p.expectPeek(token.FUNCTION) {
+		return nil
+	}
+
+	lit, ok := p.parseFunctionLiteral().(*ast.FunctionLiteral)
+	if !ok {
+		return nil
+	}
+	if lit.Generator {
+		p.errors = append(p.errors, "async generator functions are not supported")
+		return nil
+	}
+	lit.Async = true
+
+	return lit
+}
+
+// await promise (await f() + 1 は (await f()) + 1)
+func (p *Parser) parseAwaitExpression() ast.Expression {
+	expression := &ast.AwaitExpression{Token: p.curToken}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(PREFIX)
+	if expression.Value == nil {
+		return nil
+	}
+
+	return expression
+}
+
+// yield value / yield
+func (p *Parser) parseYieldExpression() ast.Expression {
+	expression := &ast.YieldExpression{Token: p.curToken}
+
+	if !p.inGenerator {
+		p.errors = append(p.errors, "yield outside of a generator function")
+		return nil
+	}
+
+	switch p.peekToken.Type {
+	case token.SEMICOLON, token.RBRACE, token.RPAREN, token.RBRACKET, token.COMMA, token.EOF:
+		return expression
+	}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(LOWEST)
+
+	return expression
+}
+
+// fn(...) -> int の戻り値の型注釈(省略可)
+func (p *Parser) parseReturnType(lit *ast.FunctionLiteral) bool {
+	if !p.peekTokenIs(token.ARROW) {
+		return true
+	}
+	p.nextToken()
+	p.nextToken()
+
+	lit.ReturnType = p.parseTypeExpression()
+	return lit.ReturnType != nil
+}
+
+func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+	identifiers := []*ast.Identifier{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return identifiers
+	}
+
+	p.nextToken()
+
+	ident := p.parseFunctionParameter()
+	if ident == nil {
+		return nil
+	}
 	identifiers = append(identifiers, ident)
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		ident := p.parseFunctionParameter()
+		if ident == nil {
+			return nil
+		}
 		identifiers = append(identifiers, ident)
 	}
 
@@ -444,6 +1359,65 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 	return identifiers
 }
 
+// a または a: int
+func (p *Parser) parseFunctionParameter() *ast.Identifier {
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		p.nextToken()
+		ident.Type = p.parseTypeExpression()
+		if ident.Type == nil {
+			return nil
+		}
+	}
+
+	return ident
+}
+
+// int, Point, fn(int, bool) -> int
+func (p *Parser) parseTypeExpression() *ast.TypeExpression {
+	te := &ast.TypeExpression{Token: p.curToken, Name: p.curToken.Literal}
+
+	switch p.curToken.Type {
+	case token.IDENT:
+		return te
+	case token.FUNCTION:
+		te.Parameters = []*ast.TypeExpression{}
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+
+		for !p.peekTokenIs(token.RPAREN) {
+			p.nextToken()
+			param := p.parseTypeExpression()
+			if param == nil {
+				return nil
+			}
+			te.Parameters = append(te.Parameters, param)
+
+			if !p.peekTokenIs(token.RPAREN) && !p.expectPeek(token.COMMA) {
+				return nil
+			}
+		}
+		p.nextToken()
+
+		if p.peekTokenIs(token.ARROW) {
+			p.nextToken()
+			p.nextToken()
+			te.Return = p.parseTypeExpression()
+			if te.Return == nil {
+				return nil
+			}
+		}
+		return te
+	default:
+		msg := fmt.Sprintf("expected type, got %s instead", p.curToken.Type)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+}
+
 // [HELPER]
 // 引数に渡されたtokenとcurTokenが一致しているか判定する
 func (p *Parser) curTokenIs(t token.TokenType) bool {
@@ -119,6 +119,28 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	testLiteralExpression(t, stmt.Expression, 5)
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	input := "2.5;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	testStatementStructure(t, program)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != 2.5 {
+		t.Errorf("literal.Value not %g. got=%g", 2.5, literal.Value)
+	}
+	if literal.String() != "2.5" {
+		t.Errorf("literal.String not %q. got=%q", "2.5", literal.String())
+	}
+}
+
 func TestBooleanExpression(t *testing.T) {
 	input := "true;"
 
@@ -512,6 +534,22 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a + b + c * d / f + g)",
 			"add((((a + b) + ((c * d) / f)) + g))",
 		},
+		{
+			"a * b[1] * c",
+			"((a * (b[1])) * c)",
+		},
+		{
+			"a + f(b)? * 2",
+			"(a + ((f(b)?) * 2))",
+		},
+		{
+			"-a?",
+			"(-(a?))",
+		},
+		{
+			"e[\"k\"]?",
+			"((e[k])?)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -685,3 +723,737 @@ func testStatementStructure(t *testing.T, program *ast.Program) bool {
 
 	return true
 }
+
+func TestFunctionLiteralWithName(t *testing.T) {
+	input := `let myFunction = fn() { };`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.LetStatement. got=%T", program.Statements[0])
+	}
+
+	function, ok := stmt.Value.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("stmt.Value is not ast.FunctionLiteral. got=%T", stmt.Value)
+	}
+
+	if function.Name != "myFunction" {
+		t.Fatalf("function literal name wrong. want 'myFunction', got=%q\n", function.Name)
+	}
+}
+
+func TestGeneratorFunctionLiteral(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let gen = fn*(n) { yield n; yield n + 1; }`, `let gen = fn*(n) yield nyield (n + 1);`},
+		{`fn*() { let x = yield; x }`, `fn*() let x = yield;x`},
+		{`fn*() { let f = fn(i) { yield i * 2 }; f(1) }`, `fn*() let f = fn(i) yield (i * 2);f(1)`},
+		{`fn*() { [yield 1, yield] }`, `fn*() [yield 1, yield]`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestYieldOutsideGenerator(t *testing.T) {
+	tests := []string{
+		`yield 1`,
+		`fn() { yield 1 }`,
+		`let g = fn*() { 1 }; yield 2`,
+	}
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", input)
+			continue
+		}
+		if errors[0] != "yield outside of a generator function" {
+			t.Errorf("wrong error for %q. got=%q", input, errors[0])
+		}
+	}
+}
+
+func TestAsyncFunctionLiteral(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let f = async fn(x) { await g(x) + 1 }`, `let f = async fn(x) ((await g(x)) + 1);`},
+		{`async fn() { await -x }`, `async fn() (await (-x))`},
+		{`await f()`, `(await f())`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestAsyncFunctionLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`async fn*() { yield 1 }`, "async generator functions are not supported"},
+		{`async 1`, "expected next token to be FUNCTION, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello world";`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.StringLiteral. got=%T", stmt.Expression)
+	}
+
+	if literal.Value != "hello world" {
+		t.Errorf("literal.Value not %q. got=%q", "hello world", literal.Value)
+	}
+}
+
+func TestParsingIndexExpressions(t *testing.T) {
+	input := `e["message"]`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+	indexExp, ok := stmt.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.IndexExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, indexExp.Left, "e") {
+		return
+	}
+
+	if indexExp.Index.String() != "message" {
+		t.Errorf("indexExp.Index wrong. got=%q", indexExp.Index.String())
+	}
+}
+
+func TestTryExpression(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedParam   string
+		expectedCatch   bool
+		expectedFinally bool
+	}{
+		{"try { x } catch (e) { y }", "e", true, false},
+		{"try { x } finally { y }", "", false, true},
+		{"try { x } catch (err) { y } finally { z }", "err", true, true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+		}
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.TryExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.TryExpression. got=%T", stmt.Expression)
+		}
+
+		if len(exp.Block.Statements) != 1 {
+			t.Errorf("try block is not 1 statements. got=%d", len(exp.Block.Statements))
+		}
+
+		if (exp.Catch != nil) != tt.expectedCatch {
+			t.Errorf("exp.Catch wrong. want present=%t, got=%+v", tt.expectedCatch, exp.Catch)
+		}
+		if tt.expectedCatch && !testIdentifier(t, exp.CatchParameter, tt.expectedParam) {
+			return
+		}
+
+		if (exp.Finally != nil) != tt.expectedFinally {
+			t.Errorf("exp.Finally wrong. want present=%t, got=%+v", tt.expectedFinally, exp.Finally)
+		}
+	}
+}
+
+func TestTryWithoutHandlerIsError(t *testing.T) {
+	l := lexer.New("try { x }")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("expected parser error for try without catch or finally")
+	}
+	if errors[0] != "expected catch or finally after try block, got EOF instead" {
+		t.Errorf("wrong error message. got=%q", errors[0])
+	}
+}
+
+func TestThrowStatement(t *testing.T) {
+	input := `throw "boom";`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ThrowStatement)
+	if !ok {
+		t.Fatalf("stmt not *ast.ThrowStatement. got=%T", program.Statements[0])
+	}
+	if stmt.String() != "throw boom;" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`match (x) { 1 => "one", _ => "other" }`,
+			`match (x) { 1 => one, _ => other }`,
+		},
+		{
+			`match (x) { -1 | 0 | 1 => small, n if n > 1 => { n * 2 }, }`,
+			`match (x) { -1 | 0 | 1 => small, n if (n > 1) => (n * 2) }`,
+		},
+		{
+			`match (f(x)) { ok(v) => v, err(_) => 0 }`,
+			`match (f(x)) { ok(v) => v, err(_) => 0 }`,
+		},
+		{
+			`match (s) { "a" | "b" => true }`,
+			`match (s) { "a" | "b" => true }`,
+		},
+		{
+			`match (x) { }`,
+			`match (x) {  }`,
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+		}
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.MatchExpression); !ok {
+			t.Fatalf("stmt.Expression is not ast.MatchExpression. got=%T", stmt.Expression)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestMatchPatternErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`match (x) { + => 1 }`, "unexpected + in pattern"},
+		{`match (x) { 1 2 }`, "expected next token to be =>, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestMatchExhaustivenessWarnings(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedWarning string
+	}{
+		{`match (x) { true => 1 }`, "1:1: non-exhaustive match on BOOLEAN: false not covered"},
+		{`match (x) { true => 1, false => 2 }`, ""},
+		{`match (x) { true | false => 1 }`, ""},
+		{`match (x) { true => 1, false if y => 2 }`, "1:1: non-exhaustive match on BOOLEAN: false not covered"},
+		{`match (x) { ok(1) => 1, err(_) => 2 }`, "1:1: non-exhaustive match on RESULT: ok(_) not covered"},
+		{`match (x) { ok(v) => 1, err(e) => 2 }`, ""},
+		{`match (5) { 1 => 1 }`, "1:1: non-exhaustive match on INTEGER: _ not covered"},
+		{`match (x) { "a" => 1, "b" => 2 }`, "1:1: non-exhaustive match on STRING: _ not covered"},
+		{`match (x) { 1 => 1, _ => 2 }`, ""},
+		{`match (x) { 1 => 1, "a" => 2 }`, ""},
+		{`match (x) { 1 => 1, n => 2 }`, ""},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		checkParserErrors(t, p)
+
+		warnings := p.Warnings()
+		if tt.expectedWarning == "" {
+			if len(warnings) != 0 {
+				t.Errorf("unexpected warnings for %q: %q", tt.input, warnings)
+			}
+			continue
+		}
+		if len(warnings) != 1 || warnings[0] != tt.expectedWarning {
+			t.Errorf("wrong warnings for %q. want=%q, got=%q", tt.input, tt.expectedWarning, warnings)
+		}
+	}
+}
+
+func TestSelectExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`select { v = recv(ch) => v, send(out, 1 + 2) => { 0 }, recv(done) => 1, _ => null }`,
+			`select { v = recv(ch) => v, send(out, (1 + 2)) => 0, recv(done) => 1, _ => null }`,
+		},
+		{
+			`select { recv(spawn(f)) => 1, }`,
+			`select { recv(spawn(f)) => 1 }`,
+		},
+		{
+			`select { }`,
+			`select {  }`,
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+		}
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.SelectExpression); !ok {
+			t.Fatalf("stmt.Expression is not ast.SelectExpression. got=%T", stmt.Expression)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`select { _ => 1, _ => 2 }`, "select has more than one default case"},
+		{`select { v = send(a, 1) => 1 }`, "expected recv(...) after v =, got send instead"},
+		{`select { 1 => 2 }`, "expected recv, send or _ in select, got 1 instead"},
+		{`select { send(a) => 2 }`, "expected next token to be ,, got ) instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error. want=%q, got=%q", tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestParsingArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.ArrayLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(array.Elements) != 3 {
+		t.Fatalf("len(array.Elements) not 3. got=%d", len(array.Elements))
+	}
+
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{}`, `{}`},
+		{`{"one": 1, "two": 2}`, `{one:1, two:2}`},
+		{`{"one": 0 + 1, true: 10 - 8}`, `{one:(0 + 1), true:(10 - 8)}`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.HashLiteral); !ok {
+			t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestDestructuringLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = xs;", "let [a, b] = xs;"},
+		{"let [a, ...rest] = xs;", "let [a, ...rest] = xs;"},
+		{"let [] = xs;", "let [] = xs;"},
+		{"let [x = 0, [y, _]] = ys;", "let [x = 0, [y, _]] = ys;"},
+		{"let {name, age: years} = person;", "let {name: name, age: years} = person;"},
+		{`let {"first-name": first = "anon", pos: [x, y]} = p;`, "let {first-name: first = anon, pos: [x, y]} = p;"},
+		{"let {a: {b}} = c;", "let {a: {b: b}} = c;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("stmt not *ast.LetStatement. got=%T", program.Statements[0])
+		}
+		if stmt.Pattern == nil || stmt.Name != nil {
+			t.Fatalf("destructuring let should have Pattern and no Name. got=%+v", stmt)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestDestructuringPatternErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"let [a b] = xs;", "expected next token to be ,, got IDENT instead"},
+		{"let [...rest, a] = xs;", "expected next token to be ], got , instead"},
+		{"let {1: a} = h;", "expected key in hash pattern, got INT instead"},
+		{`let {"a"} = h;`, "expected next token to be :, got } instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestStructDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct Point { x, y }", "struct Point { x, y }"},
+		{"struct Empty {}", "struct Empty {  }"},
+		{"impl Point { fn len(self) { self.x } }", "impl Point { fn(self) (self.x) }"},
+		{"impl Point { fn a(self) { 1 }; fn b(self, n) { n } }", "impl Point { fn(self) 1 fn(self,n) n }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestStructLiteralAndFieldAccess(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Point { x: 1, y: 2 }", "Point { x: 1, y: 2 }"},
+		{"Point { x, y: y + 1 }", "Point { x: x, y: (y + 1) }"},
+		{"p.x + p.y * 2", "((p.x) + ((p.y) * 2))"},
+		{"-p.x", "(-(p.x))"},
+		{"p.len() + 1", "((p.len)() + 1)"},
+		{"a.b.c", "((a.b).c)"},
+		{"Point { x: 1 }.x", "(Point { x: 1 }.x)"},
+		{"if (x) { y }", "ifx y"},
+		{"p.x = p.x + 1;", "(p.x) = ((p.x) + 1);"},
+		{"a.b.c = f(1)", "((a.b).c) = f(1);"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestStructParseErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"struct point { x }", `type name must start with an uppercase letter, got "point"`},
+		{"struct Point { 1 }", "expected next token to be IDENT, got INT instead"},
+		{"impl Point { len(self) {} }", "expected next token to be FUNCTION, got IDENT instead"},
+		{"p.1", "expected next token to be IDENT, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestEnumStatement(t *testing.T) {
+	input := "enum Shape { Circle(r), Rect(w, h), Empty };"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.EnumStatement)
+	if !ok {
+		t.Fatalf("stmt not *ast.EnumStatement. got=%T", program.Statements[0])
+	}
+	if len(stmt.Variants) != 3 {
+		t.Fatalf("wrong number of variants. got=%d", len(stmt.Variants))
+	}
+	if program.String() != "enum Shape { Circle(r), Rect(w, h), Empty }" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+
+	l = lexer.New("enum Shape { circle }")
+	p = New(l)
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0] != `type name must start with an uppercase letter, got "circle"` {
+		t.Errorf("wrong errors for lowercase variant. got=%q", p.Errors())
+	}
+}
+
+func TestEnumExhaustivenessWarnings(t *testing.T) {
+	decl := "enum Shape { Circle(r), Rect(w, h), Empty };\n"
+	tests := []struct {
+		input           string
+		expectedWarning string
+	}{
+		{"match (s) { Circle(r) => 1, Rect(w, h) => 2, Empty => 3 }", ""},
+		{"match (s) { Circle(r) => 1, Empty => 3 }", "2:1: non-exhaustive match on Shape: Rect not covered"},
+		{"match (s) { Circle(1) => 1, Rect(_, _) | Empty => 3 }", "2:1: non-exhaustive match on Shape: Circle not covered"},
+		{"match (s) { Circle(r) => 1, other => 3 }", ""},
+		{"match (s) { Empty => 3 }", "2:1: non-exhaustive match on Shape: Circle, Rect not covered"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(decl + tt.input)
+		p := New(l)
+		p.ParseProgram()
+		checkParserErrors(t, p)
+
+		warnings := p.Warnings()
+		if tt.expectedWarning == "" {
+			if len(warnings) != 0 {
+				t.Errorf("unexpected warnings for %q: %q", tt.input, warnings)
+			}
+			continue
+		}
+		if len(warnings) != 1 || warnings[0] != tt.expectedWarning {
+			t.Errorf("wrong warnings for %q. want=%q, got=%q", tt.input, tt.expectedWarning, warnings)
+		}
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let add = fn(a: int, b: int) -> int { a + b };", "let add = fn(a: int,b: int) -> int (a + b);"},
+		{"let x: bool = true;", "let x: bool = true;"},
+		{"let f: fn(int, bool) -> string = g;", "let f: fn(int, bool) -> string = g;"},
+		{"let f = fn(g: fn(int), p: Point) { g };", "let f = fn(g: fn(int),p: Point) g;"},
+		{"fn(a, b) { a }", "fn(a,b) a"},
+		{"impl P { fn len(self) -> int { 1 } }", "impl P { fn(self) -> int 1 }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong for %q. want=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestTypeAnnotationErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"let x: = 1;", "expected type, got = instead"},
+		{"fn(a: 1) { a }", "expected type, got INT instead"},
+		{"fn(a) -> { a }", "expected type, got { instead"},
+		{"let f: fn(int = 1;", "expected next token to be ,, got = instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expectedError {
+			t.Errorf("wrong errors for %q. want=%q, got=%q", tt.input, tt.expectedError, errors)
+		}
+	}
+}
@@ -7,23 +7,57 @@ import (
 	"monkey/evaluator"
 	"monkey/lexer"
 	"monkey/object"
+	"monkey/optimizer"
 	"monkey/parser"
+	"monkey/resolver"
+	"monkey/types"
+	"strings"
 )
 
 const PROMPT = ">> "
 
-func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+type Options struct {
+	PrintAST bool // 最適化したあとのASTを評価の前に表示する
+
+	// 時刻、乱数、環境変数をSeedから決まるものにする(同じ入力なら同じ出力になる)
+	Deterministic bool
+	Seed          int64
+
+	// scriptに許すこと(nilなら何も許さない)
+	Permissions *evaluator.Permissions
+}
+
+// 入力が終わるまで評価を続ける。exit(code)が呼ばれたらそこで止めてcodeを返す
+func Start(in io.Reader, out io.Writer, opts Options) int {
+	lines := readLines(in)
 	env := object.NewEnvironment()
+	rt := evaluator.SystemRuntime
+	if opts.Deterministic {
+		rt = evaluator.NewDeterministicRuntime(opts.Seed)
+	}
+	evaluator.InstallRuntime(env, rt)
+	perms := opts.Permissions
+	if perms == nil {
+		perms = evaluator.NewPermissions()
+	}
+	evaluator.InstallPermissions(env, perms)
+	loop := evaluator.NewEventLoop(rt)
+	loop.Install(env)
+	checker := types.NewChecker()
+	res := resolver.New(evaluator.BuiltinNames())
 
 	for {
 		fmt.Printf(PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
-			return
+		line, ok := waitForLine(lines, loop)
+		if !ok {
+			return 0
+		}
+
+		if line == ":env" {
+			printEnv(out, env)
+			continue
 		}
 
-		line := scanner.Text()
 		l := lexer.New(line)
 		p := parser.New(l)
 
@@ -32,15 +66,65 @@ func Start(in io.Reader, out io.Writer) {
 			printParserErrors(out, p.Errors())
 			continue
 		}
+		for _, msg := range p.Warnings() {
+			io.WriteString(out, "warning: "+msg+"\n")
+		}
+
+		// 型の誤りは警告にして、そのまま評価する
+		// (try/catchで受け止めるコードもあるので。実際に誤っていれば評価したときにerrorになる)
+		for _, err := range checker.Check(program) {
+			io.WriteString(out, "warning: type error: "+err.Error()+"\n")
+		}
+		// 名前の解決の誤りも同じ。解決できなかった名前は評価するときにevalIdentifierが名前で探す
+		for _, err := range res.Resolve(program) {
+			io.WriteString(out, "warning: "+err.Error()+"\n")
+		}
+
+		optimizer.Optimize(program)
+		if opts.PrintAST {
+			io.WriteString(out, program.String()+"\n")
+		}
 
 		evaluated := evaluator.Eval(program, env)
+		if err, ok := evaluated.(*object.Error); ok {
+			if code, ok := err.ExitCode(); ok {
+				return code
+			}
+		}
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
 		}
+		loop.Poll()
 	}
 }
 
+// 一行ずつ読んで送る。入力が終わったらcloseする
+func readLines(in io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	return lines
+}
+
+// 次の行が来るまで待つ。待っている間もtimerを発火させ、async fnを進める
+func waitForLine(lines <-chan string, loop *evaluator.EventLoop) (string, bool) {
+	var line string
+	var ok bool
+	received := make(chan struct{})
+	go func() {
+		line, ok = <-lines
+		close(received)
+	}()
+	loop.Serve(received)
+	return line, ok
+}
+
 const MONKEY_FACE = `            __,__
    .--.  .-"     "-.  .--.
   / .. \/  .-. .-.  \/ .. \
@@ -54,6 +138,18 @@ const MONKEY_FACE = `            __,__
            '-----'
 `
 
+// :env 今までに束縛した名前と値を名前の順に表示する
+// *で始まる名前はevent loopなどinterpreterが使うもの
+func printEnv(out io.Writer, env *object.Environment) {
+	for _, name := range env.Names() {
+		if strings.HasPrefix(name, "*") {
+			continue
+		}
+		val, _ := env.Get(name)
+		io.WriteString(out, name+" = "+val.Inspect()+"\n")
+	}
+}
+
 func printParserErrors(out io.Writer, errors []string) {
 	io.WriteString(out, MONKEY_FACE)
 	io.WriteString(out, "Woops! We ran into some monkey business here!\n")
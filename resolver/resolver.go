@@ -0,0 +1,394 @@
+package resolver
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+)
+
+// 名前の解決の誤り(位置つき)
+type Error struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// 実行時の環境(object.Environment)一つに対応する
+// 関数呼び出し・matchのarm・catchで作られ、blockでは作られない
+type scope struct {
+	slots    map[string]int
+	defined  map[string]bool
+	later    map[string]bool // このscopeでこの先letされる名前(定義前の参照を見つけるため)
+	outer    *scope
+	function bool // 関数呼び出しのscope
+	dynamic  bool // matchのdefaultを評価する環境。slotは使わず名前で探す
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{slots: map[string]int{}, defined: map[string]bool{}, later: map[string]bool{}, outer: outer}
+}
+
+// 同じscopeで同じ名前をletし直した場合は同じslotを使う(実行時も上書きになる)
+func (s *scope) declare(ident *ast.Identifier) {
+	slot, ok := s.slots[ident.Value]
+	if !ok {
+		slot = len(s.slots)
+		s.slots[ident.Value] = slot
+	}
+	s.defined[ident.Value] = true
+
+	if !s.dynamic {
+		ident.Resolved, ident.Depth, ident.Slot = true, 0, slot
+	}
+}
+
+// 関数の本体は、外側のscopeの名前がすべて宣言されてから解決する
+// (let f = fn() { g() }; let g = ...; のように後で定義する関数を呼べるように)
+type pending struct {
+	function *ast.FunctionLiteral
+	scope    *scope
+}
+
+// 評価の前に識別子に(depth, slot)を割り当て、未定義の名前や定義前の参照を報告する
+// REPLのように何度もResolveするときは同じResolverを使うと前の行のletを覚えている
+type Resolver struct {
+	scope    *scope
+	builtins map[string]bool
+	pending  []pending
+	errors   []*Error
+}
+
+// builtinsは環境になくても参照できる名前
+func New(builtins []string) *Resolver {
+	r := &Resolver{scope: newScope(nil), builtins: map[string]bool{}}
+	r.scope.function = true
+	for _, name := range builtins {
+		r.builtins[name] = true
+	}
+	return r
+}
+
+func (r *Resolver) Resolve(program *ast.Program) []*Error {
+	r.errors = []*Error{}
+	r.hoist(r.scope, program.Statements)
+	for _, stmt := range program.Statements {
+		r.resolveStatement(stmt)
+	}
+	r.resolvePending()
+	return r.errors
+}
+
+func (r *Resolver) errorf(tok token.Token, format string, a ...interface{}) {
+	r.errors = append(r.errors, &Error{Line: tok.Line, Column: tok.Column, Message: fmt.Sprintf(format, a...)})
+}
+
+// scopeの中でletされる名前を先に集めておく(blockはscopeを作らないので中も見る)
+func (r *Resolver) hoist(s *scope, stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			if stmt.Name != nil {
+				s.later[stmt.Name.Value] = true
+			}
+			for _, ident := range ast.PatternIdentifiers(stmt.Pattern) {
+				s.later[ident.Value] = true
+			}
+		case *ast.StructStatement:
+			s.later[stmt.Name.Value] = true
+		case *ast.EnumStatement:
+			s.later[stmt.Name.Value] = true
+			for _, v := range stmt.Variants {
+				s.later[v.Name.Value] = true
+			}
+		case *ast.ExpressionStatement:
+			r.hoistExpression(s, stmt.Expression)
+		}
+	}
+}
+
+func (r *Resolver) hoistExpression(s *scope, exp ast.Expression) {
+	switch exp := exp.(type) {
+	case *ast.IfExpression:
+		r.hoistBlock(s, exp.Consequence)
+		r.hoistBlock(s, exp.Alternative)
+	case *ast.TryExpression:
+		r.hoistBlock(s, exp.Block)
+		r.hoistBlock(s, exp.Finally)
+	}
+}
+
+func (r *Resolver) hoistBlock(s *scope, block *ast.BlockStatement) {
+	if block != nil {
+		r.hoist(s, block.Statements)
+	}
+}
+
+func (r *Resolver) resolvePending() {
+	for len(r.pending) > 0 {
+		p := r.pending[0]
+		r.pending = r.pending[1:]
+		r.resolveFunction(p.function, p.scope)
+	}
+}
+
+func (r *Resolver) resolveFunction(fl *ast.FunctionLiteral, outer *scope) {
+	saved := r.scope
+	r.scope = newScope(outer)
+	r.scope.function = true
+	defer func() { r.scope = saved }()
+
+	for _, param := range fl.Parameters {
+		r.scope.declare(param)
+	}
+	r.hoist(r.scope, fl.Body.Statements)
+	r.resolveBlock(fl.Body)
+}
+
+func (r *Resolver) resolveStatement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatement:
+		r.resolveExpression(stmt.Expression)
+	case *ast.LetStatement:
+		r.resolveExpression(stmt.Value)
+		if stmt.Pattern != nil {
+			r.resolvePatternDefaults(stmt.Pattern, map[string]*ast.Identifier{})
+			r.declarePattern(stmt.Pattern)
+		} else {
+			r.scope.declare(stmt.Name)
+		}
+	case *ast.ReturnStatement:
+		r.resolveExpression(stmt.ReturnValue)
+	case *ast.ThrowStatement:
+		r.resolveExpression(stmt.Value)
+	case *ast.AssignStatement:
+		r.resolveExpression(stmt.Target)
+		r.resolveExpression(stmt.Value)
+	case *ast.StructStatement:
+		r.scope.declare(stmt.Name)
+	case *ast.EnumStatement:
+		r.scope.declare(stmt.Name)
+		for _, v := range stmt.Variants {
+			r.scope.declare(v.Name)
+		}
+	case *ast.ImplStatement:
+		// 型は名前で探す(evalImplStatement)ので存在だけ確かめる
+		r.lookup(stmt.Name, false)
+		for _, method := range stmt.Methods {
+			r.pending = append(r.pending, pending{function: method, scope: r.scope})
+		}
+	}
+}
+
+func (r *Resolver) resolveBlock(block *ast.BlockStatement) {
+	if block == nil {
+		return
+	}
+	for _, stmt := range block.Statements {
+		r.resolveStatement(stmt)
+	}
+}
+
+func (r *Resolver) resolveExpression(exp ast.Expression) {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		r.lookup(exp, true)
+	case *ast.PrefixExpression:
+		r.resolveExpression(exp.Right)
+	case *ast.InfixExpression:
+		r.resolveExpression(exp.Left)
+		r.resolveExpression(exp.Right)
+	case *ast.PostfixExpression:
+		r.resolveExpression(exp.Left)
+	case *ast.IfExpression:
+		r.resolveExpression(exp.Condition)
+		r.resolveBlock(exp.Consequence)
+		r.resolveBlock(exp.Alternative)
+	case *ast.FunctionLiteral:
+		r.pending = append(r.pending, pending{function: exp, scope: r.scope})
+	case *ast.CallExpression:
+		r.resolveExpression(exp.Function)
+		for _, arg := range exp.Arguments {
+			r.resolveExpression(arg)
+		}
+	case *ast.ArrayLiteral:
+		for _, el := range exp.Elements {
+			r.resolveExpression(el)
+		}
+	case *ast.HashLiteral:
+		for i := range exp.Keys {
+			r.resolveExpression(exp.Keys[i])
+			r.resolveExpression(exp.Values[i])
+		}
+	case *ast.IndexExpression:
+		r.resolveExpression(exp.Left)
+		r.resolveExpression(exp.Index)
+	case *ast.StructLiteral:
+		r.lookup(exp.Name, true)
+		for _, v := range exp.Values {
+			r.resolveExpression(v)
+		}
+	case *ast.FieldExpression:
+		r.resolveExpression(exp.Left)
+	case *ast.TryExpression:
+		r.resolveBlock(exp.Block)
+		if exp.Catch != nil {
+			r.withScope(func() {
+				r.scope.declare(exp.CatchParameter)
+				r.resolveBlock(exp.Catch)
+			})
+		}
+		r.resolveBlock(exp.Finally)
+	case *ast.MatchExpression:
+		r.resolveExpression(exp.Subject)
+		for _, arm := range exp.Arms {
+			arm := arm
+			// patternのdefaultはarmの外側の環境で評価される
+			r.resolvePatternDefaults(arm.Pattern, map[string]*ast.Identifier{})
+			r.withScope(func() {
+				r.declarePattern(arm.Pattern)
+				r.resolveExpression(arm.Guard)
+				r.resolveBlock(arm.Body)
+			})
+		}
+	case *ast.YieldExpression:
+		r.resolveExpression(exp.Value)
+	case *ast.AwaitExpression:
+		r.resolveExpression(exp.Value)
+	case *ast.SelectExpression:
+		for _, sc := range exp.Cases {
+			sc := sc
+			r.resolveExpression(sc.Channel)
+			r.resolveExpression(sc.Value)
+			r.withScope(func() {
+				if sc.Name != nil {
+					r.scope.declare(sc.Name)
+				}
+				r.resolveBlock(sc.Body)
+			})
+		}
+	}
+}
+
+// matchのarmやcatchのscope
+func (r *Resolver) withScope(fn func()) {
+	saved := r.scope
+	r.scope = newScope(saved)
+	defer func() { r.scope = saved }()
+	fn()
+}
+
+// patternで束縛される名前を宣言する
+func (r *Resolver) declarePattern(pattern ast.Pattern) {
+	r.checkConstructors(pattern)
+	for _, ident := range ast.PatternIdentifiers(pattern) {
+		r.scope.declare(ident)
+	}
+}
+
+// defaultはそれまでに束縛した変数だけを持つ環境で評価される(matchDefault)
+// その環境にはslotがないので、中の識別子は名前で探すことになる
+func (r *Resolver) resolvePatternDefaults(pattern ast.Pattern, bound map[string]*ast.Identifier) {
+	switch pattern := pattern.(type) {
+	case *ast.BindingPattern:
+		bound[pattern.Name.Value] = pattern.Name
+	case *ast.AlternativePattern:
+		for _, alt := range pattern.Alternatives {
+			r.resolvePatternDefaults(alt, bound)
+		}
+	case *ast.ConstructorPattern:
+		for _, arg := range pattern.Arguments {
+			r.resolvePatternDefaults(arg, bound)
+		}
+	case *ast.ArrayPattern:
+		for _, el := range pattern.Elements {
+			r.resolvePatternDefaults(el, bound)
+		}
+		if pattern.Rest != nil {
+			bound[pattern.Rest.Value] = pattern.Rest
+		}
+	case *ast.HashPattern:
+		for _, v := range pattern.Values {
+			r.resolvePatternDefaults(v, bound)
+		}
+	case *ast.DefaultPattern:
+		saved := r.scope
+		r.scope = newScope(saved)
+		r.scope.dynamic = true
+		for _, ident := range bound {
+			r.scope.declare(&ast.Identifier{Value: ident.Value})
+		}
+		r.resolveExpression(pattern.Default)
+		r.scope = saved
+
+		r.resolvePatternDefaults(pattern.Pattern, bound)
+	}
+}
+
+// Circle(r) のようなpatternのconstructorは名前で探すので存在だけ確かめる
+func (r *Resolver) checkConstructors(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.ConstructorPattern:
+		if pattern.Name.Value != "ok" && pattern.Name.Value != "err" {
+			r.lookup(pattern.Name, false)
+		}
+		for _, arg := range pattern.Arguments {
+			r.checkConstructors(arg)
+		}
+	case *ast.AlternativePattern:
+		for _, alt := range pattern.Alternatives {
+			r.checkConstructors(alt)
+		}
+	case *ast.ArrayPattern:
+		for _, el := range pattern.Elements {
+			r.checkConstructors(el)
+		}
+	case *ast.HashPattern:
+		for _, v := range pattern.Values {
+			r.checkConstructors(v)
+		}
+	case *ast.DefaultPattern:
+		r.checkConstructors(pattern.Pattern)
+	}
+}
+
+// 内側のscopeから順に名前を探す
+// 同じ関数の中で、まだletしていない名前を参照していたらエラー
+// (関数の本体はあとで解決するので外側の関数の名前はすべて宣言済み)
+func (r *Resolver) lookup(ident *ast.Identifier, assign bool) {
+	depth := 0
+	dynamic := false
+	sameFunction := true
+
+	for s := r.scope; s != nil; s = s.outer {
+		dynamic = dynamic || s.dynamic
+		if slot, ok := s.slots[ident.Value]; ok && (s.defined[ident.Value] || !sameFunction) {
+			if assign && !dynamic {
+				ident.Resolved, ident.Depth, ident.Slot = true, depth, slot
+			}
+			return
+		}
+		if s.function {
+			sameFunction = false
+		}
+		depth++
+	}
+
+	if r.builtins[ident.Value] {
+		return
+	}
+
+	for s := r.scope; s != nil; s = s.outer {
+		if s.later[ident.Value] {
+			r.errorf(ident.Token, "%s used before definition", ident.Value)
+			return
+		}
+		if s.function {
+			break
+		}
+	}
+	r.errorf(ident.Token, "identifier not found: %s", ident.Value)
+}
@@ -0,0 +1,202 @@
+package resolver
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %q", input, p.Errors())
+	}
+	return program
+}
+
+// programの中の名前がnameの識別子を出てきた順に集める
+func identifiers(node ast.Node, name string) []*ast.Identifier {
+	found := []*ast.Identifier{}
+	var walk func(node ast.Node)
+	walk = func(node ast.Node) {
+		switch node := node.(type) {
+		case *ast.Program:
+			for _, s := range node.Statements {
+				walk(s)
+			}
+		case *ast.BlockStatement:
+			for _, s := range node.Statements {
+				walk(s)
+			}
+		case *ast.ExpressionStatement:
+			walk(node.Expression)
+		case *ast.LetStatement:
+			walk(node.Name)
+			walk(node.Value)
+		case *ast.ReturnStatement:
+			walk(node.ReturnValue)
+		case *ast.Identifier:
+			if node.Value == name {
+				found = append(found, node)
+			}
+		case *ast.FunctionLiteral:
+			for _, p := range node.Parameters {
+				walk(p)
+			}
+			walk(node.Body)
+		case *ast.CallExpression:
+			walk(node.Function)
+			for _, a := range node.Arguments {
+				walk(a)
+			}
+		case *ast.InfixExpression:
+			walk(node.Left)
+			walk(node.Right)
+		case *ast.IfExpression:
+			walk(node.Condition)
+			walk(node.Consequence)
+			if node.Alternative != nil {
+				walk(node.Alternative)
+			}
+		case *ast.MatchExpression:
+			walk(node.Subject)
+			for _, arm := range node.Arms {
+				for _, ident := range ast.PatternIdentifiers(arm.Pattern) {
+					walk(ident)
+				}
+				walk(arm.Body)
+			}
+		}
+	}
+	walk(node)
+	return found
+}
+
+func TestResolveSlots(t *testing.T) {
+	type location struct {
+		resolved bool
+		depth    int
+		slot     int
+	}
+	tests := []struct {
+		input    string
+		name     string
+		expected []location
+	}{
+		{"let a = 1; let b = 2; b", "b", []location{{true, 0, 1}, {true, 0, 1}}},
+		{"let a = 1; let a = a + 1; a", "a", []location{{true, 0, 0}, {true, 0, 0}, {true, 0, 0}, {true, 0, 0}}},
+		{"let x = 1; let f = fn(a, b) { a + b + x };", "b", []location{{true, 0, 1}, {true, 0, 1}}},
+		{"let x = 1; let f = fn(a, b) { a + b + x };", "x", []location{{true, 0, 0}, {true, 1, 0}}},
+		{"let x = 1; let f = fn() { fn() { x } };", "x", []location{{true, 0, 0}, {true, 2, 0}}},
+		{"let f = fn(n) { f(n) };", "f", []location{{true, 0, 0}, {true, 1, 0}}},
+		{"let x = 1; fn() { x; let x = 2; x }", "x", []location{{true, 0, 0}, {true, 1, 0}, {true, 0, 0}, {true, 0, 0}}},
+		{"let x = 1; match (2) { n => fn() { n + x } }", "n", []location{{true, 0, 0}, {true, 1, 0}}},
+		{"let x = 1; match (2) { n => fn() { n + x } }", "x", []location{{true, 0, 0}, {true, 2, 0}}},
+		{"let x = 1; if (true) { let y = 2; x }", "x", []location{{true, 0, 0}, {true, 0, 0}}},
+		{"len", "len", []location{{false, 0, 0}}},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		if errs := New([]string{"len"}).Resolve(program); len(errs) != 0 {
+			t.Errorf("unexpected errors for %q: %v", tt.input, errs)
+			continue
+		}
+
+		idents := identifiers(program, tt.name)
+		if len(idents) != len(tt.expected) {
+			t.Fatalf("wrong number of %s in %q. want=%d, got=%d", tt.name, tt.input, len(tt.expected), len(idents))
+		}
+		for i, ident := range idents {
+			got := location{ident.Resolved, ident.Depth, ident.Slot}
+			if got != tt.expected[i] {
+				t.Errorf("wrong location for %s[%d] in %q. want=%+v, got=%+v", tt.name, i, tt.input, tt.expected[i], got)
+			}
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x", "1:1: identifier not found: x"},
+		{"let y = x; let x = 1;", "1:9: x used before definition"},
+		{"let x = x + 1;", "1:9: x used before definition"},
+		{"if (true) { y }; let y = 1;", "1:13: y used before definition"},
+		{"let f = fn() { g() };", "1:16: identifier not found: g"},
+		{"let f = fn() { let a = b; let b = 1; };", "1:24: b used before definition"},
+		{"match (1) { n => n }; n", "1:23: identifier not found: n"},
+		{"try { 1 } catch (e) { e }; e", "1:28: identifier not found: e"},
+		{"impl Nope { fn f(self) { self } }", "1:6: identifier not found: Nope"},
+		{"match (1) { Circle(r) => r }", "1:13: identifier not found: Circle"},
+		{"let [a, b = c] = [1];", "1:13: identifier not found: c"},
+		{"Point { x: 1 }", "1:1: identifier not found: Point"},
+	}
+
+	for _, tt := range tests {
+		errs := New([]string{"len"}).Resolve(parse(t, tt.input))
+		if len(errs) != 1 {
+			t.Errorf("wrong number of errors for %q. want=1, got=%v", tt.input, errs)
+			continue
+		}
+		if errs[0].Error() != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errs[0].Error())
+		}
+	}
+}
+
+// 解決できなかった名前は、評価するときに名前で探せるようにslotを割り当てないでおく
+func TestUnresolvedNamesStayDynamic(t *testing.T) {
+	program := parse(t, "let f = fn() { missing }; let y = x; let x = 1;")
+	if errs := New(nil).Resolve(program); len(errs) != 2 {
+		t.Fatalf("wrong number of errors. want=2, got=%v", errs)
+	}
+	for _, name := range []string{"missing", "x"} {
+		ident := identifiers(program, name)[0]
+		if ident.Resolved {
+			t.Errorf("%s should be looked up by name. got=%+v", name, ident)
+		}
+	}
+}
+
+func TestResolveAcceptsValidPrograms(t *testing.T) {
+	tests := []string{
+		"let f = fn() { g() }; let g = fn() { 1 }; f()",
+		"let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } }; let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };",
+		"let len = fn(x) { 0 }; len(1)",
+		"len([1])",
+		"let [a, b = a] = [1];",
+		"enum Shape { Circle(r), Empty }; match (Empty) { Circle(r) => r, Empty => 0 }",
+		"struct P { x }; impl P { fn get(self) { self.x } }; P { x: 1 }.get()",
+		"try { throw 1 } catch (e) { e } finally { 2 }",
+		"if (true) { let y = 1; y }",
+		"match ([1]) { [x, ...rest] if x > 0 => rest }",
+	}
+
+	for _, input := range tests {
+		if errs := New([]string{"len"}).Resolve(parse(t, input)); len(errs) != 0 {
+			t.Errorf("unexpected errors for %q: %v", input, errs)
+		}
+	}
+}
+
+func TestResolverRemembersBindings(t *testing.T) {
+	r := New(nil)
+	if errs := r.Resolve(parse(t, "let a = 1; let b = 2;")); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	program := parse(t, "b")
+	if errs := r.Resolve(program); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	ident := identifiers(program, "b")[0]
+	if !ident.Resolved || ident.Depth != 0 || ident.Slot != 1 {
+		t.Errorf("wrong location. got=%+v", ident)
+	}
+}
@@ -5,6 +5,8 @@ type TokenType string // name string type TokenType(stirng == TokenType)
 type Token struct {
 	Type    TokenType // INT, =, ;, ...etc
 	Literal string
+	Line    int // 1-based line of the first character
+	Column  int // 1-based column of the first character
 }
 
 // Token Type(スクリプト言語をこれにマッピングする)
@@ -14,8 +16,10 @@ const (
 	EOF     = "EOF"
 
 	// 識別子 + リテラル
-	IDENT = "IDENT" // add, foobar, x, y, ...
-	INT   = "INT"   // 1334334...
+	IDENT  = "IDENT"  // add, foobar, x, y, ...
+	INT    = "INT"    // 1334334...
+	FLOAT  = "FLOAT"  // 3.14
+	STRING = "STRING" // "foo bar"
 
 	// 演算子
 	ASSIGN    = "="
@@ -24,6 +28,7 @@ const (
 	BANG      = "!"
 	ASTERRISK = "*"
 	SLASH     = "/"
+	QUESTION  = "?"
 
 	LT = "<"
 	GT = ">"
@@ -31,14 +36,23 @@ const (
 	EQ     = "=="
 	NOT_EQ = "!="
 
+	FAT_ARROW = "=>"
+	ARROW     = "->"
+	PIPE      = "|"
+
 	// デリミタ
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
+	ELLIPSIS  = "..."
+	DOT       = "."
 
-	LPAREN = "("
-	RPAREN = ")"
-	LBRACE = "{"
-	RBRACE = "}"
+	LPAREN   = "("
+	RPAREN   = ")"
+	LBRACE   = "{"
+	RBRACE   = "}"
+	LBRACKET = "["
+	RBRACKET = "]"
 
 	// キーワード
 	FUNCTION = "FUNCTION"
@@ -48,17 +62,41 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
+	THROW    = "THROW"
+	MATCH    = "MATCH"
+	STRUCT   = "STRUCT"
+	IMPL     = "IMPL"
+	ENUM     = "ENUM"
+	SELECT   = "SELECT"
+	YIELD    = "YIELD"
+	ASYNC    = "ASYNC"
+	AWAIT    = "AWAIT"
 )
 
 // 変数宣言 or 関数宣言 or ((変数・関数)名)
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":      FUNCTION,
+	"let":     LET,
+	"true":    TRUE,
+	"false":   FALSE,
+	"if":      IF,
+	"else":    ELSE,
+	"return":  RETURN,
+	"try":     TRY,
+	"catch":   CATCH,
+	"finally": FINALLY,
+	"throw":   THROW,
+	"match":   MATCH,
+	"struct":  STRUCT,
+	"impl":    IMPL,
+	"enum":    ENUM,
+	"select":  SELECT,
+	"yield":   YIELD,
+	"async":   ASYNC,
+	"await":   AWAIT,
 }
 
 func LookupIdent(ident string) TokenType {
@@ -0,0 +1,116 @@
+package types
+
+import (
+	"fmt"
+	"monkey/evaluator"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 組み込み関数の型はevaluator.BuiltinTypesの文字列から作る(CheckerとInferで同じ表を使う)
+// 型変数を作る順番が毎回同じになるように、名前の順にfnを呼ぶ
+func eachBuiltin(fn func(name, signature string)) {
+	signatures := evaluator.BuiltinTypes()
+	names := make([]string, 0, len(signatures))
+	for name := range signatures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fn(name, signatures[name])
+	}
+}
+
+// "(a, [string]) -> int" のaは型変数で、variableで何にするかを決める。[T]はlistで作る
+// 表はevaluatorの中で決まっているので、読めなければpanic
+func parseSignature(signature string, variable func(name string) Type, list func(element Type) Type) Type {
+	p := &signatureParser{tokens: tokenizeSignature(signature), variable: variable, list: list}
+	t := p.parseType()
+	if p.err == nil && p.pos != len(p.tokens) {
+		p.err = fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	if p.err != nil {
+		panic(fmt.Sprintf("bad builtin type %q: %s", signature, p.err))
+	}
+	return t
+}
+
+func tokenizeSignature(src string) []string {
+	tokens := []string{}
+	for i := 0; i < len(src); {
+		switch c := rune(src[i]); {
+		case c == ' ':
+			i++
+		case strings.HasPrefix(src[i:], "->"):
+			tokens = append(tokens, "->")
+			i += 2
+		case unicode.IsLetter(c):
+			start := i
+			for i < len(src) && unicode.IsLetter(rune(src[i])) {
+				i++
+			}
+			tokens = append(tokens, src[start:i])
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens
+}
+
+type signatureParser struct {
+	tokens   []string
+	pos      int
+	variable func(name string) Type
+	list     func(element Type) Type
+	err      error
+}
+
+func (p *signatureParser) next() string {
+	if p.pos >= len(p.tokens) {
+		if p.err == nil {
+			p.err = fmt.Errorf("unexpected end")
+		}
+		return ""
+	}
+	p.pos++
+	return p.tokens[p.pos-1]
+}
+
+func (p *signatureParser) expect(tok string) {
+	if got := p.next(); got != tok && p.err == nil {
+		p.err = fmt.Errorf("expected %q, got %q", tok, got)
+	}
+}
+
+// int / a / [T] / (T, ...) -> T
+func (p *signatureParser) parseType() Type {
+	switch tok := p.next(); {
+	case tok == "[":
+		element := p.parseType()
+		p.expect("]")
+		return p.list(element)
+	case tok == "(":
+		params := []Type{}
+		for p.err == nil && p.pos < len(p.tokens) && p.tokens[p.pos] != ")" {
+			if len(params) > 0 {
+				p.expect(",")
+			}
+			params = append(params, p.parseType())
+		}
+		p.expect(")")
+		p.expect("->")
+		return &Function{Parameters: params, Return: p.parseType()}
+	case len(tok) == 1 && unicode.IsLower(rune(tok[0])):
+		return p.variable(tok)
+	default:
+		if basic, ok := basics[tok]; ok {
+			return basic
+		}
+		if p.err == nil {
+			p.err = fmt.Errorf("unknown type %q", tok)
+		}
+		return Any
+	}
+}
@@ -0,0 +1,547 @@
+package types
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+	"unicode"
+)
+
+// 型の誤り(位置つき)
+type Error struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// 変数の型を覚えておくscope
+type scope struct {
+	vars  map[string]Type
+	outer *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{vars: map[string]Type{}, outer: outer}
+}
+
+func (s *scope) get(name string) (Type, bool) {
+	t, ok := s.vars[name]
+	if !ok && s.outer != nil {
+		return s.outer.get(name)
+	}
+	return t, ok
+}
+
+// 組み込み関数の型。型変数はany、listはarrayとして扱う
+var builtins = checkerBuiltins()
+
+func checkerBuiltins() map[string]Type {
+	types := map[string]Type{}
+	eachBuiltin(func(name, signature string) {
+		types[name] = parseSignature(signature, func(string) Type { return Any }, func(Type) Type { return Array })
+	})
+	return types
+}
+
+// 関数本体を調べている間の情報
+type function struct {
+	name    string
+	ret     Type // 注釈された戻り値の型(なければnil)
+	returns Type // return文の型をjoinしたもの
+}
+
+// 評価の前にastを調べて、実行時に型の誤りになるものを報告する
+// 注釈のない引数などはanyとして扱うので、注釈を書かなければ今まで通り動く
+// REPLのように何度もCheckするときは同じCheckerを使うと前の行のletを覚えている
+type Checker struct {
+	scope    *scope
+	function *function
+	errors   []*Error
+}
+
+func NewChecker() *Checker {
+	return &Checker{scope: newScope(nil)}
+}
+
+// 一度だけ調べるとき
+func Check(program *ast.Program) []*Error {
+	return NewChecker().Check(program)
+}
+
+func (c *Checker) Check(program *ast.Program) []*Error {
+	c.errors = []*Error{}
+	for _, stmt := range program.Statements {
+		c.checkStatement(stmt)
+	}
+	return c.errors
+}
+
+func (c *Checker) errorf(tok token.Token, format string, a ...interface{}) {
+	c.errors = append(c.errors, &Error{Line: tok.Line, Column: tok.Column, Message: fmt.Sprintf(format, a...)})
+}
+
+func (c *Checker) checkStatement(stmt ast.Statement) Type {
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatement:
+		return c.checkExpression(stmt.Expression)
+	case *ast.LetStatement:
+		c.checkLetStatement(stmt)
+	case *ast.ReturnStatement:
+		t := c.checkExpression(stmt.ReturnValue)
+		if c.function != nil {
+			c.checkReturnType(t, stmt.Token)
+			c.function.returns = join(c.function.returns, t)
+		}
+	case *ast.ThrowStatement:
+		c.checkExpression(stmt.Value)
+	case *ast.AssignStatement:
+		c.checkExpression(stmt.Target)
+		c.checkExpression(stmt.Value)
+	case *ast.StructStatement:
+		// 型そのものは値としてはanyとする
+		c.scope.vars[stmt.Name.Value] = Any
+	case *ast.EnumStatement:
+		c.checkEnumStatement(stmt)
+	case *ast.ImplStatement:
+		self := &Named{Name: stmt.Name.Value}
+		for _, method := range stmt.Methods {
+			c.checkFunctionLiteral(method, self)
+		}
+	}
+	return Any
+}
+
+func (c *Checker) checkLetStatement(stmt *ast.LetStatement) {
+	if stmt.Pattern != nil {
+		c.checkExpression(stmt.Value)
+		c.bindPattern(stmt.Pattern)
+		return
+	}
+
+	var annotated Type
+	if stmt.Name.Type != nil {
+		annotated = c.resolve(stmt.Name.Type)
+	}
+
+	// 再帰呼び出しのために、本体を調べる前に引数の注釈から分かる型で束縛しておく
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok && annotated == nil {
+		c.scope.vars[stmt.Name.Value] = c.signature(fl, nil)
+	}
+
+	t := c.checkExpression(stmt.Value)
+	if annotated == nil {
+		c.scope.vars[stmt.Name.Value] = t
+		return
+	}
+
+	if !consistent(t, annotated) {
+		c.errorf(stmt.Name.Token, "cannot use %s as %s in let %s", t, annotated, stmt.Name.Value)
+	}
+	c.scope.vars[stmt.Name.Value] = annotated
+}
+
+func (c *Checker) checkEnumStatement(stmt *ast.EnumStatement) {
+	enum := &Named{Name: stmt.Name.Value}
+	c.scope.vars[stmt.Name.Value] = Any
+
+	for _, variant := range stmt.Variants {
+		if len(variant.Fields) == 0 {
+			c.scope.vars[variant.Name.Value] = enum
+			continue
+		}
+
+		params := make([]Type, len(variant.Fields))
+		for i, field := range variant.Fields {
+			params[i] = Any
+			if field.Type != nil {
+				params[i] = c.resolve(field.Type)
+			}
+		}
+		c.scope.vars[variant.Name.Value] = &Function{Parameters: params, Return: enum}
+	}
+}
+
+func (c *Checker) checkExpression(exp ast.Expression) Type {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return Int
+	case *ast.FloatLiteral:
+		return Float
+	case *ast.Boolean:
+		return Bool
+	case *ast.StringLiteral:
+		return String
+	case *ast.Identifier:
+		if t, ok := c.scope.get(exp.Value); ok {
+			return t
+		}
+		if t, ok := builtins[exp.Value]; ok {
+			return t
+		}
+		return Any
+	case *ast.PrefixExpression:
+		return c.checkPrefixExpression(exp)
+	case *ast.InfixExpression:
+		return c.checkInfixExpression(exp)
+	case *ast.IfExpression:
+		c.checkExpression(exp.Condition)
+		t := c.checkBranch(exp.Consequence)
+		if exp.Alternative == nil {
+			return join(t, Null)
+		}
+		return join(t, c.checkBranch(exp.Alternative))
+	case *ast.FunctionLiteral:
+		return c.checkFunctionLiteral(exp, nil)
+	case *ast.CallExpression:
+		return c.checkCallExpression(exp)
+	case *ast.ArrayLiteral:
+		for _, el := range exp.Elements {
+			c.checkExpression(el)
+		}
+		return Array
+	case *ast.HashLiteral:
+		for i := range exp.Keys {
+			c.checkExpression(exp.Keys[i])
+			c.checkExpression(exp.Values[i])
+		}
+		return Hash
+	case *ast.IndexExpression:
+		c.checkExpression(exp.Left)
+		c.checkExpression(exp.Index)
+	case *ast.StructLiteral:
+		for _, v := range exp.Values {
+			c.checkExpression(v)
+		}
+		return &Named{Name: exp.Name.Value}
+	case *ast.FieldExpression:
+		c.checkExpression(exp.Left)
+	case *ast.PostfixExpression:
+		c.checkExpression(exp.Left)
+	case *ast.TryExpression:
+		c.checkBranch(exp.Block)
+		if exp.Catch != nil {
+			c.checkBranchWith(exp.Catch, func() {
+				c.scope.vars[exp.CatchParameter.Value] = Any
+			})
+		}
+		if exp.Finally != nil {
+			c.checkBranch(exp.Finally)
+		}
+	case *ast.MatchExpression:
+		c.checkExpression(exp.Subject)
+		var result Type
+		for _, arm := range exp.Arms {
+			arm := arm
+			result = join(result, c.checkBranchWith(arm.Body, func() {
+				c.bindPattern(arm.Pattern)
+				if arm.Guard != nil {
+					c.checkExpression(arm.Guard)
+				}
+			}))
+		}
+		if result != nil {
+			return result
+		}
+	case *ast.YieldExpression:
+		if exp.Value != nil {
+			c.checkExpression(exp.Value)
+		}
+	case *ast.AwaitExpression:
+		c.checkExpression(exp.Value)
+	case *ast.SelectExpression:
+		var result Type
+		for _, sc := range exp.Cases {
+			sc := sc
+			if sc.Channel != nil {
+				c.checkExpression(sc.Channel)
+			}
+			if sc.Value != nil {
+				c.checkExpression(sc.Value)
+			}
+			result = join(result, c.checkBranchWith(sc.Body, func() {
+				if sc.Name != nil {
+					c.scope.vars[sc.Name.Value] = Any
+				}
+			}))
+		}
+		if result != nil {
+			return result
+		}
+	}
+	return Any
+}
+
+func (c *Checker) checkPrefixExpression(exp *ast.PrefixExpression) Type {
+	right := c.checkExpression(exp.Right)
+
+	// structなどは演算子を定義しているかもしれない
+	if _, ok := right.(*Basic); !ok || right == Any {
+		return Any
+	}
+
+	switch exp.Operator {
+	case "!":
+		return Bool
+	case "-":
+		if right == Float {
+			return Float
+		}
+		if right != Int {
+			c.errorf(exp.Token, "unknown operator: -%s", right)
+		}
+		return Int
+	}
+	return Any
+}
+
+func (c *Checker) checkInfixExpression(exp *ast.InfixExpression) Type {
+	left := c.checkExpression(exp.Left)
+	right := c.checkExpression(exp.Right)
+
+	// どちらかがanyやstructのときは演算子を定義しているかもしれないので何もわからない
+	// (右辺のstructもrmulなどを定義できる)
+	_, leftBasic := left.(*Basic)
+	_, rightBasic := right.(*Basic)
+	if !leftBasic || !rightBasic || left == Any || right == Any {
+		return Any
+	}
+
+	switch {
+	case left == Int && right == Int:
+		switch exp.Operator {
+		case "+", "-", "*", "/":
+			return Int
+		case "<", ">", "==", "!=":
+			return Bool
+		}
+	case isNumber(left) && isNumber(right):
+		// どちらかがfloat
+		switch exp.Operator {
+		case "+", "-", "*", "/":
+			return Float
+		case "<", ">", "==", "!=":
+			return Bool
+		}
+	case left == String && right == String:
+		switch exp.Operator {
+		case "+":
+			return String
+		case "==", "!=":
+			return Bool
+		}
+		c.errorf(exp.Token, "unknown operator: %s %s %s", left, exp.Operator, right)
+		return Any
+	case exp.Operator == "==" || exp.Operator == "!=":
+		return Bool
+	case left.String() != right.String():
+		c.errorf(exp.Token, "type mismatch: %s %s %s", left, exp.Operator, right)
+		return Any
+	}
+
+	c.errorf(exp.Token, "unknown operator: %s %s %s", left, exp.Operator, right)
+	return Any
+}
+
+func isNumber(t Type) bool {
+	return t == Int || t == Float
+}
+
+func (c *Checker) checkCallExpression(exp *ast.CallExpression) Type {
+	callee := c.checkExpression(exp.Function)
+	args := make([]Type, len(exp.Arguments))
+	for i, arg := range exp.Arguments {
+		args[i] = c.checkExpression(arg)
+	}
+
+	switch fn := callee.(type) {
+	case *Function:
+		if len(fn.Parameters) != len(args) {
+			c.errorf(exp.Token, "wrong number of arguments to %s: want=%d, got=%d", exp.Function, len(fn.Parameters), len(args))
+			return fn.Return
+		}
+		for i, arg := range args {
+			if !consistent(arg, fn.Parameters[i]) {
+				c.errorf(exp.Token, "cannot use %s as %s in argument %d to %s", arg, fn.Parameters[i], i+1, exp.Function)
+			}
+		}
+		return fn.Return
+	case *Basic:
+		if fn != Any {
+			c.errorf(exp.Token, "not a function: %s", fn)
+		}
+	case *Named:
+		c.errorf(exp.Token, "not a function: %s", fn)
+	}
+	return Any
+}
+
+// 本体を調べる前の関数の型(戻り値は注釈がなければany)
+func (c *Checker) signature(fl *ast.FunctionLiteral, self Type) *Function {
+	params := make([]Type, len(fl.Parameters))
+	for i, p := range fl.Parameters {
+		params[i] = Any
+		if p.Type != nil {
+			params[i] = c.resolve(p.Type)
+		} else if i == 0 && self != nil {
+			params[i] = self
+		}
+	}
+
+	ret := Type(Any)
+	switch {
+	case fl.ReturnType != nil:
+		ret = c.resolve(fl.ReturnType)
+	case fl.Generator:
+		ret = Generator
+	case fl.Async:
+		ret = Promise
+	}
+	return &Function{Parameters: params, Return: ret}
+}
+
+// selfはimplのmethodのとき、最初の引数の型になる
+func (c *Checker) checkFunctionLiteral(fl *ast.FunctionLiteral, self Type) Type {
+	sig := c.signature(fl, self)
+
+	outerScope, outerFunction := c.scope, c.function
+	c.scope = newScope(outerScope)
+	c.function = &function{name: fl.Name}
+	if fl.ReturnType != nil && !fl.Generator && !fl.Async {
+		c.function.ret = sig.Return
+	}
+	defer func() {
+		c.scope, c.function = outerScope, outerFunction
+	}()
+
+	for i, p := range fl.Parameters {
+		c.scope.vars[p.Value] = sig.Parameters[i]
+	}
+
+	body := c.checkBlock(fl.Body)
+	c.checkReturnType(body, fl.Token)
+
+	// fn*やasync fnを呼ぶと本体の値ではなくgeneratorやpromiseが返る
+	if fl.Generator || fl.Async {
+		wrapped := Generator
+		if fl.Async {
+			wrapped = Promise
+		}
+		c.function.ret = sig.Return
+		c.checkReturnType(wrapped, fl.Token)
+		sig.Return = wrapped
+		return sig
+	}
+
+	if fl.ReturnType == nil {
+		sig.Return = join(body, c.function.returns)
+	}
+	return sig
+}
+
+func (c *Checker) checkReturnType(t Type, tok token.Token) {
+	if c.function.ret == nil || consistent(t, c.function.ret) {
+		return
+	}
+
+	name := c.function.name
+	if name == "" {
+		name = "function"
+	}
+	c.errorf(tok, "cannot use %s as %s in return from %s", t, c.function.ret, name)
+}
+
+// blockの値の型。最後の文が式でなければany
+func (c *Checker) checkBlock(block *ast.BlockStatement) Type {
+	if block == nil || len(block.Statements) == 0 {
+		return Null
+	}
+
+	var t Type
+	for _, stmt := range block.Statements {
+		t = c.checkStatement(stmt)
+	}
+	if _, ok := block.Statements[len(block.Statements)-1].(*ast.ExpressionStatement); !ok {
+		return Any
+	}
+	return t
+}
+
+func (c *Checker) checkBranch(block *ast.BlockStatement) Type {
+	return c.checkBranchWith(block, func() {})
+}
+
+// 実行されるかわからないblockを調べる
+// blockはscopeを作らないので、中でletした名前は外でも見えるが型はわからなくなる
+func (c *Checker) checkBranchWith(block *ast.BlockStatement, bind func()) Type {
+	outer := c.scope
+	c.scope = newScope(outer)
+	bind()
+	t := c.checkBlock(block)
+	inner := c.scope
+	c.scope = outer
+
+	for name, it := range inner.vars {
+		if ot, ok := outer.get(name); !ok || !identical(ot, it) {
+			outer.vars[name] = Any
+		}
+	}
+	return t
+}
+
+// patternで束縛される名前はanyにする
+func (c *Checker) bindPattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.BindingPattern:
+		c.scope.vars[pattern.Name.Value] = Any
+	case *ast.AlternativePattern:
+		for _, alt := range pattern.Alternatives {
+			c.bindPattern(alt)
+		}
+	case *ast.ConstructorPattern:
+		for _, arg := range pattern.Arguments {
+			c.bindPattern(arg)
+		}
+	case *ast.ArrayPattern:
+		for _, el := range pattern.Elements {
+			c.bindPattern(el)
+		}
+		if pattern.Rest != nil {
+			c.scope.vars[pattern.Rest.Value] = Any
+		}
+	case *ast.HashPattern:
+		for _, v := range pattern.Values {
+			c.bindPattern(v)
+		}
+	case *ast.DefaultPattern:
+		c.checkExpression(pattern.Default)
+		c.bindPattern(pattern.Pattern)
+	}
+}
+
+// 注釈を型にする
+func (c *Checker) resolve(te *ast.TypeExpression) Type {
+	if te.Token.Type == token.FUNCTION {
+		fn := &Function{Parameters: make([]Type, len(te.Parameters)), Return: Any}
+		for i, p := range te.Parameters {
+			fn.Parameters[i] = c.resolve(p)
+		}
+		if te.Return != nil {
+			fn.Return = c.resolve(te.Return)
+		}
+		return fn
+	}
+
+	if t, ok := basics[te.Name]; ok {
+		return t
+	}
+	if unicode.IsUpper(rune(te.Name[0])) {
+		return &Named{Name: te.Name}
+	}
+
+	c.errorf(te.Token, "unknown type %s", te.Name)
+	return Any
+}
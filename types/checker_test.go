@@ -0,0 +1,129 @@
+package types
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %q", input, p.Errors())
+	}
+	return program
+}
+
+func TestCheckAcceptsValidPrograms(t *testing.T) {
+	tests := []string{
+		"let add = fn(a: int, b: int) -> int { a + b }; add(1, 2)",
+		"let x: bool = 1 < 2;",
+		"let f = fn(a, b) { a + b }; f(true, 1)",
+		"let fact = fn(n: int) -> int { if (n < 2) { return 1; } n * fact(n - 1) }; fact(5)",
+		"let apply = fn(f: fn(int) -> int, x: int) -> int { f(x) }; apply(fn(n) { n * 2 }, 3)",
+		`let greet = fn(name: string) -> string { "hi " + name }; greet("bob")`,
+		"let x = if (true) { 1 } else { false }; x + 1",
+		"if (true) { let y = true }; y + 1",
+		"let y = 1; if (true) { let y = true }; y + 1",
+		"let v: any = true; v + 1",
+		"1 == true",
+		"struct V { x }; impl V { fn add(self, o) { V { x: self.x + o.x } } }; V { x: 1 } + V { x: 2 }",
+		"struct V { x }; impl V { fn rmul(self, n) { V { x: self.x * n } } }; 3 * V { x: 1 }",
+		"enum Shape { Circle(r), Empty }; match (Circle(1)) { Circle(r) => r + 1, Empty => 0 }",
+		"let x = 1; match (true) { x => x + true }",
+		"len([1, 2]) + 1",
+		"let [a, b] = [true, 1]; a + b",
+		"let x: float = 1.5 * 2; -x < 1",
+		"math.sqrt(2) + 1",
+		`str(1) + strings.upper("a")`,
+		`json.stringify(json.parse("[1]"), 2) + "!"`,
+	}
+
+	for _, input := range tests {
+		if errs := Check(parse(t, input)); len(errs) != 0 {
+			t.Errorf("unexpected errors for %q: %v", input, errs)
+		}
+	}
+}
+
+func TestCheckReportsErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"true + 1", "1:6: type mismatch: bool + int"},
+		{"true + false", "1:6: unknown operator: bool + bool"},
+		{`"a" - "b"`, "1:5: unknown operator: string - string"},
+		{"-true", "1:1: unknown operator: -bool"},
+		{"let x: bool = 1;", "1:5: cannot use int as bool in let x"},
+		{"let x: int = true;\nx + 1", "1:5: cannot use bool as int in let x"},
+		{"let add = fn(a: int, b: int) -> int { a + b };\nadd(1, true)", "2:4: cannot use bool as int in argument 2 to add"},
+		{"let add = fn(a: int, b: int) { a + b };\nadd(1)", "2:4: wrong number of arguments to add: want=2, got=1"},
+		{"let f = fn() -> int { true };", "1:9: cannot use bool as int in return from f"},
+		{"let f = fn(n) -> int { if (n) { return \"s\"; } 1 };", "1:33: cannot use string as int in return from f"},
+		{"let f = fn(a: bool) { a + 1 };", "1:25: type mismatch: bool + int"},
+		{"let s = fn() { \"s\" }; s() * 2", "1:27: type mismatch: string * int"},
+		{"let x = 5; x(1)", "1:13: not a function: int"},
+		{"let x: num = 1;", "1:8: unknown type num"},
+		{"let f = fn(g: fn(int) -> int) { g(1) }; f(fn(b: bool) { 1 })", "1:42: cannot use (bool) -> int as (int) -> int in argument 1 to f"},
+		{"enum E { A, B(x) }; A(1)", "1:22: not a function: E"},
+		{"let x: int = 1.5;", "1:5: cannot use float as int in let x"},
+		{"1.5 + \"a\"", "1:5: type mismatch: float + string"},
+		{"str(1) - 1", "1:8: type mismatch: string - int"},
+	}
+
+	for _, tt := range tests {
+		errs := Check(parse(t, tt.input))
+		if len(errs) != 1 {
+			t.Errorf("wrong number of errors for %q. want=1, got=%v", tt.input, errs)
+			continue
+		}
+		if errs[0].Error() != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errs[0].Error())
+		}
+	}
+}
+
+func TestCheckerRemembersBindings(t *testing.T) {
+	checker := NewChecker()
+
+	if errs := checker.Check(parse(t, "let add = fn(a: int, b: int) -> int { a + b };")); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	errs := checker.Check(parse(t, "add(1, true)"))
+	if len(errs) != 1 || errs[0].Message != "cannot use bool as int in argument 2 to add" {
+		t.Errorf("wrong errors. got=%v", errs)
+	}
+}
+
+func TestInferredTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(a: int, b: int) { a + b }", "(int, int) -> int"},
+		{"fn(a, b) { a < b }", "(any, any) -> any"},
+		{"fn(a: int) { a < 1 }", "(int) -> bool"},
+		{"fn(a: int) { if (a < 1) { return true; } false }", "(int) -> bool"},
+		{"fn(a: int) { if (a < 1) { return 1; } false }", "(int) -> any"},
+		{"fn(f: fn(int) -> int) -> int { f(1) }", "((int) -> int) -> int"},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		checker := NewChecker()
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+
+		got := checker.checkExpression(stmt.Expression)
+		if len(checker.errors) != 0 {
+			t.Errorf("unexpected errors for %q: %v", tt.input, checker.errors)
+		}
+		if got.String() != tt.expected {
+			t.Errorf("wrong type for %q. want=%q, got=%q", tt.input, tt.expected, got.String())
+		}
+	}
+}
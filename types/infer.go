@@ -0,0 +1,803 @@
+package types
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+	"strings"
+	"unicode"
+)
+
+// 型変数(推論の途中でまだ決まっていない型)
+type Variable struct {
+	ID int
+}
+
+func (v *Variable) String() string { return fmt.Sprintf("t%d", v.ID) }
+
+// 要素の型が決まった配列 [int]
+type List struct {
+	Element Type
+}
+
+func (l *List) String() string { return "[" + l.Element.String() + "]" }
+
+// let多相のための型スキーム(Variablesは全称量化された型変数)
+type Scheme struct {
+	Variables []*Variable
+	Type      Type
+}
+
+// letで束縛した名前とその型
+type Binding struct {
+	Name  string
+	Token token.Token
+	Type  Type
+}
+
+// add: (int, int) -> int
+// 型変数は出てきた順に a, b, c ... と表示する
+func (b *Binding) String() string {
+	return b.Name + ": " + rename(b.Type)[0]
+}
+
+// 推論中のscope
+type typeEnv struct {
+	vars  map[string]*Scheme
+	outer *typeEnv
+}
+
+func newTypeEnv(outer *typeEnv) *typeEnv {
+	return &typeEnv{vars: map[string]*Scheme{}, outer: outer}
+}
+
+func (e *typeEnv) get(name string) (*Scheme, bool) {
+	s, ok := e.vars[name]
+	if !ok && e.outer != nil {
+		return e.outer.get(name)
+	}
+	return s, ok
+}
+
+// Algorithm W
+type inferrer struct {
+	next     int
+	subst    map[int]Type
+	sites    map[int]token.Token // 型変数の型が決まった場所
+	env      *typeEnv
+	ret      Type // 今推論している関数の戻り値の型
+	retSite  token.Token
+	bindings []*Binding
+	errors   []*Error
+}
+
+// 注釈がなくても、すべてのletと関数の型を推論する
+// 型の食い違いは両方の型が決まった場所を示す
+func Infer(program *ast.Program) ([]*Binding, []*Error) {
+	in := &inferrer{
+		subst:  map[int]Type{},
+		sites:  map[int]token.Token{},
+		env:    newTypeEnv(nil),
+		errors: []*Error{},
+	}
+	in.declareBuiltins()
+
+	for _, stmt := range program.Statements {
+		in.inferStatement(stmt)
+	}
+
+	for _, b := range in.bindings {
+		b.Type = in.apply(b.Type)
+	}
+	return in.bindings, in.errors
+}
+
+// 型変数は組み込み関数ごとに全称量化する(lenはどんな型の引数でも呼べる)
+func (in *inferrer) declareBuiltins() {
+	eachBuiltin(func(name, signature string) {
+		scheme := &Scheme{}
+		variables := map[string]*Variable{}
+		scheme.Type = parseSignature(signature, func(v string) Type {
+			if _, ok := variables[v]; !ok {
+				variables[v] = in.fresh()
+				scheme.Variables = append(scheme.Variables, variables[v])
+			}
+			return variables[v]
+		}, func(element Type) Type { return &List{Element: element} })
+		in.env.vars[name] = scheme
+	})
+}
+
+func (in *inferrer) fresh() *Variable {
+	in.next++
+	return &Variable{ID: in.next}
+}
+
+func (in *inferrer) errorf(tok token.Token, format string, a ...interface{}) {
+	in.errors = append(in.errors, &Error{Line: tok.Line, Column: tok.Column, Message: fmt.Sprintf(format, a...)})
+}
+
+// 式でない文の値は何でもよいので新しい型変数にする
+func (in *inferrer) inferStatement(stmt ast.Statement) (Type, token.Token) {
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatement:
+		return in.infer(stmt.Expression)
+	case *ast.LetStatement:
+		in.inferLetStatement(stmt)
+		return in.fresh(), stmt.Token
+	case *ast.ReturnStatement:
+		t, site := in.infer(stmt.ReturnValue)
+		if in.ret != nil {
+			in.unify(in.ret, in.retSite, t, site)
+		}
+		return t, site
+	case *ast.ThrowStatement:
+		in.infer(stmt.Value)
+		return in.fresh(), stmt.Token
+	case *ast.AssignStatement:
+		in.infer(stmt.Target)
+		in.infer(stmt.Value)
+		return in.fresh(), stmt.Token
+	case *ast.StructStatement:
+		in.env.vars[stmt.Name.Value] = &Scheme{Type: in.fresh()}
+		return in.fresh(), stmt.Token
+	case *ast.EnumStatement:
+		in.inferEnumStatement(stmt)
+		return in.fresh(), stmt.Token
+	case *ast.ImplStatement:
+		self := &Named{Name: stmt.Name.Value}
+		for _, method := range stmt.Methods {
+			t := in.inferFunctionLiteral(method, self)
+			in.bindings = append(in.bindings, &Binding{Name: stmt.Name.Value + "." + method.Name, Token: method.Token, Type: t})
+		}
+		return in.fresh(), stmt.Token
+	}
+	return in.fresh(), token.Token{}
+}
+
+func (in *inferrer) inferLetStatement(stmt *ast.LetStatement) {
+	if stmt.Pattern != nil {
+		in.infer(stmt.Value)
+		in.bindPattern(stmt.Pattern)
+		return
+	}
+
+	name := stmt.Name.Value
+	self := in.fresh()
+	var annotated Type = self
+	site := stmt.Name.Token
+	if stmt.Name.Type != nil {
+		annotated, site = in.annotation(stmt.Name.Type), stmt.Name.Type.Token
+		in.unify(self, stmt.Name.Token, annotated, site)
+	}
+
+	// 再帰できるように、値を推論している間は自分自身を単相の型で見えるようにしておく
+	previous, shadowed := in.env.vars[name]
+	if _, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		in.env.vars[name] = &Scheme{Type: self}
+	}
+
+	t, valueSite := in.infer(stmt.Value)
+	in.unify(annotated, site, t, valueSite)
+
+	delete(in.env.vars, name)
+	if shadowed {
+		in.env.vars[name] = previous
+	}
+
+	in.env.vars[name] = in.generalize(t)
+	in.bindings = append(in.bindings, &Binding{Name: name, Token: stmt.Name.Token, Type: t})
+}
+
+func (in *inferrer) inferEnumStatement(stmt *ast.EnumStatement) {
+	enum := &Named{Name: stmt.Name.Value}
+	in.env.vars[stmt.Name.Value] = &Scheme{Type: in.fresh()}
+
+	for _, variant := range stmt.Variants {
+		if len(variant.Fields) == 0 {
+			in.env.vars[variant.Name.Value] = &Scheme{Type: enum}
+			continue
+		}
+
+		fn := &Function{Parameters: make([]Type, len(variant.Fields)), Return: enum}
+		vars := []*Variable{}
+		for i, field := range variant.Fields {
+			if field.Type != nil {
+				fn.Parameters[i] = in.annotation(field.Type)
+				continue
+			}
+			v := in.fresh()
+			vars = append(vars, v)
+			fn.Parameters[i] = v
+		}
+		in.env.vars[variant.Name.Value] = &Scheme{Variables: vars, Type: fn}
+	}
+}
+
+// 式の型と、その型が決まった場所を返す
+func (in *inferrer) infer(exp ast.Expression) (Type, token.Token) {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return Int, exp.Token
+	case *ast.FloatLiteral:
+		return Float, exp.Token
+	case *ast.Boolean:
+		return Bool, exp.Token
+	case *ast.StringLiteral:
+		return String, exp.Token
+	case *ast.Identifier:
+		scheme, ok := in.env.get(exp.Value)
+		if !ok {
+			in.errorf(exp.Token, "identifier not found: %s", exp.Value)
+			return in.fresh(), exp.Token
+		}
+		return in.instantiate(scheme), exp.Token
+	case *ast.PrefixExpression:
+		right, site := in.infer(exp.Right)
+		if exp.Operator == "-" {
+			if t, _ := in.prune(right, site); t == Float {
+				return Float, exp.Token
+			}
+			in.unify(right, site, Int, exp.Token)
+			return Int, exp.Token
+		}
+		return Bool, exp.Token
+	case *ast.InfixExpression:
+		return in.inferInfixExpression(exp), exp.Token
+	case *ast.IfExpression:
+		// 条件はtruthinessで判定するのでboolに限らない
+		in.infer(exp.Condition)
+		cons, consSite := in.inferBlock(exp.Consequence)
+		if exp.Alternative == nil {
+			return Null, exp.Token
+		}
+		alt, altSite := in.inferBlock(exp.Alternative)
+		in.unify(cons, consSite, alt, altSite)
+		return cons, consSite
+	case *ast.FunctionLiteral:
+		return in.inferFunctionLiteral(exp, nil), exp.Token
+	case *ast.CallExpression:
+		return in.inferCallExpression(exp), exp.Token
+	case *ast.ArrayLiteral:
+		element := Type(in.fresh())
+		site := exp.Token
+		for _, el := range exp.Elements {
+			t, s := in.infer(el)
+			in.unify(element, site, t, s)
+			site = s
+		}
+		return &List{Element: element}, exp.Token
+	case *ast.HashLiteral:
+		for i := range exp.Keys {
+			in.infer(exp.Keys[i])
+			in.infer(exp.Values[i])
+		}
+		return Hash, exp.Token
+	case *ast.IndexExpression:
+		left, leftSite := in.infer(exp.Left)
+		index, indexSite := in.infer(exp.Index)
+		if t, _ := in.prune(left, leftSite); t == Hash {
+			return in.fresh(), exp.Token
+		}
+		element := in.fresh()
+		in.unify(left, leftSite, &List{Element: element}, exp.Token)
+		in.unify(index, indexSite, Int, exp.Token)
+		return element, exp.Token
+	case *ast.StructLiteral:
+		for _, v := range exp.Values {
+			in.infer(v)
+		}
+		return &Named{Name: exp.Name.Value}, exp.Token
+	case *ast.FieldExpression:
+		in.infer(exp.Left)
+		return in.fresh(), exp.Token
+	case *ast.PostfixExpression:
+		in.infer(exp.Left)
+		return in.fresh(), exp.Token
+	case *ast.TryExpression:
+		t, site := in.inferBlock(exp.Block)
+		if exp.Catch != nil {
+			outer := in.env
+			in.env = newTypeEnv(outer)
+			in.env.vars[exp.CatchParameter.Value] = &Scheme{Type: in.fresh()}
+			c, catchSite := in.inferBlock(exp.Catch)
+			in.env = outer
+			in.unify(t, site, c, catchSite)
+		}
+		if exp.Finally != nil {
+			in.inferBlock(exp.Finally)
+		}
+		return t, site
+	case *ast.MatchExpression:
+		in.infer(exp.Subject)
+		result, site := Type(in.fresh()), exp.Token
+		outer := in.env
+		for _, arm := range exp.Arms {
+			in.env = newTypeEnv(outer)
+			in.bindPattern(arm.Pattern)
+			if arm.Guard != nil {
+				in.infer(arm.Guard)
+			}
+			t, s := in.inferBlock(arm.Body)
+			in.unify(result, site, t, s)
+			site = s
+		}
+		in.env = outer
+		return result, exp.Token
+	case *ast.AwaitExpression:
+		// promiseの中身の型はわからない
+		in.infer(exp.Value)
+		return in.fresh(), exp.Token
+	case *ast.YieldExpression:
+		// next(v)で渡される値の型はわからない
+		if exp.Value != nil {
+			in.infer(exp.Value)
+		}
+		return in.fresh(), exp.Token
+	case *ast.SelectExpression:
+		result, site := Type(in.fresh()), exp.Token
+		outer := in.env
+		for _, sc := range exp.Cases {
+			if sc.Channel != nil {
+				c, s := in.infer(sc.Channel)
+				in.unify(c, s, Channel, sc.Token)
+			}
+			if sc.Value != nil {
+				in.infer(sc.Value)
+			}
+			in.env = newTypeEnv(outer)
+			if sc.Name != nil {
+				in.env.vars[sc.Name.Value] = &Scheme{Type: in.fresh()}
+			}
+			t, s := in.inferBlock(sc.Body)
+			in.unify(result, site, t, s)
+			site = s
+		}
+		in.env = outer
+		return result, exp.Token
+	}
+	return in.fresh(), token.Token{}
+}
+
+func (in *inferrer) inferInfixExpression(exp *ast.InfixExpression) Type {
+	left, leftSite := in.infer(exp.Left)
+	right, rightSite := in.infer(exp.Right)
+
+	switch exp.Operator {
+	case "+":
+		// + はstringにも使える。どちらかわからなければint
+		l, _ := in.prune(left, leftSite)
+		r, _ := in.prune(right, rightSite)
+		if l == Float || r == Float {
+			return in.inferArithmetic(exp, left, leftSite, right, rightSite)
+		}
+		// 一つの間違いから二つ目のerrorを出さないように、合わなければそこでやめる
+		if !in.unifies(left, leftSite, right, rightSite) {
+			return Int
+		}
+		if t, _ := in.prune(left, leftSite); t == String {
+			return String
+		}
+		in.unify(left, leftSite, Int, exp.Token)
+		return Int
+	case "-", "*", "/":
+		return in.inferArithmetic(exp, left, leftSite, right, rightSite)
+	case "<", ">":
+		in.inferArithmetic(exp, left, leftSite, right, rightSite)
+		return Bool
+	default:
+		in.unify(left, leftSite, right, rightSite)
+		return Bool
+	}
+}
+
+func (in *inferrer) inferCallExpression(exp *ast.CallExpression) Type {
+	callee, calleeSite := in.infer(exp.Function)
+
+	args := make([]Type, len(exp.Arguments))
+	sites := make([]token.Token, len(exp.Arguments))
+	for i, arg := range exp.Arguments {
+		args[i], sites[i] = in.infer(arg)
+	}
+
+	// 関数の型がわかっていれば引数ごとにunifyする(エラーが引数を指すように)
+	if fn, ok := in.applyShallow(callee).(*Function); ok && len(fn.Parameters) == len(args) {
+		for i := range args {
+			in.unify(fn.Parameters[i], calleeSite, args[i], sites[i])
+		}
+		return fn.Return
+	}
+
+	ret := in.fresh()
+	in.unify(callee, calleeSite, &Function{Parameters: args, Return: ret}, exp.Token)
+	return ret
+}
+
+// selfはimplのmethodの最初の引数の型
+func (in *inferrer) inferFunctionLiteral(fl *ast.FunctionLiteral, self Type) Type {
+	outerEnv, outerRet, outerRetSite := in.env, in.ret, in.retSite
+	in.env = newTypeEnv(outerEnv)
+	defer func() {
+		in.env, in.ret, in.retSite = outerEnv, outerRet, outerRetSite
+	}()
+
+	fn := &Function{Parameters: make([]Type, len(fl.Parameters))}
+	for i, p := range fl.Parameters {
+		switch {
+		case p.Type != nil:
+			fn.Parameters[i] = in.annotation(p.Type)
+		case i == 0 && self != nil:
+			fn.Parameters[i] = self
+		default:
+			fn.Parameters[i] = in.fresh()
+		}
+		in.env.vars[p.Value] = &Scheme{Type: fn.Parameters[i]}
+	}
+
+	in.ret, in.retSite = in.fresh(), fl.Token
+	fn.Return = in.ret
+	// fn*やasync fnを呼ぶと本体の値ではなくgeneratorやpromiseが返る
+	switch {
+	case fl.Generator:
+		fn.Return = Generator
+	case fl.Async:
+		fn.Return = Promise
+	}
+	if fl.ReturnType != nil {
+		in.unify(fn.Return, fl.Token, in.annotation(fl.ReturnType), fl.ReturnType.Token)
+	}
+
+	body, site := in.inferBlock(fl.Body)
+	if !endsWithReturn(fl.Body) {
+		in.unify(in.ret, in.retSite, body, site)
+	}
+	return fn
+}
+
+func endsWithReturn(block *ast.BlockStatement) bool {
+	if len(block.Statements) == 0 {
+		return false
+	}
+	_, ok := block.Statements[len(block.Statements)-1].(*ast.ReturnStatement)
+	return ok
+}
+
+// blockはscopeを作らない(evaluatorと同じ)
+func (in *inferrer) inferBlock(block *ast.BlockStatement) (Type, token.Token) {
+	if block == nil || len(block.Statements) == 0 {
+		return Null, token.Token{}
+	}
+
+	var t Type
+	var site token.Token
+	for _, stmt := range block.Statements {
+		t, site = in.inferStatement(stmt)
+	}
+	return t, site
+}
+
+// patternで束縛される名前は新しい型変数にする
+func (in *inferrer) bindPattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.BindingPattern:
+		in.env.vars[pattern.Name.Value] = &Scheme{Type: in.fresh()}
+	case *ast.AlternativePattern:
+		for _, alt := range pattern.Alternatives {
+			in.bindPattern(alt)
+		}
+	case *ast.ConstructorPattern:
+		for _, arg := range pattern.Arguments {
+			in.bindPattern(arg)
+		}
+	case *ast.ArrayPattern:
+		for _, el := range pattern.Elements {
+			in.bindPattern(el)
+		}
+		if pattern.Rest != nil {
+			in.env.vars[pattern.Rest.Value] = &Scheme{Type: in.fresh()}
+		}
+	case *ast.HashPattern:
+		for _, v := range pattern.Values {
+			in.bindPattern(v)
+		}
+	case *ast.DefaultPattern:
+		in.infer(pattern.Default)
+		in.bindPattern(pattern.Pattern)
+	}
+}
+
+// 注釈を型にする。anyは新しい型変数になる
+func (in *inferrer) annotation(te *ast.TypeExpression) Type {
+	if te.Token.Type == token.FUNCTION {
+		fn := &Function{Parameters: make([]Type, len(te.Parameters))}
+		for i, p := range te.Parameters {
+			fn.Parameters[i] = in.annotation(p)
+		}
+		if te.Return != nil {
+			fn.Return = in.annotation(te.Return)
+		} else {
+			fn.Return = in.fresh()
+		}
+		return fn
+	}
+
+	switch te.Name {
+	case "any":
+		return in.fresh()
+	case "array":
+		return &List{Element: in.fresh()}
+	}
+	if t, ok := basics[te.Name]; ok {
+		return t
+	}
+	if unicode.IsUpper(rune(te.Name[0])) {
+		return &Named{Name: te.Name}
+	}
+
+	in.errorf(te.Token, "unknown type %s", te.Name)
+	return in.fresh()
+}
+
+// 型変数をたどって決まっている型にする(一段だけ)
+// siteは最後にたどった型変数の型が決まった場所になる
+func (in *inferrer) prune(t Type, site token.Token) (Type, token.Token) {
+	for {
+		v, ok := t.(*Variable)
+		if !ok {
+			return t, site
+		}
+		bound, ok := in.subst[v.ID]
+		if !ok {
+			return t, site
+		}
+		t, site = bound, in.sites[v.ID]
+	}
+}
+
+func (in *inferrer) applyShallow(t Type) Type {
+	t, _ = in.prune(t, token.Token{})
+	return t
+}
+
+// 型の中の決まった型変数をすべて置き換える
+func (in *inferrer) apply(t Type) Type {
+	switch t := in.applyShallow(t).(type) {
+	case *Function:
+		fn := &Function{Parameters: make([]Type, len(t.Parameters)), Return: in.apply(t.Return)}
+		for i, p := range t.Parameters {
+			fn.Parameters[i] = in.apply(p)
+		}
+		return fn
+	case *List:
+		return &List{Element: in.apply(t.Element)}
+	default:
+		return t
+	}
+}
+
+func (in *inferrer) unify(a Type, aSite token.Token, b Type, bSite token.Token) {
+	a, aSite = in.prune(a, aSite)
+	b, bSite = in.prune(b, bSite)
+
+	if va, ok := a.(*Variable); ok {
+		in.bindVariable(va, b, bSite, a, aSite)
+		return
+	}
+	if vb, ok := b.(*Variable); ok {
+		in.bindVariable(vb, a, aSite, b, bSite)
+		return
+	}
+
+	switch a := a.(type) {
+	case *Function:
+		if b, ok := b.(*Function); ok && len(a.Parameters) == len(b.Parameters) {
+			for i := range a.Parameters {
+				in.unify(a.Parameters[i], aSite, b.Parameters[i], bSite)
+			}
+			in.unify(a.Return, aSite, b.Return, bSite)
+			return
+		}
+	case *List:
+		if b, ok := b.(*List); ok {
+			in.unify(a.Element, aSite, b.Element, bSite)
+			return
+		}
+	default:
+		if a.String() == b.String() {
+			return
+		}
+	}
+
+	in.mismatch(a, aSite, b, bSite)
+}
+
+// unifyして、errorにならなかったか
+func (in *inferrer) unifies(a Type, aSite token.Token, b Type, bSite token.Token) bool {
+	n := len(in.errors)
+	in.unify(a, aSite, b, bSite)
+	return len(in.errors) == n
+}
+
+func (in *inferrer) bindVariable(v *Variable, t Type, site token.Token, vt Type, vSite token.Token) {
+	if other, ok := t.(*Variable); ok && other.ID == v.ID {
+		return
+	}
+	if in.occurs(v.ID, t) {
+		names := rename(in.apply(vt), in.apply(t))
+		in.errorf(site, "cannot construct infinite type %s = %s (from %d:%d)", names[0], names[1], vSite.Line, vSite.Column)
+		return
+	}
+	in.subst[v.ID] = t
+	in.sites[v.ID] = site
+}
+
+func (in *inferrer) occurs(id int, t Type) bool {
+	switch t := in.applyShallow(t).(type) {
+	case *Variable:
+		return t.ID == id
+	case *Function:
+		for _, p := range t.Parameters {
+			if in.occurs(id, p) {
+				return true
+			}
+		}
+		return in.occurs(id, t.Return)
+	case *List:
+		return in.occurs(id, t.Element)
+	}
+	return false
+}
+
+// 食い違った二つの型と、それぞれの型が決まった場所を示す
+func (in *inferrer) mismatch(a Type, aSite token.Token, b Type, bSite token.Token) {
+	names := rename(in.apply(a), in.apply(b))
+	in.errorf(bSite, "cannot unify %s with %s: %s from %d:%d, %s from %d:%d",
+		names[0], names[1], names[0], aSite.Line, aSite.Column, names[1], bSite.Line, bSite.Column)
+}
+
+// schemeの量化された型変数を新しい型変数に置き換える
+func (in *inferrer) instantiate(s *Scheme) Type {
+	mapping := map[int]Type{}
+	for _, v := range s.Variables {
+		mapping[v.ID] = in.fresh()
+	}
+	return substitute(in.apply(s.Type), mapping)
+}
+
+func substitute(t Type, mapping map[int]Type) Type {
+	switch t := t.(type) {
+	case *Variable:
+		if r, ok := mapping[t.ID]; ok {
+			return r
+		}
+		return t
+	case *Function:
+		fn := &Function{Parameters: make([]Type, len(t.Parameters)), Return: substitute(t.Return, mapping)}
+		for i, p := range t.Parameters {
+			fn.Parameters[i] = substitute(p, mapping)
+		}
+		return fn
+	case *List:
+		return &List{Element: substitute(t.Element, mapping)}
+	default:
+		return t
+	}
+}
+
+// 環境に出てこない型変数を量化する
+func (in *inferrer) generalize(t Type) *Scheme {
+	t = in.apply(t)
+	bound := map[int]bool{}
+	for e := in.env; e != nil; e = e.outer {
+		for _, s := range e.vars {
+			quantified := map[int]bool{}
+			for _, v := range s.Variables {
+				quantified[v.ID] = true
+			}
+			for _, v := range freeVariables(in.apply(s.Type)) {
+				if !quantified[v.ID] {
+					bound[v.ID] = true
+				}
+			}
+		}
+	}
+
+	scheme := &Scheme{Type: t}
+	for _, v := range freeVariables(t) {
+		if !bound[v.ID] {
+			scheme.Variables = append(scheme.Variables, v)
+		}
+	}
+	return scheme
+}
+
+// 出てきた順に重複なく
+func freeVariables(t Type) []*Variable {
+	vars := []*Variable{}
+	seen := map[int]bool{}
+
+	var walk func(t Type)
+	walk = func(t Type) {
+		switch t := t.(type) {
+		case *Variable:
+			if !seen[t.ID] {
+				seen[t.ID] = true
+				vars = append(vars, t)
+			}
+		case *Function:
+			for _, p := range t.Parameters {
+				walk(p)
+			}
+			walk(t.Return)
+		case *List:
+			walk(t.Element)
+		}
+	}
+	walk(t)
+	return vars
+}
+
+// 型変数を出てきた順に a, b, c ... と名前をつけて表示する
+// 複数の型を渡すと同じ型変数には同じ名前をつける
+func rename(types ...Type) []string {
+	names := map[int]string{}
+	for _, t := range types {
+		for _, v := range freeVariables(t) {
+			if _, ok := names[v.ID]; !ok {
+				names[v.ID] = variableName(len(names))
+			}
+		}
+	}
+
+	out := make([]string, len(types))
+	for i, t := range types {
+		out[i] = show(t, names)
+	}
+	return out
+}
+
+func variableName(n int) string {
+	name := string(rune('a' + n%26))
+	if n >= 26 {
+		name += fmt.Sprint(n / 26)
+	}
+	return name
+}
+
+func show(t Type, names map[int]string) string {
+	switch t := t.(type) {
+	case *Variable:
+		return names[t.ID]
+	case *Function:
+		params := []string{}
+		for _, p := range t.Parameters {
+			params = append(params, show(p, names))
+		}
+		return fmt.Sprintf("(%s) -> %s", strings.Join(params, ", "), show(t.Return, names))
+	case *List:
+		return "[" + show(t.Element, names) + "]"
+	default:
+		return t.String()
+	}
+}
+
+// 数の演算。どちらかがfloatなら結果もfloatで、もう片方はintでもよい
+// そうでなければ両方int
+func (in *inferrer) inferArithmetic(exp *ast.InfixExpression, left Type, leftSite token.Token, right Type, rightSite token.Token) Type {
+	l, _ := in.prune(left, leftSite)
+	r, _ := in.prune(right, rightSite)
+	// 左が合わなければ右は見ない(errorは一つだけ)
+	if l != Float && r != Float {
+		if in.unifies(left, leftSite, Int, exp.Token) {
+			in.unify(right, rightSite, Int, exp.Token)
+		}
+		return Int
+	}
+
+	if l == Int || in.unifies(left, leftSite, Float, exp.Token) {
+		if r != Int {
+			in.unify(right, rightSite, Float, exp.Token)
+		}
+	}
+	return Float
+}
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestInferBindings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let add = fn(a, b) { a + b };", []string{"add: (int, int) -> int"}},
+		{`let greet = fn(name) { "hi " + name };`, []string{"greet: (string) -> string"}},
+		{"let x = 1 < 2;", []string{"x: bool"}},
+		{"let id = fn(x) { x }; let n = id(1); let s = id(\"s\");", []string{"id: (a) -> a", "n: int", "s: string"}},
+		{"let compose = fn(f, g) { fn(x) { f(g(x)) } };", []string{"compose: ((a) -> b, (c) -> a) -> (c) -> b"}},
+		{"let fact = fn(n) { if (n < 2) { return 1; } n * fact(n - 1) };", []string{"fact: (int) -> int"}},
+		{"let first = fn(arr) { arr[0] }; let k = first([true]);", []string{"first: ([a]) -> a", "k: bool"}},
+		{"let pick = fn(c, a, b) { if (c) { a } else { b } };", []string{"pick: (a, b, b) -> b"}},
+		{"let f = fn(a: any, b: bool) -> int { len(a) };", []string{"f: (a, bool) -> int"}},
+		{"let f = fn(x) { let y = x + 1; y * 2 };", []string{"y: int", "f: (int) -> int"}},
+		{"struct P { x }; impl P { fn get(self, n) { n + 1 } };", []string{"P.get: (P, int) -> int"}},
+		{"enum Shape { Circle(r), Empty }; let c = Circle(1); let e = Empty;", []string{"c: Shape", "e: Shape"}},
+		{"let c = channel(1); let n = select { v = recv(c) => v + 1, _ => 0 };", []string{"c: channel", "n: int"}},
+		{"let done = spawn(fn() { \"s\" });", []string{"done: channel"}},
+		{"let gen = fn*(n) { let x = yield n + 1; x };", []string{"x: a", "gen: (int) -> generator"}},
+		{"let names = listDir(\".\"); let first = names[0];", []string{"names: [string]", "first: string"}},
+		{"let r = exec(\"ls\", [\"-l\"]); let code = exit(1) + 1;", []string{"r: hash", "code: int"}},
+		{"let show = fn(x) { str(x) + \"!\" };", []string{"show: (a) -> string"}},
+		{"let f = fn(x) { x * 1.5 }; let g = fn(x) { 2 + x };", []string{"f: (float) -> float", "g: (int) -> int"}},
+		{"let f = async fn(x) { await x + 1 }; let t = setTimeout(fn() { 1 }, 10);", []string{"f: (a) -> promise", "t: promise"}},
+	}
+
+	for _, tt := range tests {
+		bindings, errs := Infer(parse(t, tt.input))
+		if len(errs) != 0 {
+			t.Errorf("unexpected errors for %q: %v", tt.input, errs)
+			continue
+		}
+		if len(bindings) != len(tt.expected) {
+			t.Errorf("wrong number of bindings for %q. want=%d, got=%v", tt.input, len(tt.expected), bindings)
+			continue
+		}
+		for i, b := range bindings {
+			if b.String() != tt.expected[i] {
+				t.Errorf("wrong binding for %q. want=%q, got=%q", tt.input, tt.expected[i], b.String())
+			}
+		}
+	}
+}
+
+func TestInferErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + true", "1:5: cannot unify int with bool: int from 1:1, bool from 1:5"},
+		{"true + 1", "1:8: cannot unify bool with int: bool from 1:1, int from 1:8"},
+		{"let x = 1; let x = true; x + 1", "1:30: cannot unify bool with int: bool from 1:26, int from 1:30"},
+		{"true * 1", "1:6: cannot unify bool with int: bool from 1:1, int from 1:6"},
+		{"true * 1.5", "1:6: cannot unify bool with float: bool from 1:1, float from 1:6"},
+		{"let add = fn(a, b) { a + b };\nadd(1, true)", "2:8: cannot unify int with bool: int from 2:1, bool from 2:8"},
+		{"if (true) { 1 } else { \"x\" }", "1:24: cannot unify int with string: int from 1:13, string from 1:24"},
+		{"let f = fn(x) { x + 1 };\nf(\"s\")", "2:3: cannot unify int with string: int from 2:1, string from 2:3"},
+		{"let f = fn(x) -> bool { x + 1 };", "1:27: cannot unify bool with int: bool from 1:18, int from 1:27"},
+		{"let x: int = true;", "1:14: cannot unify int with bool: int from 1:8, bool from 1:14"},
+		{"let twice = fn(f) { f(f) };", "1:22: cannot construct infinite type a = (a) -> b (from 1:21)"},
+		{"let f = fn(a) { a };\nf(1, 2)", "2:2: cannot unify (a) -> a with (int, int) -> b: (a) -> a from 2:1, (int, int) -> b from 2:2"},
+		{"[1, true]", "1:5: cannot unify int with bool: int from 1:2, bool from 1:5"},
+		{"y + 1", "1:1: identifier not found: y"},
+		{"select { recv(1) => 1 }", "1:18: cannot unify int with channel: int from 1:15, channel from 1:18"},
+		{"let f = fn(x) { if (x) { return 1; } \"s\" };", "1:38: cannot unify int with string: int from 1:33, string from 1:38"},
+	}
+
+	for _, tt := range tests {
+		_, errs := Infer(parse(t, tt.input))
+		if len(errs) != 1 {
+			t.Errorf("wrong number of errors for %q. want=1, got=%v", tt.input, errs)
+			continue
+		}
+		if errs[0].Error() != tt.expected {
+			t.Errorf("wrong error for %q.\nwant=%q\ngot= %q", tt.input, tt.expected, errs[0].Error())
+		}
+	}
+}
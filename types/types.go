@@ -0,0 +1,123 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 静的な型
+type Type interface {
+	String() string
+}
+
+// int, bool などの組み込みの型
+type Basic struct {
+	Name string
+}
+
+func (b *Basic) String() string { return b.Name }
+
+var (
+	Int    = &Basic{Name: "int"}
+	Float  = &Basic{Name: "float"}
+	Bool   = &Basic{Name: "bool"}
+	String = &Basic{Name: "string"}
+	Null   = &Basic{Name: "null"}
+	Array  = &Basic{Name: "array"}
+	Hash   = &Basic{Name: "hash"}
+
+	// 要素の型は区別しない(recvしたものは何の型にもなる)
+	Channel = &Basic{Name: "channel"}
+
+	// fn*の関数を呼んだときの値
+	Generator = &Basic{Name: "generator"}
+
+	// async fnやsetTimeoutが返す値(awaitした結果の型は区別しない)
+	Promise = &Basic{Name: "promise"}
+
+	// mathなど。fieldの型は区別しない
+	Module = &Basic{Name: "module"}
+
+	// 注釈のないものはanyとして扱い、何とでも組み合わせられる
+	Any = &Basic{Name: "any"}
+)
+
+var basics = map[string]*Basic{
+	"int":       Int,
+	"float":     Float,
+	"bool":      Bool,
+	"string":    String,
+	"null":      Null,
+	"array":     Array,
+	"hash":      Hash,
+	"channel":   Channel,
+	"generator": Generator,
+	"promise":   Promise,
+	"module":    Module,
+	"any":       Any,
+}
+
+// structやenumの型(名前だけで区別する)
+type Named struct {
+	Name string
+}
+
+func (n *Named) String() string { return n.Name }
+
+// (int, int) -> int
+type Function struct {
+	Parameters []Type
+	Return     Type
+}
+
+func (f *Function) String() string {
+	params := []string{}
+	for _, p := range f.Parameters {
+		params = append(params, p.String())
+	}
+	return fmt.Sprintf("(%s) -> %s", strings.Join(params, ", "), f.Return.String())
+}
+
+// 片方がanyならどちらとも組み合わせられる(gradual typingのconsistency)
+func consistent(a, b Type) bool {
+	if a == Any || b == Any {
+		return true
+	}
+
+	switch a := a.(type) {
+	case *Named:
+		b, ok := b.(*Named)
+		return ok && a.Name == b.Name
+	case *Function:
+		b, ok := b.(*Function)
+		if !ok || len(a.Parameters) != len(b.Parameters) {
+			return false
+		}
+		for i := range a.Parameters {
+			if !consistent(a.Parameters[i], b.Parameters[i]) {
+				return false
+			}
+		}
+		return consistent(a.Return, b.Return)
+	default:
+		return a == b
+	}
+}
+
+// 分岐の結果などをまとめる。同じ型でなければany
+func join(a, b Type) Type {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if identical(a, b) {
+		return a
+	}
+	return Any
+}
+
+func identical(a, b Type) bool {
+	return a.String() == b.String()
+}